@@ -58,13 +58,29 @@ func DownloadFile(rawURL, destPath string) error {
 		return fmt.Errorf("downloading %s: %w: %d", rawURL, ErrHTTPStatus, resp.StatusCode)
 	}
 
+	return writeFile(destPath, resp.Body)
+}
+
+// CopyFile copies the file at srcPath, such as a cached image, to destPath.
+func CopyFile(srcPath, destPath string) error {
+	src, err := os.Open(srcPath) //nolint:gosec // srcPath is a cache file
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", srcPath, err)
+	}
+	defer src.Close()
+
+	return writeFile(destPath, src)
+}
+
+// writeFile creates destPath and fills it from r.
+func writeFile(destPath string, r io.Reader) error {
 	f, err := os.Create(destPath) //nolint:gosec // destPath is user-provided output flag
 	if err != nil {
 		return fmt.Errorf("creating %s: %w", destPath, err)
 	}
 	defer f.Close()
 
-	if _, err := io.Copy(f, resp.Body); err != nil {
+	if _, err := io.Copy(f, r); err != nil {
 		return fmt.Errorf("writing %s: %w", destPath, err)
 	}
 
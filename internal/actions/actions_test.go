@@ -67,6 +67,23 @@ func TestDownloadFileHTTPError(t *testing.T) {
 	assert.ErrorIs(t, err, ErrHTTPStatus)
 }
 
+func TestCopyFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "cached.jpg")
+	require.NoError(t, os.WriteFile(src, []byte("cached-image"), 0o600))
+
+	dest := filepath.Join(dir, "out.jpg")
+	require.NoError(t, CopyFile(src, dest))
+
+	got, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "cached-image", string(got))
+
+	require.Error(t, CopyFile(filepath.Join(dir, "missing.jpg"), dest))
+}
+
 func TestCopyToClipboard(t *testing.T) {
 	origWrite := ClipboardWrite
 	origUnsupported := ClipboardUnsupported
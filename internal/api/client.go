@@ -6,18 +6,39 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
 // DefaultBaseURL is the Memegen.link API base URL.
 const DefaultBaseURL = "https://api.memegen.link"
 
+// Retry defaults used when ClientOptions leaves them unset.
+const (
+	DefaultMaxRetries   = 3
+	DefaultMaxRetryWait = 30 * time.Second
+)
+
+// attemptTimeout limits each request attempt, reading the body included.
+// Waits between retries are bounded by MaxRetryWait instead.
+const attemptTimeout = 30 * time.Second
+
 // ClientOptions configures a new Client.
 type ClientOptions struct {
 	BaseURL   string
 	APIKey    string
 	Verbose   bool
 	UserAgent string
+
+	// MaxRetries caps retries of 429/5xx responses; nil uses DefaultMaxRetries.
+	MaxRetries *int
+	// MaxRetryWait caps the total time spent waiting between retries;
+	// 0 uses DefaultMaxRetryWait.
+	MaxRetryWait time.Duration
+	// RateLimit is the sustained request rate per second; 0 disables the limiter.
+	RateLimit float64
+	// RateBurst is the limiter bucket size; 0 uses ceil(RateLimit).
+	RateBurst int
 }
 
 // Client wraps an HTTP client for Memegen API calls.
@@ -28,9 +49,10 @@ type Client struct {
 	userAgent string
 }
 
-// NewClient builds a Client with retry transport and optional verbose logging.
+// NewClient builds a Client with retry transport, an optional rate limiter
+// and optional verbose logging.
 func NewClient(opts ClientOptions) *Client {
-	baseURL := opts.BaseURL
+	baseURL := strings.TrimRight(opts.BaseURL, "/")
 	if baseURL == "" {
 		baseURL = DefaultBaseURL
 	}
@@ -40,10 +62,28 @@ func NewClient(opts ClientOptions) *Client {
 		ua = "memelink-cli/dev"
 	}
 
+	maxRetries := DefaultMaxRetries
+	if opts.MaxRetries != nil {
+		maxRetries = max(*opts.MaxRetries, 0)
+	}
+
+	maxWait := opts.MaxRetryWait
+	if maxWait <= 0 {
+		maxWait = DefaultMaxRetryWait
+	}
+
+	base := http.DefaultTransport
+	if opts.RateLimit > 0 {
+		base = &limitTransport{base: base, limiter: newRateLimiter(opts.RateLimit, opts.RateBurst)}
+	}
+
 	var transport http.RoundTripper = &retryTransport{
-		base:       http.DefaultTransport,
-		maxRetries: 3,
+		base:       base,
+		maxRetries: maxRetries,
 		baseDelay:  1 * time.Second,
+		maxWait:    maxWait,
+		timeout:    attemptTimeout,
+		jitter:     equalJitter,
 	}
 
 	if opts.Verbose {
@@ -53,7 +93,6 @@ func NewClient(opts ClientOptions) *Client {
 	return &Client{
 		http: &http.Client{
 			Transport: transport,
-			Timeout:   30 * time.Second,
 		},
 		baseURL:   baseURL,
 		apiKey:    opts.APIKey,
@@ -61,8 +100,20 @@ func NewClient(opts ClientOptions) *Client {
 	}
 }
 
+// BaseURL returns the API base URL the client talks to.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
 // do executes an HTTP request with standard headers.
 func (c *Client) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	return c.doWithHeaders(ctx, method, path, body, nil)
+}
+
+// doWithHeaders executes an HTTP request with standard headers plus extra.
+func (c *Client) doWithHeaders(
+	ctx context.Context, method, path string, body io.Reader, extra http.Header,
+) (*http.Response, error) {
 	url := c.baseURL + path
 
 	req, err := http.NewRequestWithContext(ctx, method, url, body)
@@ -79,6 +130,12 @@ func (c *Client) do(ctx context.Context, method, path string, body io.Reader) (*
 		req.Header.Set("Content-Type", "application/json")
 	}
 
+	for k, vs := range extra {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+
 	resp, err := c.http.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("executing request: %w", err)
@@ -92,6 +149,40 @@ func (c *Client) Get(ctx context.Context, path string) (*http.Response, error) {
 	return c.do(ctx, http.MethodGet, path, nil)
 }
 
+// GetIfModified performs a conditional GET, sending v as If-None-Match and
+// If-Modified-Since. A 304 response is closed and reported as ErrNotModified.
+func (c *Client) GetIfModified(ctx context.Context, path string, v Validators) (*http.Response, error) {
+	h := http.Header{}
+	if v.ETag != "" {
+		h.Set("If-None-Match", v.ETag)
+	}
+
+	if v.LastModified != "" {
+		h.Set("If-Modified-Since", v.LastModified)
+	}
+
+	resp, err := c.doWithHeaders(ctx, http.MethodGet, path, nil, h)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		_ = resp.Body.Close()
+
+		return nil, ErrNotModified
+	}
+
+	return resp, nil
+}
+
+// validatorsOf extracts the cache validators from a response.
+func validatorsOf(resp *http.Response) Validators {
+	return Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+}
+
 // Post performs a POST request against the API with a JSON body.
 func (c *Client) Post(ctx context.Context, path string, body io.Reader) (*http.Response, error) {
 	return c.do(ctx, http.MethodPost, path, body)
@@ -198,6 +198,133 @@ func TestRetryTransport_ContextCancellation(t *testing.T) {
 	assert.Contains(t, err.Error(), "context canceled")
 }
 
+func TestRetryTransport_HonorsRetryAfter(t *testing.T) {
+	var callCount atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if callCount.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// A huge baseDelay proves the Retry-After value was used instead.
+	c := newTestClient(srv.URL, "")
+	c.http.Transport.(*retryTransport).baseDelay = time.Hour
+
+	resp, err := c.Get(context.Background(), "/limited")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), callCount.Load())
+}
+
+func TestRetryTransport_MaxWaitStopsRetrying(t *testing.T) {
+	var callCount atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		callCount.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL, "")
+	c.http.Transport.(*retryTransport).maxWait = time.Second
+
+	start := time.Now()
+	resp, err := c.Get(context.Background(), "/limited")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, int32(1), callCount.Load(), "a wait beyond the budget is not attempted")
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryTransport_TimeoutPerAttempt(t *testing.T) {
+	var callCount atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if callCount.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// The Retry-After wait is longer than the timeout, which must not count it.
+	c := newTestClient(srv.URL, "")
+	c.http.Transport.(*retryTransport).timeout = 500 * time.Millisecond
+
+	resp, err := c.Get(context.Background(), "/limited")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), callCount.Load())
+}
+
+func TestRetryTransport_TimeoutSlowAttempt(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL, "")
+	c.http.Transport.(*retryTransport).timeout = 50 * time.Millisecond
+
+	resp, err := c.Get(context.Background(), "/slow")
+	if resp != nil {
+		resp.Body.Close()
+	}
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "deadline exceeded")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{" 0 ", 0, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEqualJitter(t *testing.T) {
+	for range 100 {
+		d := equalJitter(time.Second)
+		assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+		assert.LessOrEqual(t, d, time.Second)
+	}
+
+	assert.Equal(t, time.Duration(0), equalJitter(0))
+}
+
 // --- Error detection tests ---
 
 func TestCheckImageResponse_Success(t *testing.T) {
@@ -412,6 +539,27 @@ func TestNewClient_CustomBaseURL(t *testing.T) {
 	assert.Equal(t, "https://custom.example.com", c.baseURL)
 }
 
+func TestNewClient_RetryAndRateOptions(t *testing.T) {
+	retries := 1
+	c := NewClient(ClientOptions{MaxRetries: &retries, MaxRetryWait: 5 * time.Second, RateLimit: 2, RateBurst: 4})
+
+	rt, ok := c.http.Transport.(*retryTransport)
+	require.True(t, ok)
+	assert.Equal(t, 1, rt.maxRetries)
+	assert.Equal(t, 5*time.Second, rt.maxWait)
+
+	lt, ok := rt.base.(*limitTransport)
+	require.True(t, ok)
+	assert.InDelta(t, 2.0, lt.limiter.rate, 0)
+	assert.InDelta(t, 4.0, lt.limiter.burst, 0)
+
+	c = NewClient(ClientOptions{})
+	rt = c.http.Transport.(*retryTransport)
+	assert.Equal(t, DefaultMaxRetries, rt.maxRetries)
+	assert.Equal(t, DefaultMaxRetryWait, rt.maxWait)
+	assert.Equal(t, http.DefaultTransport, rt.base, "no limiter without a rate")
+}
+
 func TestNewClient_DefaultUserAgent(t *testing.T) {
 	c := NewClient(ClientOptions{})
 	assert.Equal(t, "memelink-cli/dev", c.userAgent)
@@ -441,3 +589,8 @@ func TestPost_SetsGetBody(t *testing.T) {
 
 	assert.Equal(t, "payload", buf.String())
 }
+
+func TestNewClient_TrimsTrailingSlash(t *testing.T) {
+	c := NewClient(ClientOptions{BaseURL: "https://memes.example.com/api/"})
+	assert.Equal(t, "https://memes.example.com/api", c.BaseURL())
+}
@@ -2,10 +2,15 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 )
 
+// ErrNotModified reports a 304 answer to a conditional request: the caller's
+// cached copy is still current.
+var ErrNotModified = errors.New("not modified")
+
 // Error represents an error from the Memegen API.
 type Error struct {
 	StatusCode int
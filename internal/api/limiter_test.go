@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_BurstThenRate(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(2, 2)
+	l.now = func() time.Time { return now }
+
+	assert.Zero(t, l.reserve())
+	assert.Zero(t, l.reserve())
+	assert.Equal(t, 500*time.Millisecond, l.reserve(), "third request waits for half a second at 2 rps")
+	assert.Equal(t, time.Second, l.reserve())
+
+	now = now.Add(10 * time.Second)
+	assert.Zero(t, l.reserve(), "bucket refills after idling")
+}
+
+func TestRateLimiter_DefaultBurst(t *testing.T) {
+	assert.InDelta(t, 3.0, newRateLimiter(2.5, 0).burst, 0)
+	assert.InDelta(t, 1.0, newRateLimiter(0.2, 0).burst, 0)
+}
+
+func TestRateLimiter_WaitCanceled(t *testing.T) {
+	l := newRateLimiter(0.01, 1)
+	require.NoError(t, l.Wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := l.Wait(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "context canceled")
+}
+
+func TestLimitTransport_Throttles(t *testing.T) {
+	var callCount atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		callCount.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL, "")
+	c.http.Transport = &limitTransport{base: http.DefaultTransport, limiter: newRateLimiter(20, 1)}
+
+	start := time.Now()
+
+	for range 3 {
+		resp, err := c.Get(context.Background(), "/")
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, int32(3), callCount.Load())
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond, "two waits of 50ms at 20 rps")
+}
@@ -4,8 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/dedene/memelink-cli/internal/encoding"
 )
 
 // GenerateAutomatic posts text to /images/automatic and returns the
@@ -111,70 +119,249 @@ func AppendQueryParams(baseURL string, params url.Values) (string, error) {
 	return u.String(), nil
 }
 
+// BuildURL constructs the image URL for a template-based meme locally,
+// without a round trip to the API. Text lines are normalized and encoded with
+// the Memegen escaping rules (empty lines become "_"); font, layout and style
+// are carried as query parameters. Extension defaults to "jpg".
+func (c *Client) BuildURL(req GenerateRequest) string {
+	ext := req.Extension
+	if ext == "" {
+		ext = "jpg"
+	}
+
+	segments := make([]string, 0, len(req.Text)+2)
+	segments = append(segments, "images", url.PathEscape(req.TemplateID))
+
+	for _, line := range req.Text {
+		segments = append(segments, encodeLine(line))
+	}
+
+	u := c.baseURL + "/" + strings.Join(segments, "/") + "." + ext
+
+	q := url.Values{}
+	if req.Font != "" {
+		q.Set("font", req.Font)
+	}
+
+	if req.Layout != "" && req.Layout != "default" {
+		q.Set("layout", req.Layout)
+	}
+
+	if len(req.Style) > 0 {
+		q.Set("style", strings.Join(req.Style, ","))
+	}
+
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	return u
+}
+
+// encodeLine converts a single text line to a URL path segment.
+func encodeLine(line string) string {
+	if line == "" {
+		return "_"
+	}
+
+	return url.PathEscape(encoding.Encode(encoding.NormalizeQuotes(line)))
+}
+
+// ErrNotMemeURL indicates a URL that has no /images/{template} path.
+var ErrNotMemeURL = errors.New("not a memegen image URL")
+
+// ParseURL breaks a Memegen image URL back into its template ID, decoded text
+// lines, extension and presentation query parameters. It is the inverse of
+// BuildURL plus AppendQueryParams. A lone "_" segment decodes to an empty line.
+func ParseURL(rawURL string) (*MemeURL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing URL: %w", err)
+	}
+
+	segments := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+
+	// Self-hosted instances may mount the API under a prefix, so look for the
+	// "images" segment rather than requiring it first.
+	start := -1
+
+	for i, s := range segments {
+		if s == "images" {
+			start = i + 1
+
+			break
+		}
+	}
+
+	if start < 0 || start >= len(segments) {
+		return nil, fmt.Errorf("%w: %s", ErrNotMemeURL, rawURL)
+	}
+
+	segments = segments[start:]
+
+	last := segments[len(segments)-1]
+	ext := path.Ext(last)
+	segments[len(segments)-1] = strings.TrimSuffix(last, ext)
+
+	out := &MemeURL{
+		Extension: strings.TrimPrefix(ext, "."),
+		Text:      make([]string, 0, len(segments)-1),
+	}
+
+	for i, s := range segments {
+		seg, err := url.PathUnescape(s)
+		if err != nil {
+			return nil, fmt.Errorf("unescaping %q: %w", s, err)
+		}
+
+		if i == 0 {
+			out.TemplateID = seg
+
+			continue
+		}
+
+		out.Text = append(out.Text, decodeLine(seg))
+	}
+
+	if err := parseQuery(u.Query(), out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// decodeLine converts a URL path segment back to a text line.
+func decodeLine(seg string) string {
+	if seg == "_" {
+		return ""
+	}
+
+	return encoding.Decode(seg)
+}
+
+// parseQuery copies known presentation parameters from q into m.
+func parseQuery(q url.Values, m *MemeURL) error {
+	m.Font = q.Get("font")
+	m.Layout = q.Get("layout")
+	m.Background = q.Get("background")
+	m.Center = q.Get("center")
+	m.Scale = q.Get("scale")
+	m.Safe = q.Get("safe") == "true"
+
+	for _, v := range q["style"] {
+		m.Style = append(m.Style, strings.Split(v, ",")...)
+	}
+
+	if v := q.Get("color"); v != "" {
+		m.Color = strings.Split(v, ",")
+	}
+
+	for key, dst := range map[string]*int{"width": &m.Width, "height": &m.Height} {
+		v := q.Get(key)
+		if v == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", key, v, err)
+		}
+
+		*dst = n
+	}
+
+	return nil
+}
+
 // ListTemplates fetches all meme templates from GET /templates.
 // The optional filter query-param narrows results server-side.
 func (c *Client) ListTemplates(ctx context.Context, filter string) ([]Template, error) {
+	out, _, err := c.ListTemplatesIfModified(ctx, filter, Validators{})
+
+	return out, err
+}
+
+// ListTemplatesIfModified is ListTemplates as a conditional request. It
+// returns the response's validators, or ErrNotModified when the copy
+// described by v is still current.
+func (c *Client) ListTemplatesIfModified(ctx context.Context, filter string, v Validators) ([]Template, Validators, error) {
 	path := "/templates"
 	if filter != "" {
 		path += "?filter=" + url.QueryEscape(filter)
 	}
 
-	resp, err := c.Get(ctx, path)
+	resp, err := c.GetIfModified(ctx, path, v)
 	if err != nil {
-		return nil, fmt.Errorf("listing templates: %w", err)
+		return nil, v, fmt.Errorf("listing templates: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if err := checkJSONResponse(resp); err != nil {
-		return nil, err
+		return nil, v, err
 	}
 
 	var out []Template
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return nil, fmt.Errorf("decoding templates: %w", err)
+		return nil, v, fmt.Errorf("decoding templates: %w", err)
 	}
 
-	return out, nil
+	return out, validatorsOf(resp), nil
 }
 
 // GetTemplate fetches a single template by ID from GET /templates/{id}.
 func (c *Client) GetTemplate(ctx context.Context, id string) (*Template, error) {
-	resp, err := c.Get(ctx, "/templates/"+url.PathEscape(id))
+	out, _, err := c.GetTemplateIfModified(ctx, id, Validators{})
+
+	return out, err
+}
+
+// GetTemplateIfModified is GetTemplate as a conditional request (see
+// ListTemplatesIfModified).
+func (c *Client) GetTemplateIfModified(ctx context.Context, id string, v Validators) (*Template, Validators, error) {
+	resp, err := c.GetIfModified(ctx, "/templates/"+url.PathEscape(id), v)
 	if err != nil {
-		return nil, fmt.Errorf("getting template %q: %w", id, err)
+		return nil, v, fmt.Errorf("getting template %q: %w", id, err)
 	}
 	defer resp.Body.Close()
 
 	if err := checkJSONResponse(resp); err != nil {
-		return nil, err
+		return nil, v, err
 	}
 
 	var out Template
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return nil, fmt.Errorf("decoding template %q: %w", id, err)
+		return nil, v, fmt.Errorf("decoding template %q: %w", id, err)
 	}
 
-	return &out, nil
+	return &out, validatorsOf(resp), nil
 }
 
 // ListFonts fetches all fonts from GET /fonts.
 func (c *Client) ListFonts(ctx context.Context) ([]Font, error) {
-	resp, err := c.Get(ctx, "/fonts")
+	out, _, err := c.ListFontsIfModified(ctx, Validators{})
+
+	return out, err
+}
+
+// ListFontsIfModified is ListFonts as a conditional request (see
+// ListTemplatesIfModified).
+func (c *Client) ListFontsIfModified(ctx context.Context, v Validators) ([]Font, Validators, error) {
+	resp, err := c.GetIfModified(ctx, "/fonts", v)
 	if err != nil {
-		return nil, fmt.Errorf("listing fonts: %w", err)
+		return nil, v, fmt.Errorf("listing fonts: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if err := checkJSONResponse(resp); err != nil {
-		return nil, err
+		return nil, v, err
 	}
 
 	var out []Font
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return nil, fmt.Errorf("decoding fonts: %w", err)
+		return nil, v, fmt.Errorf("decoding fonts: %w", err)
 	}
 
-	return out, nil
+	return out, validatorsOf(resp), nil
 }
 
 // GetFont fetches a single font by ID from GET /fonts/{id}.
@@ -196,3 +383,97 @@ func (c *Client) GetFont(ctx context.Context, id string) (*Font, error) {
 
 	return &out, nil
 }
+
+// FetchImage downloads an absolute image URL, such as a template's blank,
+// through the client's transport so rate limiting and retries apply, and
+// copies the body to w.
+func (c *Client) FetchImage(ctx context.Context, rawURL string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkImageResponse(resp); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("reading image: %w", err)
+	}
+
+	return nil
+}
+
+// GetServerInfo fetches the API root (GET /), which lists the server's
+// endpoints, plus the version from the root or the OpenAPI document.
+func (c *Client) GetServerInfo(ctx context.Context) (*ServerInfo, error) {
+	resp, err := c.Get(ctx, "/")
+	if err != nil {
+		return nil, fmt.Errorf("getting api root: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkJSONResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var root map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&root); err != nil {
+		return nil, fmt.Errorf("decoding api root: %w", err)
+	}
+
+	info := &ServerInfo{Endpoints: make(map[string]string)}
+
+	for k, v := range root {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		if k == "version" {
+			info.Version = s
+		} else {
+			info.Endpoints[k] = s
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = c.openAPIVersion(ctx)
+	}
+
+	return info, nil
+}
+
+// openAPIVersion returns info.version from /docs/openapi.json, or "" when
+// the server does not publish one (best-effort).
+func (c *Client) openAPIVersion(ctx context.Context) string {
+	resp, err := c.Get(ctx, "/docs/openapi.json")
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+
+	var doc struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return ""
+	}
+
+	return doc.Info.Version
+}
@@ -305,6 +305,144 @@ func TestAppendQueryParams_EncodesSpecialChars(t *testing.T) {
 	assert.Equal(t, "#ff0000", u.Query().Get("color"))
 }
 
+// --- BuildURL tests ---
+
+func TestBuildURL_Lines(t *testing.T) {
+	c := newTestClient("https://api.memegen.link", "")
+
+	got := c.BuildURL(GenerateRequest{
+		TemplateID: "drake",
+		Text:       []string{"top text", "bottom-line?"},
+		Extension:  "png",
+	})
+	assert.Equal(t, "https://api.memegen.link/images/drake/top_text/bottom--line~q.png", got)
+}
+
+func TestBuildURL_EmptyAndNoLines(t *testing.T) {
+	c := newTestClient("https://api.memegen.link", "")
+
+	assert.Equal(t, "https://api.memegen.link/images/fry/_/bottom.jpg",
+		c.BuildURL(GenerateRequest{TemplateID: "fry", Text: []string{"", "bottom"}}))
+	assert.Equal(t, "https://api.memegen.link/images/fry.jpg",
+		c.BuildURL(GenerateRequest{TemplateID: "fry"}))
+}
+
+func TestBuildURL_EscapesPath(t *testing.T) {
+	c := newTestClient("https://api.memegen.link", "")
+
+	got := c.BuildURL(GenerateRequest{
+		TemplateID: "drake",
+		Text:       []string{"caf\u00e9 \u201cquote\u201d", "a/b"},
+		Extension:  "jpg",
+	})
+
+	u, err := url.Parse(got)
+	require.NoError(t, err)
+	assert.Equal(t, "/images/drake/caf\u00e9_''quote''/a~sb.jpg", u.Path)
+}
+
+func TestBuildURL_QueryParams(t *testing.T) {
+	c := newTestClient("https://api.memegen.link", "")
+
+	got := c.BuildURL(GenerateRequest{
+		TemplateID: "drake",
+		Text:       []string{"a", "b"},
+		Extension:  "gif",
+		Font:       "impact",
+		Layout:     "top",
+		Style:      []string{"animated", "https://example.com/hat.png"},
+	})
+
+	u, err := url.Parse(got)
+	require.NoError(t, err)
+	assert.Equal(t, "impact", u.Query().Get("font"))
+	assert.Equal(t, "top", u.Query().Get("layout"))
+	assert.Equal(t, "animated,https://example.com/hat.png", u.Query().Get("style"))
+}
+
+func TestBuildURL_DefaultLayoutOmitted(t *testing.T) {
+	c := newTestClient("https://api.memegen.link", "")
+
+	got := c.BuildURL(GenerateRequest{TemplateID: "drake", Text: []string{"a"}, Layout: "default"})
+	assert.NotContains(t, got, "layout")
+}
+
+// --- ParseURL tests ---
+
+func TestParseURL_Basic(t *testing.T) {
+	m, err := ParseURL("https://api.memegen.link/images/drake/top_text/bottom--line~q.png")
+	require.NoError(t, err)
+
+	assert.Equal(t, "drake", m.TemplateID)
+	assert.Equal(t, []string{"top text", "bottom-line?"}, m.Text)
+	assert.Equal(t, "png", m.Extension)
+}
+
+func TestParseURL_EmptyLineAndNoLines(t *testing.T) {
+	m, err := ParseURL("https://api.memegen.link/images/fry/_/bottom.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "bottom"}, m.Text)
+
+	m, err = ParseURL("https://api.memegen.link/images/fry.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "fry", m.TemplateID)
+	assert.Empty(t, m.Text)
+	assert.Equal(t, "jpg", m.Extension)
+}
+
+func TestParseURL_QueryParams(t *testing.T) {
+	m, err := ParseURL("https://api.memegen.link/images/drake/a/b.gif" +
+		"?font=impact&layout=top&style=animated&color=red,blue&width=400&height=300&center=0.5,0.5&scale=0.25&safe=true")
+	require.NoError(t, err)
+
+	assert.Equal(t, "impact", m.Font)
+	assert.Equal(t, "top", m.Layout)
+	assert.Equal(t, []string{"animated"}, m.Style)
+	assert.Equal(t, []string{"red", "blue"}, m.Color)
+	assert.Equal(t, 400, m.Width)
+	assert.Equal(t, 300, m.Height)
+	assert.Equal(t, "0.5,0.5", m.Center)
+	assert.Equal(t, "0.25", m.Scale)
+	assert.True(t, m.Safe)
+}
+
+func TestParseURL_PathPrefix(t *testing.T) {
+	m, err := ParseURL("https://memes.example.com/api/images/buzz/hi.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "buzz", m.TemplateID)
+	assert.Equal(t, []string{"hi"}, m.Text)
+}
+
+func TestParseURL_NotMemeURL(t *testing.T) {
+	_, err := ParseURL("https://example.com/photo.jpg")
+	require.ErrorIs(t, err, ErrNotMemeURL)
+}
+
+func TestParseURL_InvalidWidth(t *testing.T) {
+	_, err := ParseURL("https://api.memegen.link/images/drake/a.jpg?width=wide")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid width")
+}
+
+func TestParseURL_RoundTripsBuildURL(t *testing.T) {
+	c := newTestClient("https://api.memegen.link", "")
+	req := GenerateRequest{
+		TemplateID: "drake",
+		Text:       []string{"100% under_score", "a/b & <c>"},
+		Extension:  "webp",
+		Font:       "impact",
+		Style:      []string{"animated"},
+	}
+
+	m, err := ParseURL(c.BuildURL(req))
+	require.NoError(t, err)
+	assert.Equal(t, req.TemplateID, m.TemplateID)
+	assert.Equal(t, req.Text, m.Text)
+	assert.Equal(t, req.Extension, m.Extension)
+	assert.Equal(t, req.Font, m.Font)
+	assert.Equal(t, req.Style, m.Style)
+}
+
 // --- ListTemplates tests ---
 
 func TestListTemplates_Success(t *testing.T) {
@@ -418,6 +556,69 @@ func TestListFonts_APIError(t *testing.T) {
 	assert.Nil(t, fonts)
 }
 
+// --- Conditional request tests ---
+
+func TestListTemplatesIfModified_SendsValidators(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, `"v1"`, r.Header.Get("If-None-Match"))
+		assert.Equal(t, "Mon, 02 Jan 2006 15:04:05 GMT", r.Header.Get("If-Modified-Since"))
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL, "")
+	v := Validators{ETag: `"v1"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT"}
+
+	templates, got, err := c.ListTemplatesIfModified(context.Background(), "", v)
+	require.ErrorIs(t, err, ErrNotModified)
+	assert.Nil(t, templates)
+	assert.Equal(t, v, got)
+}
+
+func TestListTemplatesIfModified_ReturnsNewValidators(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("If-None-Match"), "no validators, no conditional headers")
+		w.Header().Set("ETag", `"v2"`)
+		w.Header().Set("Last-Modified", "Tue, 03 Jan 2006 15:04:05 GMT")
+		_, _ = w.Write([]byte(`[{"id":"drake","name":"Drake","lines":2}]`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL, "")
+
+	templates, v, err := c.ListTemplatesIfModified(context.Background(), "", Validators{})
+	require.NoError(t, err)
+	assert.Len(t, templates, 1)
+	assert.Equal(t, Validators{ETag: `"v2"`, LastModified: "Tue, 03 Jan 2006 15:04:05 GMT"}, v)
+}
+
+func TestGetTemplateIfModified_NotModified(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/templates/drake", r.URL.Path)
+		assert.Equal(t, `"d1"`, r.Header.Get("If-None-Match"))
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL, "")
+
+	_, _, err := c.GetTemplateIfModified(context.Background(), "drake", Validators{ETag: `"d1"`})
+	require.ErrorIs(t, err, ErrNotModified)
+}
+
+func TestListFontsIfModified_NotModified(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, `"f1"`, r.Header.Get("If-None-Match"))
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL, "")
+
+	_, _, err := c.ListFontsIfModified(context.Background(), Validators{ETag: `"f1"`})
+	require.ErrorIs(t, err, ErrNotModified)
+}
+
 // --- GetFont tests ---
 
 func TestGetFont_Success(t *testing.T) {
@@ -462,3 +663,62 @@ func TestGetFont_NotFound(t *testing.T) {
 	require.Error(t, err)
 	assert.Nil(t, font)
 }
+
+func TestGetServerInfo_VersionFromOpenAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`{"templates":"http://x/templates/","fonts":"http://x/fonts/","images":"http://x/images/","count":3}`))
+		case "/docs/openapi.json":
+			_, _ = w.Write([]byte(`{"info":{"title":"Memegen.link","version":"11.0.0"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	info, err := newTestClient(srv.URL, "").GetServerInfo(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "11.0.0", info.Version)
+	assert.Equal(t, map[string]string{
+		"templates": "http://x/templates/",
+		"fonts":     "http://x/fonts/",
+		"images":    "http://x/images/",
+	}, info.Endpoints)
+}
+
+func TestGetServerInfo_VersionInRoot(t *testing.T) {
+	openAPICalled := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			openAPICalled = true
+		}
+
+		_, _ = w.Write([]byte(`{"version":"10.2","images":"http://x/images/"}`))
+	}))
+	defer srv.Close()
+
+	info, err := newTestClient(srv.URL, "").GetServerInfo(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "10.2", info.Version)
+	assert.False(t, openAPICalled)
+}
+
+func TestGetServerInfo_NoVersion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"images":"http://x/images/"}`))
+	}))
+	defer srv.Close()
+
+	info, err := newTestClient(srv.URL, "").GetServerInfo(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, info.Version)
+}
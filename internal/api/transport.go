@@ -1,9 +1,14 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,13 +17,22 @@ type retryTransport struct {
 	base       http.RoundTripper
 	maxRetries int
 	baseDelay  time.Duration
+	maxWait    time.Duration                     // total wait budget across retries; 0 means no cap
+	timeout    time.Duration                     // per-attempt limit, body read included; 0 means none
+	jitter     func(time.Duration) time.Duration // randomizes backoff delays; nil disables jitter
+	now        func() time.Time                  // clock for HTTP-date Retry-After; nil uses time.Now
 }
 
 // RoundTrip implements http.RoundTripper with retry logic for 429 and 5xx responses.
+// A Retry-After header takes precedence over exponential backoff. When the next
+// wait would exceed maxWait the last response is returned as-is. The timeout
+// applies to each attempt, so waits between retries do not count against it.
 func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	var resp *http.Response
 	var err error
 
+	var waited time.Duration
+
 	for attempt := range t.maxRetries + 1 {
 		// Clone body for retry (body is consumed on read).
 		if req.Body != nil && req.GetBody != nil {
@@ -30,32 +44,123 @@ func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 			req.Body = body
 		}
 
-		resp, err = t.base.RoundTrip(req)
+		resp, err = t.attempt(req)
 		if err != nil {
 			return nil, fmt.Errorf("round trip: %w", err)
 		}
 
-		if !shouldRetry(resp.StatusCode) {
+		if !shouldRetry(resp.StatusCode) || attempt == t.maxRetries {
+			return resp, nil
+		}
+
+		delay := t.delay(attempt, resp)
+		if t.maxWait > 0 && waited+delay > t.maxWait {
 			return resp, nil
 		}
 
-		if attempt < t.maxRetries {
-			// Close response body before retry to prevent connection leak.
-			_ = resp.Body.Close()
+		waited += delay
 
-			delay := t.baseDelay * (1 << attempt) //nolint:gosec // attempt is bounded by maxRetries (small int)
+		// Close response body before retry to prevent connection leak.
+		_ = resp.Body.Close()
 
-			select {
-			case <-req.Context().Done():
-				return nil, fmt.Errorf("retry wait: %w", req.Context().Err())
-			case <-time.After(delay):
-			}
+		select {
+		case <-req.Context().Done():
+			return nil, fmt.Errorf("retry wait: %w", req.Context().Err())
+		case <-time.After(delay):
 		}
 	}
 
 	return resp, nil
 }
 
+// attempt sends req once under the per-attempt timeout. The timeout is
+// released when the response body is closed, so it covers reading it too.
+func (t *retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+
+		return nil, err
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// cancelBody releases an attempt's timeout when the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
+
+// delay returns how long to wait before retrying after resp.
+func (t *retryTransport) delay(attempt int, resp *http.Response) time.Duration {
+	now := time.Now
+	if t.now != nil {
+		now = t.now
+	}
+
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now()); ok {
+		return d
+	}
+
+	d := t.baseDelay * (1 << attempt) //nolint:gosec // attempt is bounded by maxRetries (small int)
+	if t.jitter != nil {
+		d = t.jitter(d)
+	}
+
+	return d
+}
+
+// parseRetryAfter parses a Retry-After value given as delay-seconds or an
+// HTTP-date. Dates in the past yield zero.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+
+		return time.Duration(secs) * time.Second, true
+	}
+
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(at.Sub(now), 0), true
+}
+
+// equalJitter keeps half of d and randomizes the other half, spreading
+// retries from parallel callers while preserving the backoff floor.
+func equalJitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+
+	return half + rand.N(half+1) //nolint:gosec // jitter does not need a CSPRNG
+}
+
 // shouldRetry returns true for status codes that warrant a retry.
 func shouldRetry(statusCode int) bool {
 	return statusCode == http.StatusTooManyRequests ||
@@ -64,3 +64,35 @@ type Font struct {
 	Filename string  `json:"filename"`
 	Self     string  `json:"_self"`
 }
+
+// MemeURL describes the components of a Memegen image URL, as recovered by
+// ParseURL. Text lines are decoded back to plain text.
+type MemeURL struct {
+	TemplateID string   `json:"template_id"`
+	Text       []string `json:"text"`
+	Extension  string   `json:"extension"`
+	Font       string   `json:"font,omitempty"`
+	Layout     string   `json:"layout,omitempty"`
+	Style      []string `json:"style,omitempty"`
+	Background string   `json:"background,omitempty"`
+	Color      []string `json:"color,omitempty"`
+	Width      int      `json:"width,omitempty"`
+	Height     int      `json:"height,omitempty"`
+	Center     string   `json:"center,omitempty"`
+	Scale      string   `json:"scale,omitempty"`
+	Safe       bool     `json:"safe,omitempty"`
+}
+
+// ServerInfo describes a Memegen server: its version (when published) and
+// the endpoints listed at the API root.
+type ServerInfo struct {
+	Version   string            `json:"version,omitempty"`
+	Endpoints map[string]string `json:"endpoints"`
+}
+
+// Validators are the HTTP cache validators of a JSON response. Sending them
+// back lets the server answer 304 Not Modified instead of the full body.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
@@ -1,101 +1,163 @@
-// Package cache provides file-based template caching with TTL support.
+// Package cache provides file-based caching of API data with TTL support.
 package cache
 
 import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/atomicfile"
 )
 
-// TemplateCache is the on-disk representation of cached templates.
-type TemplateCache struct {
-	Templates []api.Template `json:"templates"`
-	FetchedAt time.Time      `json:"fetched_at"`
+// Entry is a cached API response of any shape, with the validators needed
+// to revalidate it.
+type Entry[T any] struct {
+	Data      T         `json:"data"`
+	FetchedAt time.Time `json:"fetched_at"`
+	api.Validators
 }
 
-// LoadTemplates reads the cache file and returns templates if fresh.
-// Returns (nil, nil) when: file missing, JSON corrupt, or TTL expired.
-// Only returns a non-nil error for unexpected read failures.
-func LoadTemplates(path string, ttl time.Duration) ([]api.Template, error) {
-	data, err := os.ReadFile(path) //nolint:gosec // path is internal cache, not untrusted input
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil, nil
-		}
+// Freshness classifies a cache entry by age.
+type Freshness int
+
+const (
+	// Missing means there is no usable entry.
+	Missing Freshness = iota
+	// Fresh entries are within their TTL.
+	Fresh
+	// Stale entries are past the TTL but within max-stale: they may be
+	// served while a refresh runs, or when the refresh fails.
+	Stale
+	// Expired entries are past TTL plus max-stale and must not be served.
+	Expired
+)
 
-		return nil, fmt.Errorf("reading cache: %w", err)
+// String returns the lowercase name of f.
+func (f Freshness) String() string {
+	switch f {
+	case Fresh:
+		return "fresh"
+	case Stale:
+		return "stale"
+	case Expired:
+		return "expired"
+	default:
+		return "missing"
 	}
+}
 
-	var tc TemplateCache
-	if err := json.Unmarshal(data, &tc); err != nil {
-		// Corrupt cache -- treat as miss.
-		return nil, nil //nolint:nilerr
+// Classify returns the freshness of an entry fetched at fetchedAt.
+func Classify(fetchedAt time.Time, ttl, maxStale time.Duration) Freshness {
+	if fetchedAt.IsZero() {
+		return Missing
 	}
 
-	if time.Since(tc.FetchedAt) > ttl {
-		return nil, nil
-	}
+	age := time.Since(fetchedAt)
 
-	return tc.Templates, nil
+	switch {
+	case age <= ttl:
+		return Fresh
+	case age <= ttl+maxStale:
+		return Stale
+	default:
+		return Expired
+	}
 }
 
-// SaveTemplates writes templates to the cache file atomically.
-func SaveTemplates(path string, templates []api.Template) error {
-	tc := TemplateCache{
-		Templates: templates,
-		FetchedAt: time.Now(),
-	}
+// storedEntry is Entry as read from disk: Data is nil when the file has
+// none, such as a template list cached by older versions.
+type storedEntry[T any] struct {
+	Data      *T        `json:"data"`
+	FetchedAt time.Time `json:"fetched_at"`
+	api.Validators
+}
 
-	data, err := json.MarshalIndent(tc, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshaling cache: %w", err)
+// LoadEntry reads a cached entry regardless of its age. Returns (nil, nil)
+// when the file is missing, corrupt or holds no data.
+func LoadEntry[T any](path string) (*Entry[T], error) {
+	var e storedEntry[T]
+	if ok, err := readJSON(path, &e); !ok || e.Data == nil {
+		return nil, err
 	}
 
-	data = append(data, '\n')
+	return &Entry[T]{Data: *e.Data, FetchedAt: e.FetchedAt, Validators: e.Validators}, nil
+}
 
-	return atomicWrite(path, data)
+// SaveEntry writes data and its validators to path, stamped as fetched now.
+func SaveEntry[T any](path string, data T, v api.Validators) error {
+	return writeJSON(path, Entry[T]{Data: data, FetchedAt: time.Now(), Validators: v})
 }
 
-// atomicWrite writes data to path via temp-file + rename.
-func atomicWrite(path string, data []byte) error {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o750); err != nil {
-		return fmt.Errorf("creating directory: %w", err)
+// Usage reports the number of files and total bytes at path, which may be
+// a file or a directory (walked recursively). A missing path is empty.
+func Usage(path string) (int, int64, error) {
+	var files int
+
+	var size int64
+
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		files++
+		size += info.Size()
+
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
 	}
 
-	tmp, err := os.CreateTemp(dir, ".tmp-*")
 	if err != nil {
-		return fmt.Errorf("creating temp file: %w", err)
+		return 0, 0, fmt.Errorf("measuring %s: %w", path, err)
 	}
 
-	tmpPath := tmp.Name()
+	return files, size, nil
+}
 
-	defer func() {
-		if tmpPath != "" {
-			_ = os.Remove(tmpPath)
+// readJSON decodes the file at path into out. It reports false with a nil
+// error for a missing or corrupt file, which callers treat as a miss.
+func readJSON(path string, out any) (bool, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is internal cache, not untrusted input
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
 		}
-	}()
-
-	if _, err := tmp.Write(data); err != nil {
-		_ = tmp.Close()
 
-		return fmt.Errorf("writing temp file: %w", err)
+		return false, fmt.Errorf("reading cache: %w", err)
 	}
 
-	if err := tmp.Close(); err != nil {
-		return fmt.Errorf("closing temp file: %w", err)
+	if err := json.Unmarshal(data, out); err != nil {
+		// Corrupt cache -- treat as miss.
+		return false, nil //nolint:nilerr
 	}
 
-	if err := os.Rename(tmpPath, path); err != nil {
-		return fmt.Errorf("renaming temp file: %w", err)
+	return true, nil
+}
+
+// writeJSON marshals v as indented JSON and writes it atomically.
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling cache: %w", err)
 	}
 
-	tmpPath = "" // prevent deferred cleanup
+	data = append(data, '\n')
 
-	return nil
+	return atomicfile.Write(path, data)
 }
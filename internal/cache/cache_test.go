@@ -1,7 +1,6 @@
 package cache
 
 import (
-	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -13,91 +12,74 @@ import (
 	"github.com/dedene/memelink-cli/internal/api"
 )
 
-var testTemplates = []api.Template{
-	{ID: "drake", Name: "Drake Hotline Bling", Lines: 2, Styles: []string{"default", "animated"}},
-	{ID: "fry", Name: "Futurama Fry", Lines: 2, Styles: []string{"default"}},
-}
+func TestEntryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "fonts.json")
+	fonts := []api.Font{{ID: "impact", Filename: "impact.ttf"}}
 
-func TestLoadMissing(t *testing.T) {
-	path := filepath.Join(t.TempDir(), "nonexistent.json")
+	require.NoError(t, SaveEntry(path, fonts, api.Validators{ETag: `"f"`}))
 
-	templates, err := LoadTemplates(path, 24*time.Hour)
+	e, err := LoadEntry[[]api.Font](path)
 	require.NoError(t, err)
-	assert.Nil(t, templates)
+	require.NotNil(t, e)
+	assert.Equal(t, fonts, e.Data)
+	assert.Equal(t, `"f"`, e.ETag)
+	assert.WithinDuration(t, time.Now(), e.FetchedAt, time.Minute)
 }
 
-func TestSaveLoadRoundTrip(t *testing.T) {
-	path := filepath.Join(t.TempDir(), "templates.json")
-
-	require.NoError(t, SaveTemplates(path, testTemplates))
+func TestLoadEntryMissingAndCorrupt(t *testing.T) {
+	dir := t.TempDir()
 
-	loaded, err := LoadTemplates(path, 24*time.Hour)
+	e, err := LoadEntry[[]api.Font](filepath.Join(dir, "missing.json"))
 	require.NoError(t, err)
-	require.Len(t, loaded, 2)
-	assert.Equal(t, "drake", loaded[0].ID)
-	assert.Equal(t, "Drake Hotline Bling", loaded[0].Name)
-	assert.Equal(t, 2, loaded[0].Lines)
-	assert.Equal(t, []string{"default", "animated"}, loaded[0].Styles)
-	assert.Equal(t, "fry", loaded[1].ID)
-}
+	assert.Nil(t, e)
 
-func TestLoadExpired(t *testing.T) {
-	path := filepath.Join(t.TempDir(), "templates.json")
-
-	// Write cache with FetchedAt in the past.
-	tc := TemplateCache{
-		Templates: testTemplates,
-		FetchedAt: time.Now().Add(-48 * time.Hour),
-	}
+	corrupt := filepath.Join(dir, "corrupt.json")
+	require.NoError(t, os.WriteFile(corrupt, []byte("{nope"), 0o644))
 
-	data, err := json.MarshalIndent(tc, "", "  ")
+	e, err = LoadEntry[[]api.Font](corrupt)
 	require.NoError(t, err)
-
-	require.NoError(t, os.WriteFile(path, data, 0o644))
-
-	loaded, loadErr := LoadTemplates(path, 24*time.Hour)
-	require.NoError(t, loadErr)
-	assert.Nil(t, loaded)
+	assert.Nil(t, e)
 }
 
-func TestLoadCorrupt(t *testing.T) {
+func TestLoadEntryWithoutData(t *testing.T) {
+	// A template list cached by older versions has no "data" field.
 	path := filepath.Join(t.TempDir(), "templates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"templates":[{"id":"drake"}],"fetched_at":"2026-01-01T00:00:00Z"}`), 0o644))
 
-	require.NoError(t, os.WriteFile(path, []byte("{{{not json"), 0o644))
-
-	loaded, err := LoadTemplates(path, 24*time.Hour)
+	e, err := LoadEntry[[]api.Template](path)
 	require.NoError(t, err)
-	assert.Nil(t, loaded)
+	assert.Nil(t, e, "read as a miss, not as an empty list")
 }
 
-func TestLoadFresh(t *testing.T) {
-	path := filepath.Join(t.TempDir(), "templates.json")
-
-	require.NoError(t, SaveTemplates(path, testTemplates))
+func TestClassify(t *testing.T) {
+	now := time.Now()
 
-	loaded, err := LoadTemplates(path, 24*time.Hour)
-	require.NoError(t, err)
-	require.NotNil(t, loaded)
-	assert.Len(t, loaded, 2)
+	assert.Equal(t, Missing, Classify(time.Time{}, time.Hour, time.Hour))
+	assert.Equal(t, Fresh, Classify(now.Add(-30*time.Minute), time.Hour, time.Hour))
+	assert.Equal(t, Stale, Classify(now.Add(-90*time.Minute), time.Hour, time.Hour))
+	assert.Equal(t, Expired, Classify(now.Add(-3*time.Hour), time.Hour, time.Hour))
+	assert.Equal(t, Expired, Classify(now.Add(-90*time.Minute), time.Hour, 0), "max-stale 0 disables stale serving")
 }
 
-func TestSaveCreatesDir(t *testing.T) {
-	dir := filepath.Join(t.TempDir(), "nested", "deep")
-	path := filepath.Join(dir, "templates.json")
+func TestUsage(t *testing.T) {
+	dir := t.TempDir()
 
-	require.NoError(t, SaveTemplates(path, testTemplates))
-
-	_, err := os.Stat(path)
+	files, size, err := Usage(filepath.Join(dir, "missing"))
 	require.NoError(t, err)
-}
+	assert.Zero(t, files)
+	assert.Zero(t, size)
 
-func TestLoadZeroTTL(t *testing.T) {
-	path := filepath.Join(t.TempDir(), "templates.json")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte("1234"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b.png"), []byte("123456"), 0o644))
 
-	require.NoError(t, SaveTemplates(path, testTemplates))
+	files, size, err = Usage(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, files)
+	assert.Equal(t, int64(10), size)
 
-	// 0 TTL means always expired.
-	loaded, err := LoadTemplates(path, 0)
+	files, size, err = Usage(filepath.Join(dir, "a.json"))
 	require.NoError(t, err)
-	assert.Nil(t, loaded)
+	assert.Equal(t, 1, files)
+	assert.Equal(t, int64(4), size)
 }
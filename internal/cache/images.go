@@ -0,0 +1,199 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ImageFetcher downloads the image at rawURL into w.
+type ImageFetcher func(ctx context.Context, rawURL string, w io.Writer) error
+
+// Images is a content-addressed disk cache of downloaded images. Files are
+// named by the SHA-256 of their URL, so the same meme or blank is fetched
+// once no matter which command asks for it. The total size is kept under
+// maxBytes by evicting the least recently used files; a cache hit refreshes
+// the file's modification time.
+type Images struct {
+	dir      string
+	maxBytes int64
+	fetch    ImageFetcher
+	now      func() time.Time
+
+	mu sync.Mutex // serializes pruning
+}
+
+// NewImages returns an image cache in dir holding at most maxBytes, which
+// downloads misses with fetch.
+func NewImages(dir string, maxBytes int64, fetch ImageFetcher) *Images {
+	return &Images{dir: dir, maxBytes: maxBytes, fetch: fetch, now: time.Now}
+}
+
+// Dir returns the cache directory.
+func (c *Images) Dir() string {
+	return c.dir
+}
+
+// Path returns where the image at rawURL is cached: the hex SHA-256 of the
+// URL plus the extension of its path.
+func (c *Images) Path(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+
+	ext := ""
+	if u, err := url.Parse(rawURL); err == nil {
+		ext = strings.ToLower(path.Ext(u.Path))
+	}
+
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+ext)
+}
+
+// Has reports whether rawURL is cached.
+func (c *Images) Has(rawURL string) bool {
+	_, err := os.Stat(c.Path(rawURL))
+
+	return err == nil
+}
+
+// Fetch returns the path of the cached image at rawURL, downloading it
+// first on a miss. Downloads go through a temp file, so an interrupted
+// fetch never leaves a truncated image behind.
+func (c *Images) Fetch(ctx context.Context, rawURL string) (string, error) {
+	dest := c.Path(rawURL)
+
+	if _, err := os.Stat(dest); err == nil {
+		now := c.now()
+		if err := os.Chtimes(dest, now, now); err != nil {
+			slog.Debug("touching cached image", "path", dest, "error", err)
+		}
+
+		return dest, nil
+	}
+
+	if err := os.MkdirAll(c.dir, 0o750); err != nil {
+		return "", fmt.Errorf("creating image cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+
+	defer func() {
+		if rmErr := os.Remove(tmp.Name()); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) {
+			slog.Debug("removing temp file", "error", rmErr)
+		}
+	}()
+
+	if err := c.fetch(ctx, rawURL, tmp); err != nil {
+		_ = tmp.Close()
+
+		return "", err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("closing temp file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return "", fmt.Errorf("renaming temp file: %w", err)
+	}
+
+	if err := c.prune(dest); err != nil {
+		slog.Debug("pruning image cache", "error", err)
+	}
+
+	return dest, nil
+}
+
+// Open returns the cached image at rawURL, downloading it on a miss.
+func (c *Images) Open(ctx context.Context, rawURL string) (*os.File, error) {
+	p, err := c.Fetch(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p) //nolint:gosec // path is derived from a hash inside the cache dir
+	if err != nil {
+		return nil, fmt.Errorf("opening cached image: %w", err)
+	}
+
+	return f, nil
+}
+
+// Prune evicts least recently used images until the cache fits maxBytes.
+func (c *Images) Prune() error {
+	return c.prune("")
+}
+
+// prune evicts least recently used images, never keep, until the cache
+// fits maxBytes.
+func (c *Images) prune(keep string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("reading image cache: %w", err)
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var (
+		files []file
+		total int64
+	)
+
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".tmp-") {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		files = append(files, file{filepath.Join(c.dir, e.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	for _, f := range files {
+		if total <= c.maxBytes {
+			break
+		}
+
+		if f.path == keep {
+			continue
+		}
+
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("evicting %s: %w", f.path, err)
+		}
+
+		total -= f.size
+	}
+
+	return nil
+}
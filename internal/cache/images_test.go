@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingFetcher writes body for every URL and counts calls.
+func countingFetcher(body string, calls *int) ImageFetcher {
+	return func(_ context.Context, _ string, w io.Writer) error {
+		*calls++
+		_, err := io.WriteString(w, body)
+
+		return err
+	}
+}
+
+func TestImages_Path(t *testing.T) {
+	c := NewImages("/c", 0, nil)
+
+	p := c.Path("https://api.memegen.link/images/drake/a/b.PNG?font=impact")
+	assert.Equal(t, "/c", filepath.Dir(p))
+	assert.Equal(t, ".png", filepath.Ext(p))
+	assert.Len(t, strings.TrimSuffix(filepath.Base(p), ".png"), 64)
+
+	assert.NotEqual(t, p, c.Path("https://api.memegen.link/images/drake/a/b.png"), "query is part of the key")
+	assert.Equal(t, p, c.Path("https://api.memegen.link/images/drake/a/b.PNG?font=impact"))
+}
+
+func TestImages_FetchCaches(t *testing.T) {
+	calls := 0
+	c := NewImages(t.TempDir(), 1<<20, countingFetcher("img", &calls))
+
+	first, err := c.Fetch(context.Background(), "https://x/a.png")
+	require.NoError(t, err)
+
+	second, err := c.Fetch(context.Background(), "https://x/a.png")
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, calls)
+	assert.True(t, c.Has("https://x/a.png"))
+
+	f, err := c.Open(context.Background(), "https://x/a.png")
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "img", string(data))
+	assert.Equal(t, 1, calls)
+}
+
+func TestImages_FetchError(t *testing.T) {
+	dir := t.TempDir()
+	c := NewImages(dir, 1<<20, func(_ context.Context, _ string, w io.Writer) error {
+		_, _ = io.WriteString(w, "partial")
+
+		return errors.New("boom")
+	})
+
+	_, err := c.Fetch(context.Background(), "https://x/a.png")
+	require.Error(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "no partial or temp files left behind")
+}
+
+func TestImages_EvictsLeastRecentlyUsed(t *testing.T) {
+	calls := 0
+	c := NewImages(t.TempDir(), 10, countingFetcher("12345", &calls))
+
+	base := time.Now().Add(-time.Hour)
+	tick := 0
+	c.now = func() time.Time {
+		tick++
+
+		return base.Add(time.Duration(tick) * time.Minute)
+	}
+
+	a, err := c.Fetch(context.Background(), "https://x/a.png")
+	require.NoError(t, err)
+	require.NoError(t, os.Chtimes(a, base, base))
+
+	b, err := c.Fetch(context.Background(), "https://x/b.png")
+	require.NoError(t, err)
+	require.NoError(t, os.Chtimes(b, base.Add(time.Second), base.Add(time.Second)))
+
+	// A hit makes a the most recently used, so c's arrival evicts b.
+	_, err = c.Fetch(context.Background(), "https://x/a.png")
+	require.NoError(t, err)
+
+	_, err = c.Fetch(context.Background(), "https://x/c.png")
+	require.NoError(t, err)
+
+	assert.True(t, c.Has("https://x/a.png"))
+	assert.False(t, c.Has("https://x/b.png"))
+	assert.True(t, c.Has("https://x/c.png"))
+	assert.Equal(t, 3, calls)
+}
+
+func TestImages_KeepsOversizedFetch(t *testing.T) {
+	calls := 0
+	c := NewImages(t.TempDir(), 2, countingFetcher("12345", &calls))
+
+	p, err := c.Fetch(context.Background(), "https://x/big.png")
+	require.NoError(t, err)
+
+	_, err = os.Stat(p)
+	require.NoError(t, err, "the image just fetched is never evicted")
+
+	require.NoError(t, c.Prune())
+	assert.False(t, c.Has("https://x/big.png"), "a later prune evicts it")
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/dedene/memelink-cli/internal/api"
+)
+
+// Kind identifies a class of cached API data with its own TTL.
+type Kind string
+
+// Kinds held in a Store.
+const (
+	KindTemplates Kind = "templates" // the template list (single entry)
+	KindFonts     Kind = "fonts"     // the font list (single entry)
+	KindDetails   Kind = "details"   // single-template lookups, keyed by ID
+)
+
+// DefaultTTL applies to kinds without a configured TTL.
+const DefaultTTL = 24 * time.Hour
+
+// Store is a keyed cache rooted at a directory: each (kind, key) pair is
+// one Entry file, and each kind has its own TTL.
+type Store struct {
+	dir      string
+	ttls     map[Kind]time.Duration
+	maxStale time.Duration
+}
+
+// NewStore returns a Store under dir. Kinds missing from ttls use
+// DefaultTTL; entries up to maxStale past their TTL classify as Stale.
+func NewStore(dir string, ttls map[Kind]time.Duration, maxStale time.Duration) *Store {
+	return &Store{dir: dir, ttls: ttls, maxStale: maxStale}
+}
+
+// TTL returns the lifetime of entries of kind.
+func (s *Store) TTL(kind Kind) time.Duration {
+	if ttl, ok := s.ttls[kind]; ok {
+		return ttl
+	}
+
+	return DefaultTTL
+}
+
+// KindPath returns the directory holding the keyed entries of kind.
+func (s *Store) KindPath(kind Kind) string {
+	return filepath.Join(s.dir, string(kind))
+}
+
+// Path returns the entry file for (kind, key). The empty key names the
+// kind's single entry, <dir>/<kind>.json; other keys are path-escaped so
+// they cannot leave the kind's directory.
+func (s *Store) Path(kind Kind, key string) string {
+	if key == "" {
+		return filepath.Join(s.dir, string(kind)+".json")
+	}
+
+	return filepath.Join(s.KindPath(kind), url.PathEscape(key)+".json")
+}
+
+// LockPath returns the refresh lock file for (kind, key).
+func (s *Store) LockPath(kind Kind, key string) string {
+	name := string(kind)
+	if key != "" {
+		name += "-" + key
+	}
+
+	return LockPath(s.dir, name)
+}
+
+// LockPath returns the refresh lock file called name under the cache
+// directory dir. Locks live in their own subdirectory so they never count
+// as cached data and survive clearing a cache.
+func LockPath(dir, name string) string {
+	return filepath.Join(dir, "locks", url.PathEscape(name)+".lock")
+}
+
+// Freshness classifies an entry of kind fetched at fetchedAt.
+func (s *Store) Freshness(kind Kind, fetchedAt time.Time) Freshness {
+	return Classify(fetchedAt, s.TTL(kind), s.maxStale)
+}
+
+// Load reads the entry for (kind, key) regardless of age and classifies it.
+// Returns (nil, Missing, nil) when the entry is missing or corrupt.
+func Load[T any](s *Store, kind Kind, key string) (*Entry[T], Freshness, error) {
+	e, err := LoadEntry[T](s.Path(kind, key))
+	if e == nil {
+		return nil, Missing, err
+	}
+
+	return e, s.Freshness(kind, e.FetchedAt), nil
+}
+
+// Save writes data and its validators as the entry for (kind, key).
+func Save[T any](s *Store, kind Kind, key string, data T, v api.Validators) error {
+	return SaveEntry(s.Path(kind, key), data, v)
+}
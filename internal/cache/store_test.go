@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/api"
+)
+
+func TestStore_Paths(t *testing.T) {
+	s := NewStore("/c", nil, 0)
+
+	assert.Equal(t, filepath.Join("/c", "fonts.json"), s.Path(KindFonts, ""))
+	assert.Equal(t, filepath.Join("/c", "details", "drake.json"), s.Path(KindDetails, "drake"))
+	assert.Equal(t, filepath.Join("/c", "details", "..%2Fescape.json"), s.Path(KindDetails, "../escape"))
+	assert.Equal(t, filepath.Join("/c", "locks", "fonts.lock"), s.LockPath(KindFonts, ""))
+	assert.Equal(t, filepath.Join("/c", "locks", "details-..%2Fescape.lock"), s.LockPath(KindDetails, "../escape"))
+	assert.Equal(t, filepath.Join("/c", "details"), s.KindPath(KindDetails))
+}
+
+func TestStore_PerKindTTL(t *testing.T) {
+	s := NewStore(t.TempDir(), map[Kind]time.Duration{KindFonts: 7 * 24 * time.Hour}, time.Hour)
+
+	assert.Equal(t, 7*24*time.Hour, s.TTL(KindFonts))
+	assert.Equal(t, DefaultTTL, s.TTL(KindDetails))
+
+	old := time.Now().Add(-48 * time.Hour)
+	assert.Equal(t, Fresh, s.Freshness(KindFonts, old))
+	assert.Equal(t, Expired, s.Freshness(KindDetails, old))
+	assert.Equal(t, Stale, s.Freshness(KindDetails, time.Now().Add(-DefaultTTL-30*time.Minute)))
+}
+
+func TestStore_SaveLoad(t *testing.T) {
+	s := NewStore(t.TempDir(), nil, 0)
+
+	e, freshness, err := Load[*api.Template](s, KindDetails, "drake")
+	require.NoError(t, err)
+	assert.Nil(t, e)
+	assert.Equal(t, Missing, freshness)
+
+	tmpl := &api.Template{ID: "drake", Lines: 2}
+	require.NoError(t, Save(s, KindDetails, "drake", tmpl, api.Validators{ETag: `"x"`}))
+
+	e, freshness, err = Load[*api.Template](s, KindDetails, "drake")
+	require.NoError(t, err)
+	require.NotNil(t, e)
+	assert.Equal(t, Fresh, freshness)
+	assert.Equal(t, tmpl, e.Data)
+	assert.Equal(t, `"x"`, e.ETag)
+
+	_, err = os.Stat(s.Path(KindDetails, "drake"))
+	require.NoError(t, err)
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dedene/memelink-cli/internal/config"
+	"github.com/dedene/memelink-cli/internal/manifest"
+	"github.com/dedene/memelink-cli/internal/outfmt"
+	"github.com/dedene/memelink-cli/internal/ui"
+)
+
+// BatchCmd generates memes for every row of a CSV, JSONL or YAML manifest.
+type BatchCmd struct {
+	File     string `arg:"" help:"Manifest file (.csv, .jsonl, .yaml)"`
+	Parallel int    `help:"Number of rows generated concurrently" short:"j" default:"4"`
+}
+
+// batchResult is the outcome of one manifest row.
+type batchResult struct {
+	Row      int    `json:"row"`
+	Template string `json:"template"`
+	URL      string `json:"url,omitempty"`
+	Output   string `json:"output,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Run loads the manifest, generates all rows and prints a summary.
+// Returns an error when any row failed, after reporting every row.
+func (c *BatchCmd) Run(ctx context.Context, root *RootFlags) error {
+	rows, err := manifest.Load(c.File)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return fmt.Errorf("%s: manifest has no rows", c.File)
+	}
+
+	results := c.runRows(ctx, config.FromContext(ctx), rows, root != nil && root.Force)
+
+	failed := 0
+
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+
+	if err := c.printResults(ctx, results, failed); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// runRows generates rows with a bounded worker pool. Results keep manifest order.
+func (c *BatchCmd) runRows(ctx context.Context, cfg *config.Config, rows []manifest.Row, force bool) []batchResult {
+	results := make([]batchResult, len(rows))
+	jobs := make(chan int)
+
+	workers := max(1, min(c.Parallel, len(rows)))
+
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				results[i] = runBatchRow(ctx, cfg, i+1, rows[i], force)
+			}
+		}()
+	}
+
+	for i := range rows {
+		jobs <- i
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// runBatchRow generates a single row through the same path as GenerateCmd
+// and downloads it when the row names an output file.
+func runBatchRow(ctx context.Context, cfg *config.Config, n int, row manifest.Row, force bool) batchResult {
+	gen := batchGenerateCmd(row)
+
+	result := batchResult{Row: n, Template: row.Template, Output: row.Output}
+	if result.Template == "" {
+		result.Template = "(auto)"
+	}
+
+	if err := gen.applyPreset(cfg); err != nil {
+		result.Error = err.Error()
+
+		return result
+	}
+
+	res, err := gen.generate(ctx, cfg, force)
+	if err != nil {
+		result.Error = err.Error()
+
+		return result
+	}
+
+	result.URL = res.URL
+
+	recordHistory(ctx, gen.historyEntry(cfg, res))
+
+	if row.Output != "" {
+		if err := saveImage(ctx, imageCache(ctx), res.URL, row.Output); err != nil {
+			result.Error = fmt.Sprintf("download: %v", err)
+		}
+	}
+
+	return result
+}
+
+// batchGenerateCmd maps a manifest row onto GenerateCmd fields. Rows without
+// a template are auto-generated from their joined text. A template row
+// without text gets one empty line, since no text at all would mean
+// auto-generate.
+func batchGenerateCmd(row manifest.Row) *GenerateCmd {
+	if row.Template == "" {
+		return &GenerateCmd{Template: strings.Join(row.Text, " ")}
+	}
+
+	text := row.Text
+	if len(text) == 0 {
+		text = []string{""}
+	}
+
+	return &GenerateCmd{
+		Template: row.Template,
+		Text:     text,
+		GenerateFlags: GenerateFlags{
+			Format:     row.Format,
+			Font:       row.Font,
+			Style:      row.Style,
+			Background: row.Background,
+		},
+	}
+}
+
+// printResults writes per-row results as JSON or a summary table.
+func (c *BatchCmd) printResults(ctx context.Context, results []batchResult, failed int) error {
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{
+			"results":   results,
+			"succeeded": len(results) - failed,
+			"failed":    failed,
+		})
+	}
+
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		status, detail := "ok", r.URL
+		if r.Error != "" {
+			status, detail = "failed", r.Error
+		}
+
+		rows = append(rows, []string{strconv.Itoa(r.Row), r.Template, status, detail})
+	}
+
+	colorEnabled := false
+	if u := ui.FromContext(ctx); u != nil {
+		colorEnabled = u.Out().ColorEnabled()
+	}
+
+	fmt.Fprint(os.Stdout, ui.RenderTable(
+		[]string{"#", "Template", "Status", "Result"},
+		rows,
+		colorEnabled,
+	))
+	fmt.Fprintf(os.Stdout, "\n%d succeeded, %d failed\n", len(results)-failed, failed)
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/manifest"
+)
+
+// batchServer answers generate calls and fails template "broken".
+func batchServer(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Template lookups for client-side validation are not counted.
+		if r.Method == http.MethodGet {
+			id := strings.TrimPrefix(r.URL.Path, "/templates/")
+			if id == "broken" {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"id":"` + id + `","lines":2,"styles":[]}`))
+
+			return
+		}
+
+		calls.Add(1)
+
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if body["template_id"] == "broken" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"template not found"}`))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+
+		switch r.URL.Path {
+		case "/images/automatic":
+			_, _ = w.Write([]byte(`{"url":"https://api.memegen.link/images/buzz/auto.jpg"}`))
+		case "/images/custom":
+			_, _ = w.Write([]byte(`{"url":"https://api.memegen.link/images/custom/hello.jpg"}`))
+		default:
+			_, _ = w.Write([]byte(`{"url":"https://api.memegen.link/images/` + body["template_id"].(string) + `/a/b.jpg"}`))
+		}
+	}))
+}
+
+func writeManifest(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	return path
+}
+
+func TestBatchCmd_AllModes(t *testing.T) {
+	var calls atomic.Int32
+	srv := batchServer(t, &calls)
+	defer srv.Close()
+
+	path := writeManifest(t, "memes.jsonl", `{"template":"drake","text":["a","b"]}
+{"template":"custom","text":["hello"],"background":"https://example.com/bg.jpg"}
+{"text":["when the build passes"]}
+`)
+
+	ctx := testCtx(t, srv.URL, true)
+	cmd := &BatchCmd{File: path, Parallel: 2}
+
+	var runErr error
+	output := captureStdout(t, func() { runErr = cmd.Run(ctx, nil) })
+	require.NoError(t, runErr)
+
+	assert.Equal(t, int32(3), calls.Load())
+
+	var parsed struct {
+		Results []batchResult `json:"results"`
+		Failed  int           `json:"failed"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &parsed))
+	require.Len(t, parsed.Results, 3)
+	assert.Equal(t, 0, parsed.Failed)
+
+	// Results keep manifest order regardless of worker scheduling.
+	assert.Equal(t, "https://api.memegen.link/images/drake/a/b.jpg", parsed.Results[0].URL)
+	assert.Equal(t, "https://api.memegen.link/images/custom/hello.jpg", parsed.Results[1].URL)
+	assert.Equal(t, "(auto)", parsed.Results[2].Template)
+	assert.Equal(t, "https://api.memegen.link/images/buzz/auto.jpg", parsed.Results[2].URL)
+}
+
+func TestBatchGenerateCmd_TemplateWithoutText(t *testing.T) {
+	gen := batchGenerateCmd(manifest.Row{Template: "drake"})
+	assert.Equal(t, "drake", gen.Template)
+	assert.Equal(t, []string{""}, gen.Text, "no text would mean auto-generate")
+
+	gen = batchGenerateCmd(manifest.Row{Text: []string{"when", "it works"}})
+	assert.Equal(t, "when it works", gen.Template)
+	assert.Empty(t, gen.Text)
+}
+
+func TestBatchCmd_PartialFailure(t *testing.T) {
+	var calls atomic.Int32
+	srv := batchServer(t, &calls)
+	defer srv.Close()
+
+	path := writeManifest(t, "memes.csv", "template,text,text,format\ndrake,a,b,png\nbroken,x,y,\nfry,c,d,bmp\n")
+
+	ctx := testCtx(t, srv.URL, false)
+	cmd := &BatchCmd{File: path, Parallel: 4}
+
+	var runErr error
+	output := captureStdout(t, func() { runErr = cmd.Run(ctx, nil) })
+
+	require.Error(t, runErr)
+	assert.Contains(t, runErr.Error(), "2 of 3 rows failed")
+	assert.Equal(t, int32(1), calls.Load(), "invalid format and unknown template rejected before any POST")
+
+	assert.Contains(t, output, "template not found")
+	assert.Contains(t, output, `invalid format "bmp"`)
+	assert.Contains(t, output, "1 succeeded, 2 failed")
+}
+
+func TestBatchCmd_DownloadsOutput(t *testing.T) {
+	imgSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("jpeg-bytes"))
+	}))
+	defer imgSrv.Close()
+
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"url":"` + imgSrv.URL + `/images/drake/a/b.jpg"}`))
+	}))
+	defer apiSrv.Close()
+
+	out := filepath.Join(t.TempDir(), "drake.jpg")
+	path := writeManifest(t, "memes.yaml", "- template: drake\n  text: [a, b]\n  output: "+out+"\n")
+
+	ctx := testCtx(t, apiSrv.URL, false)
+	cmd := &BatchCmd{File: path, Parallel: 1}
+
+	captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, nil)) })
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg-bytes", string(data))
+}
+
+func TestBatchCmd_EmptyManifest(t *testing.T) {
+	path := writeManifest(t, "memes.jsonl", "\n")
+
+	err := (&BatchCmd{File: path, Parallel: 1}).Run(testCtx(t, "http://unused", false), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no rows")
+}
@@ -0,0 +1,383 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/cache"
+	"github.com/dedene/memelink-cli/internal/config"
+	"github.com/dedene/memelink-cli/internal/outfmt"
+	"github.com/dedene/memelink-cli/internal/ui"
+)
+
+// CacheCmd groups cache management subcommands.
+type CacheCmd struct {
+	Info  CacheInfoCmd  `cmd:"" default:"1" help:"Show cache contents, age and size"`
+	Path  CachePathCmd  `cmd:"" help:"Show cache directory path"`
+	Clear CacheClearCmd `cmd:"" help:"Delete cached data"`
+	Warm  CacheWarmCmd  `cmd:"" help:"Prefetch templates, fonts and blank images for offline use"`
+}
+
+// Cache kinds, as shown by `cache info` and accepted by `cache clear`.
+const (
+	cacheTemplates = "templates"
+	cacheFonts     = "fonts"
+	cacheDetails   = "details"
+	cacheImages    = "images"
+)
+
+// cachePaths maps each cache kind to its file or directory, in display order.
+func cachePaths(ctx context.Context) ([]string, map[string]string, error) {
+	images, err := config.ImageCacheDir(apiBaseURL(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	store, err := cacheStore(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []string{cacheTemplates, cacheFonts, cacheDetails, cacheImages}, map[string]string{
+		cacheTemplates: store.Path(cache.KindTemplates, ""),
+		cacheFonts:     store.Path(cache.KindFonts, ""),
+		cacheDetails:   store.KindPath(cache.KindDetails),
+		cacheImages:    images,
+	}, nil
+}
+
+// CachePathCmd prints the cache directory.
+type CachePathCmd struct{}
+
+// Run prints the cache directory path for the configured API server.
+func (c *CachePathCmd) Run(ctx context.Context) error {
+	dir, err := config.ServerCacheDir(apiBaseURL(ctx))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stdout, dir)
+
+	return nil
+}
+
+// CacheInfoCmd reports what is cached.
+type CacheInfoCmd struct{}
+
+// cacheUsage is one row of `cache info` (also its --json shape).
+type cacheUsage struct {
+	Name      string     `json:"name"`
+	Path      string     `json:"path"`
+	Entries   int        `json:"entries"`
+	Files     int        `json:"files"`
+	Bytes     int64      `json:"bytes"`
+	TTL       string     `json:"ttl,omitempty"`
+	FetchedAt *time.Time `json:"fetched_at,omitempty"`
+	State     string     `json:"state,omitempty"`
+}
+
+// cacheInfo is the full `cache info --json` shape.
+type cacheInfo struct {
+	Dir        string       `json:"dir"`
+	MaxStale   string       `json:"max_stale"`
+	Caches     []cacheUsage `json:"caches"`
+	TotalBytes int64        `json:"total_bytes"`
+}
+
+// Run measures each cache and prints a per-cache breakdown.
+func (c *CacheInfoCmd) Run(ctx context.Context) error {
+	dir, err := config.ServerCacheDir(apiBaseURL(ctx))
+	if err != nil {
+		return err
+	}
+
+	order, paths, err := cachePaths(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg := config.FromContext(ctx)
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	maxStale := cfg.CacheMaxStaleDuration()
+	info := cacheInfo{Dir: dir, MaxStale: maxStale.String()}
+
+	ttls := map[string]time.Duration{
+		cacheTemplates: cfg.CacheTTLFor(cacheTemplates),
+		cacheFonts:     cfg.CacheTTLFor(cacheFonts),
+		cacheDetails:   cfg.CacheTTLFor(cacheDetails),
+	}
+
+	for _, kind := range order {
+		u := cacheUsage{Name: kind, Path: paths[kind]}
+
+		ttl, hasTTL := ttls[kind]
+		if hasTTL {
+			u.TTL = ttl.String()
+		}
+
+		u.Files, u.Bytes, err = cache.Usage(u.Path)
+		if err != nil {
+			return err
+		}
+
+		u.Entries = u.Files
+
+		var fetchedAt time.Time
+
+		switch kind {
+		case cacheTemplates:
+			if e, _ := cache.LoadEntry[[]api.Template](u.Path); e != nil {
+				u.Entries, fetchedAt = len(e.Data), e.FetchedAt
+			}
+		case cacheFonts:
+			if e, _ := cache.LoadEntry[[]api.Font](u.Path); e != nil {
+				u.Entries, fetchedAt = len(e.Data), e.FetchedAt
+			}
+		}
+
+		if !fetchedAt.IsZero() {
+			u.FetchedAt = &fetchedAt
+			u.State = cache.Classify(fetchedAt, ttl, maxStale).String()
+		}
+
+		info.Caches = append(info.Caches, u)
+		info.TotalBytes += u.Bytes
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, info)
+	}
+
+	info.print(ctx)
+
+	return nil
+}
+
+// print writes the human-readable cache report.
+func (info cacheInfo) print(ctx context.Context) {
+	fmt.Fprintf(os.Stdout, "Path:      %s\n", info.Dir)
+	fmt.Fprintf(os.Stdout, "Max stale: %s\n\n", info.MaxStale)
+
+	rows := make([][]string, 0, len(info.Caches))
+	for _, u := range info.Caches {
+		ttl, age, state := "-", "-", "-"
+		if u.TTL != "" {
+			ttl = u.TTL
+		}
+
+		if u.FetchedAt != nil {
+			age, state = formatAge(time.Since(*u.FetchedAt)), u.State
+		}
+
+		rows = append(rows, []string{
+			u.Name, fmt.Sprintf("%d", u.Entries), fmt.Sprintf("%d", u.Files), formatSize(u.Bytes), ttl, age, state,
+		})
+	}
+
+	colorEnabled := false
+	if u := ui.FromContext(ctx); u != nil {
+		colorEnabled = u.Out().ColorEnabled()
+	}
+
+	fmt.Fprint(os.Stdout, ui.RenderTable(
+		[]string{"Cache", "Entries", "Files", "Size", "TTL", "Age", "State"},
+		rows,
+		colorEnabled,
+	))
+	fmt.Fprintf(os.Stdout, "\nTotal: %s\n", formatSize(info.TotalBytes))
+}
+
+// CacheClearCmd deletes cached data.
+type CacheClearCmd struct {
+	Kinds []string `arg:"" optional:"" enum:"templates,fonts,details,images" help:"Caches to clear (templates, fonts, details, images); all when omitted"`
+}
+
+// Run removes the selected caches, or the whole cache directory.
+func (c *CacheClearCmd) Run(ctx context.Context) error {
+	order, paths, err := cachePaths(ctx)
+	if err != nil {
+		return err
+	}
+
+	kinds := c.Kinds
+	if len(kinds) == 0 {
+		kinds = order
+	}
+
+	var files int
+
+	var size int64
+
+	for _, kind := range kinds {
+		n, b, err := cache.Usage(paths[kind])
+		if err != nil {
+			return err
+		}
+
+		if err := os.RemoveAll(paths[kind]); err != nil {
+			return fmt.Errorf("clearing %s cache: %w", kind, err)
+		}
+
+		files += n
+		size += b
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"cleared": kinds, "files": files, "bytes": size})
+	}
+
+	fmt.Fprintf(os.Stderr, "Cleared %d files (%s)\n", files, formatSize(size))
+
+	return nil
+}
+
+// CacheWarmCmd prefetches data for offline use.
+type CacheWarmCmd struct {
+	NoBlanks bool `help:"Skip downloading blank template images" name:"no-blanks"`
+	Parallel int  `help:"Concurrent blank image downloads" default:"4"`
+}
+
+// warmResult is the --json shape of `cache warm`.
+type warmResult struct {
+	Templates    int `json:"templates"`
+	Fonts        int `json:"fonts"`
+	Blanks       int `json:"blanks"`
+	BlanksCached int `json:"blanks_cached"`
+	BlanksFailed int `json:"blanks_failed"`
+}
+
+// Run refreshes the template and font caches and downloads blank images
+// that are not cached yet.
+func (c *CacheWarmCmd) Run(ctx context.Context) error {
+	client := api.ClientFromContext(ctx)
+	if client == nil {
+		return errors.New("api client not found in context")
+	}
+
+	templates, err := fetchTemplates(ctx, client, true)
+	if err != nil {
+		return fmt.Errorf("warming templates: %w", err)
+	}
+
+	fonts, err := fetchFonts(ctx, client, true)
+	if err != nil {
+		return fmt.Errorf("warming fonts: %w", err)
+	}
+
+	res := warmResult{Templates: len(templates), Fonts: len(fonts)}
+
+	if !c.NoBlanks {
+		if images := imageCache(ctx); images != nil {
+			c.warmBlanks(ctx, images, templates, &res)
+		} else {
+			fmt.Fprintln(os.Stderr, "warning: image cache disabled (image_cache_mb is 0), skipping blank images")
+		}
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, res)
+	}
+
+	fmt.Fprintf(os.Stdout, "Cached %d templates, %d fonts", res.Templates, res.Fonts)
+
+	if !c.NoBlanks {
+		fmt.Fprintf(os.Stdout, ", %d blank images (%d already cached)", res.Blanks, res.BlanksCached)
+	}
+
+	fmt.Fprintln(os.Stdout)
+
+	return nil
+}
+
+// warmBlanks downloads missing blank images into the image cache with
+// c.Parallel workers. Failures are counted and summarized on stderr, not
+// fatal.
+func (c *CacheWarmCmd) warmBlanks(
+	ctx context.Context, images *cache.Images, templates []api.Template, res *warmResult,
+) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+
+	jobs := make(chan api.Template)
+
+	for range max(c.Parallel, 1) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for t := range jobs {
+				_, err := images.Fetch(ctx, t.Blank)
+
+				mu.Lock()
+				if err != nil {
+					res.BlanksFailed++
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					res.Blanks++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, t := range templates {
+		if t.Blank == "" {
+			continue
+		}
+
+		if images.Has(t.Blank) {
+			res.BlanksCached++
+
+			continue
+		}
+
+		jobs <- t
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	if res.BlanksFailed > 0 {
+		fmt.Fprintf(os.Stderr, "warning: %d blank images failed: %v\n", res.BlanksFailed, firstErr)
+	}
+}
+
+// formatSize renders n bytes with a binary unit (B, KiB, MiB, GiB).
+func formatSize(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit && exp < 2; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMG"[exp])
+}
+
+// formatAge renders a cache age rounded to the minute (or second when young).
+func formatAge(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+
+	return d.Round(time.Minute).String()
+}
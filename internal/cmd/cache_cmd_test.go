@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/cache"
+	"github.com/dedene/memelink-cli/internal/config"
+)
+
+func TestCachePathCmd(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	output := captureStdout(t, func() { require.NoError(t, (&CachePathCmd{}).Run(testCtxNoClient(t, false))) })
+	assert.Equal(t, filepath.Join(cacheDir, "memelink")+"\n", output)
+
+	for _, baseURL := range []string{api.DefaultBaseURL, api.DefaultBaseURL + "/"} {
+		output = captureStdout(t, func() { require.NoError(t, (&CachePathCmd{}).Run(testCtx(t, baseURL, false))) })
+		assert.Equal(t, filepath.Join(cacheDir, "memelink")+"\n", output, "the public API uses the cache root: %q", baseURL)
+	}
+
+	// Another API server has its own cache directory.
+	output = captureStdout(t, func() {
+		require.NoError(t, (&CachePathCmd{}).Run(testCtx(t, "http://localhost:5000", false)))
+	})
+	assert.True(t, strings.HasPrefix(output, filepath.Join(cacheDir, "memelink", "servers", "localhost_5000-")))
+}
+
+func TestCacheInfoCmd_JSON(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	seedTemplateCacheAged(t, "", 30*time.Hour)
+
+	ctx := config.WithConfig(testCtxNoClient(t, true), &config.Config{})
+
+	store, err := cacheStore(ctx)
+	require.NoError(t, err)
+	require.NoError(t, cache.Save(store, cache.KindFonts, "", []api.Font{{ID: "impact"}}, api.Validators{}))
+
+	output := captureStdout(t, func() { require.NoError(t, (&CacheInfoCmd{}).Run(ctx)) })
+
+	var info cacheInfo
+	require.NoError(t, json.Unmarshal([]byte(output), &info))
+	require.Len(t, info.Caches, 4)
+
+	templates := info.Caches[0]
+	assert.Equal(t, "templates", templates.Name)
+	assert.Equal(t, 2, templates.Entries)
+	assert.Equal(t, "stale", templates.State)
+	assert.Positive(t, templates.Bytes)
+
+	assert.Equal(t, "fonts", info.Caches[1].Name)
+	assert.Equal(t, 1, info.Caches[1].Entries)
+	assert.Equal(t, "fresh", info.Caches[1].State)
+
+	assert.Zero(t, info.Caches[3].Files)
+	assert.Equal(t, templates.Bytes+info.Caches[1].Bytes, info.TotalBytes)
+	assert.Equal(t, "24h0m0s", templates.TTL)
+	assert.Equal(t, "168h0m0s", info.Caches[1].TTL)
+	assert.Equal(t, "images", info.Caches[3].Name)
+	assert.Empty(t, info.Caches[3].TTL, "images have no TTL")
+}
+
+func TestCacheInfoCmd_Human(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	seedTemplateCache(t, "http://unused")
+
+	ctx := testCtxWithConfig(t, "http://unused")
+
+	output := captureStdout(t, func() { require.NoError(t, (&CacheInfoCmd{}).Run(ctx)) })
+	dir, err := config.ServerCacheDir("http://unused")
+	require.NoError(t, err)
+	assert.Contains(t, output, "Path:      "+dir)
+	assert.Contains(t, output, "templates")
+	assert.Contains(t, output, "fresh")
+	assert.Contains(t, output, "Total: ")
+}
+
+func TestCacheClearCmd(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	seedTemplateCache(t, "")
+
+	ctx := testCtxNoClient(t, false)
+
+	store, err := cacheStore(ctx)
+	require.NoError(t, err)
+
+	fontsPath := store.Path(cache.KindFonts, "")
+	require.NoError(t, cache.Save(store, cache.KindFonts, "", []api.Font{{ID: "impact"}}, api.Validators{}))
+
+	templatesPath := store.Path(cache.KindTemplates, "")
+
+	captureStderr(t, func() { require.NoError(t, (&CacheClearCmd{Kinds: []string{"fonts"}}).Run(ctx)) })
+	assert.NoFileExists(t, fontsPath)
+	assert.FileExists(t, templatesPath)
+
+	stderr := captureStderr(t, func() { require.NoError(t, (&CacheClearCmd{}).Run(ctx)) })
+	assert.NoFileExists(t, templatesPath)
+	assert.Contains(t, stderr, "Cleared 1 files")
+}
+
+func TestCacheWarmCmd(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	var imageHits atomic.Int32
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/templates":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(strings.ReplaceAll(`[
+				{"id":"drake","name":"Drake","lines":2,"blank":"BASE/images/drake.png"},
+				{"id":"fry","name":"Fry","lines":2,"blank":"BASE/images/fry.jpg"},
+				{"id":"gone","name":"Gone","lines":2,"blank":"BASE/images/gone.png"}
+			]`, "BASE", srv.URL)))
+		case r.URL.Path == "/fonts":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(fontsListJSON))
+		case r.URL.Path == "/images/gone.png":
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasPrefix(r.URL.Path, "/images/"):
+			imageHits.Add(1)
+			_, _ = w.Write([]byte("PNGDATA"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := testCtx(t, srv.URL, true)
+
+	var output string
+
+	stderr := captureStderr(t, func() {
+		output = captureStdout(t, func() { require.NoError(t, (&CacheWarmCmd{Parallel: 2}).Run(ctx)) })
+	})
+
+	var res warmResult
+	require.NoError(t, json.Unmarshal([]byte(output), &res))
+	assert.Equal(t, warmResult{Templates: 3, Fonts: 2, Blanks: 2, BlanksFailed: 1}, res)
+	assert.Contains(t, stderr, "warning: 1 blank images failed")
+
+	images := imageCache(ctx)
+	require.NotNil(t, images)
+
+	data, err := os.ReadFile(images.Path(srv.URL + "/images/fry.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "PNGDATA", string(data))
+
+	// A second warm skips blanks that are already cached.
+	captureStderr(t, func() {
+		output = captureStdout(t, func() { require.NoError(t, (&CacheWarmCmd{}).Run(ctx)) })
+	})
+	require.NoError(t, json.Unmarshal([]byte(output), &res))
+	assert.Equal(t, 2, res.BlanksCached)
+	assert.Equal(t, int32(2), imageHits.Load())
+}
+
+func TestCacheWarmCmd_ImageCacheDisabled(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/fonts" {
+			_, _ = w.Write([]byte(fontsListJSON))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(templatesListJSON))
+	}))
+	defer srv.Close()
+
+	zero := 0
+	ctx := config.WithConfig(testCtx(t, srv.URL, true), &config.Config{ImageCacheMB: &zero})
+
+	var output string
+
+	stderr := captureStderr(t, func() {
+		output = captureStdout(t, func() { require.NoError(t, (&CacheWarmCmd{}).Run(ctx)) })
+	})
+
+	var res warmResult
+	require.NoError(t, json.Unmarshal([]byte(output), &res))
+	assert.Zero(t, res.Blanks)
+	assert.Contains(t, stderr, "image cache disabled")
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", formatSize(512))
+	assert.Equal(t, "1.5 KiB", formatSize(1536))
+	assert.Equal(t, "2.0 MiB", formatSize(2*1024*1024))
+	assert.Equal(t, "3.0 GiB", formatSize(3*1024*1024*1024))
+}
@@ -16,14 +16,16 @@ type ConfigCmd struct {
 	Get   ConfigGetCmd   `cmd:"" help:"Get a config value"`
 	Set   ConfigSetCmd   `cmd:"" help:"Set a config value"`
 	Unset ConfigUnsetCmd `cmd:"" help:"Unset a config value"`
+
+	Profiles ConfigProfilesCmd `cmd:"" help:"Manage config profiles"`
 }
 
 // ConfigPathCmd prints the config file path.
 type ConfigPathCmd struct{}
 
-// Run prints the config file path.
-func (c *ConfigPathCmd) Run(_ context.Context) error {
-	path, err := config.Path()
+// Run prints the config file path of the active profile.
+func (c *ConfigPathCmd) Run(ctx context.Context) error {
+	path, err := config.ProfilePath(config.ProfileFromContext(ctx))
 	if err != nil {
 		return err
 	}
@@ -33,8 +35,16 @@ func (c *ConfigPathCmd) Run(_ context.Context) error {
 	return nil
 }
 
-// ConfigListCmd lists all config values.
-type ConfigListCmd struct{}
+// ConfigListCmd lists all effective config values.
+type ConfigListCmd struct {
+	ShowOrigin bool `help:"Show the file or environment variable each value came from" name:"show-origin"`
+}
+
+// configOrigin is one entry of `config list --show-origin --json`.
+type configOrigin struct {
+	Value  string `json:"value"`
+	Origin string `json:"origin"`
+}
 
 // Run lists all config keys with their values.
 func (c *ConfigListCmd) Run(ctx context.Context) error {
@@ -43,16 +53,43 @@ func (c *ConfigListCmd) Run(ctx context.Context) error {
 		cfg = &config.Config{}
 	}
 
+	keys := append(config.KnownKeys(), cfg.PresetKeys()...)
+
 	if outfmt.IsJSON(ctx) {
-		return outfmt.WriteJSON(os.Stdout, cfg)
+		if !c.ShowOrigin {
+			return outfmt.WriteJSON(os.Stdout, cfg)
+		}
+
+		out := make(map[string]configOrigin)
+
+		for _, key := range keys {
+			if val, ok := cfg.Get(key); ok {
+				out[key] = configOrigin{Value: val, Origin: cfg.Origin(key)}
+			}
+		}
+
+		return outfmt.WriteJSON(os.Stdout, out)
 	}
 
-	for _, key := range config.KnownKeys() {
+	fmt.Fprintf(os.Stdout, "# profile: %s\n", config.ProfileFromContext(ctx))
+
+	for _, key := range keys {
 		val, ok := cfg.Get(key)
 		if !ok {
 			val = "(unset)"
 		}
 
+		if c.ShowOrigin {
+			origin := "default"
+			if o := cfg.Origin(key); ok && o != "" {
+				origin = o
+			}
+
+			fmt.Fprintf(os.Stdout, "%s\t%s = %s\n", origin, key, val)
+
+			continue
+		}
+
 		fmt.Fprintf(os.Stdout, "%s = %s\n", key, val)
 	}
 
@@ -89,57 +126,142 @@ type ConfigSetCmd struct {
 	Value string `arg:"" help:"Config value"`
 }
 
-// Run sets a config key to a value, persisting to disk.
-func (c *ConfigSetCmd) Run(_ context.Context) error {
-	cfgPath, err := config.Path()
+// Run sets a config key to a value, persisting to the active profile.
+func (c *ConfigSetCmd) Run(ctx context.Context) error {
+	cfgPath, err := config.ProfilePath(config.ProfileFromContext(ctx))
 	if err != nil {
 		return err
 	}
 
-	cfg, err := config.Load(cfgPath)
-	if err != nil {
+	if err := config.Update(ctx, cfgPath, func(cfg *config.Config) error { return cfg.Set(c.Key, c.Value) }); err != nil {
 		return err
 	}
 
-	if err := cfg.Set(c.Key, c.Value); err != nil {
+	fmt.Fprintf(os.Stderr, "Set %s = %s\n", c.Key, c.Value)
+
+	return nil
+}
+
+// ConfigUnsetCmd removes a config value.
+type ConfigUnsetCmd struct {
+	Key string `arg:"" help:"Config key to unset"`
+}
+
+// Run unsets a config key, persisting to the active profile.
+func (c *ConfigUnsetCmd) Run(ctx context.Context) error {
+	cfgPath, err := config.ProfilePath(config.ProfileFromContext(ctx))
+	if err != nil {
 		return err
 	}
 
-	if err := config.Save(cfgPath, cfg); err != nil {
+	if err := config.Update(ctx, cfgPath, func(cfg *config.Config) error { return cfg.Unset(c.Key) }); err != nil {
 		return err
 	}
 
-	fmt.Fprintf(os.Stderr, "Set %s = %s\n", c.Key, c.Value)
+	fmt.Fprintf(os.Stderr, "Unset %s\n", c.Key)
 
 	return nil
 }
 
-// ConfigUnsetCmd removes a config value.
-type ConfigUnsetCmd struct {
-	Key string `arg:"" help:"Config key to unset"`
+// ConfigProfilesCmd groups profile subcommands.
+type ConfigProfilesCmd struct {
+	List   ConfigProfilesListCmd   `cmd:"" default:"1" help:"List profiles"`
+	Use    ConfigProfilesUseCmd    `cmd:"" help:"Make a profile the default for future runs"`
+	Copy   ConfigProfilesCopyCmd   `cmd:"" help:"Copy a profile into a new one"`
+	Delete ConfigProfilesDeleteCmd `cmd:"" help:"Delete a profile"`
+}
+
+// ConfigProfilesListCmd lists profiles, marking the active one.
+type ConfigProfilesListCmd struct{}
+
+// profileInfo is one row of `config profiles list --json`.
+type profileInfo struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+	Path   string `json:"path"`
 }
 
-// Run unsets a config key, persisting to disk.
-func (c *ConfigUnsetCmd) Run(_ context.Context) error {
-	cfgPath, err := config.Path()
+// Run lists all profiles.
+func (c *ConfigProfilesListCmd) Run(ctx context.Context) error {
+	names, err := config.ListProfiles()
 	if err != nil {
 		return err
 	}
 
-	cfg, err := config.Load(cfgPath)
-	if err != nil {
+	active := config.ProfileFromContext(ctx)
+
+	profiles := make([]profileInfo, 0, len(names))
+	for _, name := range names {
+		path, err := config.ProfilePath(name)
+		if err != nil {
+			return err
+		}
+
+		profiles = append(profiles, profileInfo{Name: name, Active: name == active, Path: path})
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, profiles)
+	}
+
+	for _, p := range profiles {
+		marker := " "
+		if p.Active {
+			marker = "*"
+		}
+
+		fmt.Fprintf(os.Stdout, "%s %s\n", marker, p.Name)
+	}
+
+	return nil
+}
+
+// ConfigProfilesUseCmd selects the profile used when --profile and
+// MEMELINK_PROFILE are not set.
+type ConfigProfilesUseCmd struct {
+	Name string `arg:"" help:"Profile name"`
+}
+
+// Run records the active profile.
+func (c *ConfigProfilesUseCmd) Run(_ context.Context) error {
+	if err := config.UseProfile(c.Name); err != nil {
 		return err
 	}
 
-	if err := cfg.Unset(c.Key); err != nil {
+	fmt.Fprintf(os.Stderr, "Using profile %s\n", c.Name)
+
+	return nil
+}
+
+// ConfigProfilesCopyCmd copies a profile.
+type ConfigProfilesCopyCmd struct {
+	Source string `arg:"" help:"Profile to copy"`
+	Target string `arg:"" help:"New profile name"`
+}
+
+// Run copies Source into a new profile Target.
+func (c *ConfigProfilesCopyCmd) Run(_ context.Context) error {
+	if err := config.CopyProfile(c.Source, c.Target); err != nil {
 		return err
 	}
 
-	if err := config.Save(cfgPath, cfg); err != nil {
+	fmt.Fprintf(os.Stderr, "Copied profile %s to %s\n", c.Source, c.Target)
+
+	return nil
+}
+
+// ConfigProfilesDeleteCmd deletes a profile.
+type ConfigProfilesDeleteCmd struct {
+	Name string `arg:"" help:"Profile name"`
+}
+
+// Run deletes the profile.
+func (c *ConfigProfilesDeleteCmd) Run(_ context.Context) error {
+	if err := config.DeleteProfile(c.Name); err != nil {
 		return err
 	}
 
-	fmt.Fprintf(os.Stderr, "Unset %s\n", c.Key)
+	fmt.Fprintf(os.Stderr, "Deleted profile %s\n", c.Name)
 
 	return nil
 }
@@ -197,3 +197,123 @@ func TestConfigFileCreated(t *testing.T) {
 	require.NoError(t, json.Unmarshal(data, &parsed))
 	assert.Equal(t, "impact", parsed["default_font"])
 }
+
+func TestConfigListPresets(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	require.NoError(t, (&ConfigSetCmd{Key: "presets.standup.template", Value: "fine"}).Run(context.Background()))
+	require.NoError(t, (&ConfigSetCmd{Key: "presets.standup.text_color", Value: "white"}).Run(context.Background()))
+
+	cfg, err := config.Load(filepath.Join(dir, "memelink", "config.json"))
+	require.NoError(t, err)
+
+	ctx := config.WithConfig(context.Background(), cfg)
+
+	output := captureStdout(t, func() {
+		require.NoError(t, (&ConfigListCmd{}).Run(ctx))
+	})
+
+	assert.Contains(t, output, "presets.standup.template = fine")
+	assert.Contains(t, output, "presets.standup.text_color = white")
+}
+
+func TestConfigSetUsesProfile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	ctx := config.WithProfile(context.Background(), "work")
+	require.NoError(t, (&ConfigSetCmd{Key: "safe", Value: "true"}).Run(ctx))
+
+	cfg, err := config.Load(filepath.Join(dir, "memelink", "profiles", "work.json"))
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Safe)
+	assert.True(t, *cfg.Safe)
+
+	_, err = os.Stat(filepath.Join(dir, "memelink", "config.json"))
+	assert.True(t, os.IsNotExist(err), "default profile untouched")
+}
+
+func TestConfigProfilesList(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	require.NoError(t, (&ConfigSetCmd{Key: "safe", Value: "true"}).Run(config.WithProfile(context.Background(), "work")))
+
+	ctx := config.WithProfile(outfmt.WithMode(context.Background(), outfmt.Mode{}), "work")
+
+	output := captureStdout(t, func() {
+		require.NoError(t, (&ConfigProfilesListCmd{}).Run(ctx))
+	})
+	assert.Equal(t, "  default\n* work\n", output)
+
+	jsonCtx := config.WithProfile(outfmt.WithMode(context.Background(), outfmt.Mode{JSON: true}), "work")
+	output = captureStdout(t, func() {
+		require.NoError(t, (&ConfigProfilesListCmd{}).Run(jsonCtx))
+	})
+
+	var profiles []profileInfo
+	require.NoError(t, json.Unmarshal([]byte(output), &profiles))
+	require.Len(t, profiles, 2)
+	assert.True(t, profiles[1].Active)
+	assert.Equal(t, filepath.Join(dir, "memelink", "profiles", "work.json"), profiles[1].Path)
+}
+
+func TestConfigProfilesUseCopyDelete(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(config.ProfileEnv, "")
+
+	ctx := context.Background()
+
+	require.Error(t, (&ConfigProfilesUseCmd{Name: "home"}).Run(ctx))
+	require.NoError(t, (&ConfigProfilesCopyCmd{Source: "default", Target: "home"}).Run(ctx))
+	require.NoError(t, (&ConfigProfilesUseCmd{Name: "home"}).Run(ctx))
+
+	name, err := config.ActiveProfile("", false)
+	require.NoError(t, err)
+	assert.Equal(t, "home", name)
+
+	require.NoError(t, (&ConfigProfilesDeleteCmd{Name: "home"}).Run(ctx))
+
+	name, err = config.ActiveProfile("", false)
+	require.NoError(t, err)
+	assert.Equal(t, config.DefaultProfile, name)
+}
+
+func TestConfigListShowOrigin(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "config.json")
+	require.NoError(t, config.Save(userPath, &config.Config{DefaultFormat: "png"}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, config.ProjectFileName), []byte(`{default_font: "impact"}`), 0o644))
+
+	cfg, err := config.LoadEffective(userPath, dir)
+	require.NoError(t, err)
+
+	ctx := config.WithConfig(outfmt.WithMode(context.Background(), outfmt.Mode{}), cfg)
+	output := captureStdout(t, func() {
+		require.NoError(t, (&ConfigListCmd{ShowOrigin: true}).Run(ctx))
+	})
+
+	assert.Contains(t, output, "file:"+userPath+"\tdefault_format = png\n")
+	assert.Contains(t, output, "file:"+filepath.Join(dir, config.ProjectFileName)+"\tdefault_font = impact\n")
+	assert.Contains(t, output, "default\tsafe = (unset)\n")
+
+	jsonCtx := config.WithConfig(outfmt.WithMode(context.Background(), outfmt.Mode{JSON: true}), cfg)
+	output = captureStdout(t, func() {
+		require.NoError(t, (&ConfigListCmd{ShowOrigin: true}).Run(jsonCtx))
+	})
+
+	var parsed map[string]configOrigin
+	require.NoError(t, json.Unmarshal([]byte(output), &parsed))
+	assert.Equal(t, configOrigin{Value: "png", Origin: "file:" + userPath}, parsed["default_format"])
+	assert.NotContains(t, parsed, "safe")
+}
+
+func TestExecute_BadEnvDurationFails(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("MEMELINK_CACHE_TTL", "bogus")
+
+	err := Execute([]string{"config", "path"})
+	require.ErrorIs(t, err, config.ErrInvalidValue)
+	assert.Contains(t, err.Error(), "MEMELINK_CACHE_TTL")
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/outfmt"
+)
+
+// DecodeCmd parses a meme URL back into its template, text and options.
+type DecodeCmd struct {
+	URL string `arg:"" help:"Meme image URL to decode"`
+}
+
+// Run decodes the URL and prints its components.
+func (c *DecodeCmd) Run(ctx context.Context) error {
+	m, err := api.ParseURL(c.URL)
+	if err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, m)
+	}
+
+	fmt.Fprintf(os.Stdout, "Template:   %s\n", m.TemplateID)
+
+	for i, line := range m.Text {
+		fmt.Fprintf(os.Stdout, "Line %d:     %s\n", i+1, line)
+	}
+
+	fmt.Fprintf(os.Stdout, "Extension:  %s\n", m.Extension)
+
+	if m.Font != "" {
+		fmt.Fprintf(os.Stdout, "Font:       %s\n", m.Font)
+	}
+
+	if m.Layout != "" {
+		fmt.Fprintf(os.Stdout, "Layout:     %s\n", m.Layout)
+	}
+
+	if len(m.Style) > 0 {
+		fmt.Fprintf(os.Stdout, "Style:      %s\n", strings.Join(m.Style, ", "))
+	}
+
+	if m.Background != "" {
+		fmt.Fprintf(os.Stdout, "Background: %s\n", m.Background)
+	}
+
+	if len(m.Color) > 0 {
+		fmt.Fprintf(os.Stdout, "Color:      %s\n", strings.Join(m.Color, ", "))
+	}
+
+	if m.Width > 0 {
+		fmt.Fprintf(os.Stdout, "Width:      %d\n", m.Width)
+	}
+
+	if m.Height > 0 {
+		fmt.Fprintf(os.Stdout, "Height:     %d\n", m.Height)
+	}
+
+	if m.Center != "" {
+		fmt.Fprintf(os.Stdout, "Center:     %s\n", m.Center)
+	}
+
+	if m.Scale != "" {
+		fmt.Fprintf(os.Stdout, "Scale:      %s\n", m.Scale)
+	}
+
+	if m.Safe {
+		fmt.Fprintln(os.Stdout, "Safe:       true")
+	}
+
+	return nil
+}
+
+// EditCmd re-generates an existing meme URL with overrides. Values decoded
+// from the URL act as defaults; explicit flags win.
+type EditCmd struct {
+	URL  string   `arg:"" help:"Meme image URL to edit"`
+	Text []string `arg:"" optional:"" help:"Replacement text lines (replaces all lines; '-' reads stdin)"`
+
+	Line     []string `help:"Replace a single line as N=text (repeatable)" name:"line" sep:"none"`
+	Template string   `help:"Switch to a different template ID" name:"template"`
+
+	// Same flags as generate.
+	GenerateFlags
+}
+
+// Run decodes the URL, applies overrides and runs generate.
+func (c *EditCmd) Run(ctx context.Context, root *RootFlags) error {
+	m, err := api.ParseURL(c.URL)
+	if err != nil {
+		return err
+	}
+
+	gen, err := c.generateCmd(m)
+	if err != nil {
+		return err
+	}
+
+	return gen.Run(ctx, root)
+}
+
+// generateCmd merges the decoded URL with explicit overrides into a
+// GenerateCmd. --text-file replaces all lines, like replacement text.
+func (c *EditCmd) generateCmd(m *api.MemeURL) (*GenerateCmd, error) {
+	if c.TextFile != "" && len(c.Line) > 0 {
+		return nil, errors.New("--line cannot be combined with --text-file")
+	}
+
+	text := m.Text
+	if len(c.Text) > 0 || c.TextFile != "" {
+		text = c.Text
+	}
+
+	text, err := applyLineOverrides(text, c.Line)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(text) == 0 && c.TextFile == "" {
+		return nil, fmt.Errorf("%s has no text lines; pass replacement text to add some", c.URL)
+	}
+
+	gen := &GenerateCmd{
+		Template:      cmp.Or(c.Template, m.TemplateID),
+		Text:          text,
+		GenerateFlags: c.GenerateFlags,
+	}
+
+	gen.Format = cmp.Or(c.Format, m.Extension)
+	gen.Font = cmp.Or(c.Font, m.Font)
+	gen.TextColor = firstNonEmptySlice(c.TextColor, m.Color)
+	gen.Layout = cmp.Or(c.Layout, m.Layout)
+	gen.Style = firstNonEmptySlice(c.Style, m.Style)
+	gen.Width = firstPositive(c.Width, m.Width)
+	gen.Height = firstPositive(c.Height, m.Height)
+	gen.Center = cmp.Or(c.Center, m.Center)
+	gen.Scale = cmp.Or(c.Scale, m.Scale)
+	if gen.Safe == nil && m.Safe {
+		gen.Safe = &m.Safe
+	}
+	gen.Background = cmp.Or(c.Background, m.Background)
+
+	return gen, nil
+}
+
+// applyLineOverrides replaces individual lines given as "N=text" (1-based).
+// Lines past the end are padded with empty strings.
+func applyLineOverrides(lines, overrides []string) ([]string, error) {
+	out := append([]string(nil), lines...)
+
+	for _, o := range overrides {
+		idx, text, ok := strings.Cut(o, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --line %q: expected N=text", o)
+		}
+
+		n, err := strconv.Atoi(idx)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid --line %q: line number must be a positive integer", o)
+		}
+
+		for len(out) < n {
+			out = append(out, "")
+		}
+
+		out[n-1] = text
+	}
+
+	return out, nil
+}
+
+// firstNonEmptySlice returns the first non-empty slice.
+func firstNonEmptySlice(vals ...[]string) []string {
+	for _, v := range vals {
+		if len(v) > 0 {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// firstPositive returns the first value greater than zero.
+func firstPositive(vals ...int) int {
+	for _, v := range vals {
+		if v > 0 {
+			return v
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/api"
+)
+
+const editSourceURL = "https://api.memegen.link/images/drake/writing_memes/using_memelink.png?font=impact&color=white,black&width=400"
+
+func TestDecodeCmd_Human(t *testing.T) {
+	cmd := &DecodeCmd{URL: editSourceURL}
+
+	output := captureStdout(t, func() {
+		require.NoError(t, cmd.Run(testCtxNoClient(t, false)))
+	})
+
+	assert.Contains(t, output, "Template:   drake")
+	assert.Contains(t, output, "Line 1:     writing memes")
+	assert.Contains(t, output, "Line 2:     using memelink")
+	assert.Contains(t, output, "Extension:  png")
+	assert.Contains(t, output, "Font:       impact")
+	assert.Contains(t, output, "Color:      white, black")
+	assert.Contains(t, output, "Width:      400")
+}
+
+func TestDecodeCmd_JSON(t *testing.T) {
+	cmd := &DecodeCmd{URL: editSourceURL}
+
+	output := captureStdout(t, func() {
+		require.NoError(t, cmd.Run(testCtxNoClient(t, true)))
+	})
+
+	var parsed map[string]any
+	require.NoError(t, json.Unmarshal([]byte(output), &parsed))
+	assert.Equal(t, "drake", parsed["template_id"])
+	assert.Equal(t, []any{"writing memes", "using memelink"}, parsed["text"])
+	assert.Equal(t, "png", parsed["extension"])
+	assert.InDelta(t, 400, parsed["width"], 0.001)
+}
+
+func TestDecodeCmd_NotMemeURL(t *testing.T) {
+	cmd := &DecodeCmd{URL: "https://example.com/cat.jpg"}
+	err := cmd.Run(testCtxNoClient(t, false))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a memegen image URL")
+}
+
+func TestEditCmd_ReplaceLine(t *testing.T) {
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"url":"https://api.memegen.link/images/drake/writing_memes/ship_it.png"}`))
+	}))
+	defer srv.Close()
+
+	ctx := testCtx(t, srv.URL, false)
+	cmd := &EditCmd{URL: editSourceURL, Line: []string{"2=ship it"}}
+
+	var runErr error
+	output := captureStdout(t, func() { runErr = cmd.Run(ctx, &RootFlags{}) })
+	require.NoError(t, runErr)
+
+	var parsed map[string]any
+	require.NoError(t, json.Unmarshal(gotBody, &parsed))
+	assert.Equal(t, "drake", parsed["template_id"])
+	assert.Equal(t, []any{"writing memes", "ship it"}, parsed["text"])
+	assert.Equal(t, "png", parsed["extension"], "extension carried over from URL")
+	assert.Equal(t, "impact", parsed["font"], "font carried over from URL")
+
+	// Presentation params from the source URL are re-applied.
+	assert.Contains(t, output, "color=white%2Cblack")
+	assert.Contains(t, output, "width=400")
+}
+
+func TestEditCmd_FlagsOverrideURL(t *testing.T) {
+	cmd := &EditCmd{
+		URL:  editSourceURL,
+		Text: []string{"new top", "new bottom"},
+		GenerateFlags: GenerateFlags{
+			Format: "gif",
+			Font:   "comic",
+			Width:  800,
+		},
+	}
+
+	gen, err := cmd.generateCmd(mustParse(t, editSourceURL))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"new top", "new bottom"}, gen.Text)
+	assert.Equal(t, "gif", gen.Format)
+	assert.Equal(t, "comic", gen.Font)
+	assert.Equal(t, 800, gen.Width)
+	assert.Equal(t, []string{"white", "black"}, gen.TextColor)
+}
+
+func TestEditCmd_SharesGenerateFlags(t *testing.T) {
+	cmd := &EditCmd{
+		URL: editSourceURL,
+		GenerateFlags: GenerateFlags{
+			TextFile:  "lines.txt",
+			Render:    renderLocal,
+			FromFrame: 2,
+			ToFrame:   5,
+		},
+	}
+
+	gen, err := cmd.generateCmd(mustParse(t, editSourceURL))
+	require.NoError(t, err)
+
+	assert.Empty(t, gen.Text, "--text-file replaces the decoded lines")
+	assert.Equal(t, "lines.txt", gen.TextFile)
+	assert.Equal(t, renderLocal, gen.Render)
+	assert.Equal(t, 2, gen.FromFrame)
+	assert.Equal(t, 5, gen.ToFrame)
+
+	cmd.Line = []string{"1=top"}
+	_, err = cmd.generateCmd(mustParse(t, editSourceURL))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--line cannot be combined with --text-file")
+}
+
+func TestEditCmd_InvalidLine(t *testing.T) {
+	cmd := &EditCmd{URL: editSourceURL, Line: []string{"two=oops"}}
+
+	err := cmd.Run(testCtx(t, "http://unused", false), &RootFlags{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid --line")
+}
+
+func TestApplyLineOverrides_Pads(t *testing.T) {
+	got, err := applyLineOverrides([]string{"a"}, []string{"3=c"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "", "c"}, got)
+}
+
+func mustParse(t *testing.T, rawURL string) *api.MemeURL {
+	t.Helper()
+
+	m, err := api.ParseURL(rawURL)
+	require.NoError(t, err)
+
+	return m
+}
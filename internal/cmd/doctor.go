@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/outfmt"
+)
+
+// DoctorCmd probes the configured Memegen server and reports its version
+// and capabilities.
+type DoctorCmd struct{}
+
+// doctorCheck is the outcome of one probe.
+type doctorCheck struct {
+	OK         bool   `json:"ok"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// doctorReport is the full doctor result (also the --json shape).
+type doctorReport struct {
+	APIURL    string      `json:"api_url"`
+	APIKey    bool        `json:"api_key"`
+	Root      doctorCheck `json:"root"`
+	Version   string      `json:"version,omitempty"`
+	Endpoints []string    `json:"endpoints,omitempty"`
+	Templates doctorCheck `json:"templates"`
+	Count     int         `json:"template_count"`
+	Animated  int         `json:"animated_count"`
+}
+
+// Run probes GET / and GET /templates and prints a report. Returns an
+// error when any probe failed.
+func (c *DoctorCmd) Run(ctx context.Context) error {
+	client := api.ClientFromContext(ctx)
+	if client == nil {
+		return errors.New("api client not found in context")
+	}
+
+	report := doctorReport{
+		APIURL: client.BaseURL(),
+		APIKey: os.Getenv("MEMEGEN_API_KEY") != "",
+	}
+
+	start := time.Now()
+	info, err := client.GetServerInfo(ctx)
+	report.Root = newDoctorCheck(start, err)
+
+	if err == nil {
+		report.Version = info.Version
+
+		for name := range info.Endpoints {
+			report.Endpoints = append(report.Endpoints, name)
+		}
+
+		sort.Strings(report.Endpoints)
+	}
+
+	start = time.Now()
+	templates, err := client.ListTemplates(ctx, "")
+	report.Templates = newDoctorCheck(start, err)
+	report.Count = len(templates)
+	report.Animated = len(filterAnimated(templates))
+
+	if outfmt.IsJSON(ctx) {
+		if err := outfmt.WriteJSON(os.Stdout, report); err != nil {
+			return err
+		}
+	} else {
+		report.print()
+	}
+
+	failed := 0
+
+	for _, check := range []doctorCheck{report.Root, report.Templates} {
+		if !check.OK {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of 2 checks failed for %s", failed, report.APIURL)
+	}
+
+	return nil
+}
+
+// newDoctorCheck records the duration since start and the probe error.
+func newDoctorCheck(start time.Time, err error) doctorCheck {
+	check := doctorCheck{OK: err == nil, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		check.Error = err.Error()
+	}
+
+	return check
+}
+
+// print writes the human-readable report.
+func (r doctorReport) print() {
+	keyStatus := "not set"
+	if r.APIKey {
+		keyStatus = "set"
+	}
+
+	fmt.Fprintf(os.Stdout, "API URL:    %s\n", r.APIURL)
+	fmt.Fprintf(os.Stdout, "API key:    %s\n", keyStatus)
+	fmt.Fprintf(os.Stdout, "Root:       %s\n", r.Root.status())
+
+	if r.Root.OK {
+		version := r.Version
+		if version == "" {
+			version = "unknown"
+		}
+
+		fmt.Fprintf(os.Stdout, "Version:    %s\n", version)
+		fmt.Fprintf(os.Stdout, "Endpoints:  %s\n", strings.Join(r.Endpoints, ", "))
+	}
+
+	templates := r.Templates.status()
+	if r.Templates.OK {
+		templates = fmt.Sprintf("%s, %d templates, %d animated", templates, r.Count, r.Animated)
+	}
+
+	fmt.Fprintf(os.Stdout, "Templates:  %s\n", templates)
+}
+
+// status renders "ok (12ms)" or "FAIL: <error>".
+func (c doctorCheck) status() string {
+	if !c.OK {
+		return "FAIL: " + c.Error
+	}
+
+	return fmt.Sprintf("ok (%dms)", c.DurationMS)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func doctorServer(t *testing.T, templatesStatus int) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`{"templates":"x","images":"x","fonts":"x","version":"11.0.0"}`))
+		case "/templates":
+			w.WriteHeader(templatesStatus)
+			_, _ = w.Write([]byte(templatesListJSON))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestDoctorCmd_Human(t *testing.T) {
+	t.Setenv("MEMEGEN_API_KEY", "")
+
+	srv := doctorServer(t, http.StatusOK)
+	defer srv.Close()
+
+	var runErr error
+	output := captureStdout(t, func() { runErr = (&DoctorCmd{}).Run(testCtx(t, srv.URL, false)) })
+	require.NoError(t, runErr)
+
+	assert.Contains(t, output, "API URL:    "+srv.URL+"\n")
+	assert.Contains(t, output, "API key:    not set\n")
+	assert.Contains(t, output, "Root:       ok (")
+	assert.Contains(t, output, "Version:    11.0.0\n")
+	assert.Contains(t, output, "Endpoints:  fonts, images, templates\n")
+	assert.Contains(t, output, "3 templates, 2 animated")
+}
+
+func TestDoctorCmd_JSON(t *testing.T) {
+	srv := doctorServer(t, http.StatusOK)
+	defer srv.Close()
+
+	output := captureStdout(t, func() { require.NoError(t, (&DoctorCmd{}).Run(testCtx(t, srv.URL, true))) })
+
+	var report doctorReport
+	require.NoError(t, json.Unmarshal([]byte(output), &report))
+	assert.True(t, report.Root.OK)
+	assert.True(t, report.Templates.OK)
+	assert.Equal(t, "11.0.0", report.Version)
+	assert.Equal(t, 3, report.Count)
+}
+
+func TestDoctorCmd_TemplatesFail(t *testing.T) {
+	srv := doctorServer(t, http.StatusNotFound)
+	defer srv.Close()
+
+	var runErr error
+	output := captureStdout(t, func() { runErr = (&DoctorCmd{}).Run(testCtx(t, srv.URL, false)) })
+
+	require.Error(t, runErr)
+	assert.Contains(t, runErr.Error(), "1 of 2 checks failed")
+	assert.Contains(t, output, "Templates:  FAIL: ")
+}
+
+func TestDoctorCmd_Unreachable(t *testing.T) {
+	srv := doctorServer(t, http.StatusOK)
+	srv.Close()
+
+	var runErr error
+	output := captureStdout(t, func() { runErr = (&DoctorCmd{}).Run(testCtx(t, srv.URL, false)) })
+
+	require.Error(t, runErr)
+	assert.Contains(t, runErr.Error(), "2 of 2 checks failed")
+	assert.Contains(t, output, "Root:       FAIL: ")
+	assert.NotContains(t, output, "Version:")
+}
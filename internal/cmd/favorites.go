@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/list"
+
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/config"
+	"github.com/dedene/memelink-cli/internal/favorites"
+	"github.com/dedene/memelink-cli/internal/history"
+	"github.com/dedene/memelink-cli/internal/outfmt"
+	"github.com/dedene/memelink-cli/internal/tui"
+	"github.com/dedene/memelink-cli/internal/ui"
+)
+
+// FavCmd groups favorite template subcommands.
+type FavCmd struct {
+	List FavListCmd `cmd:"" default:"1" help:"List favorite templates"`
+	Add  FavAddCmd  `cmd:"" help:"Add templates to the favorites"`
+	Rm   FavRmCmd   `cmd:"" help:"Remove templates from the favorites"`
+}
+
+// FavAddCmd adds favorite templates.
+type FavAddCmd struct {
+	Templates []string `arg:"" name:"template" help:"Template IDs (see 'memelink templates')"`
+}
+
+// Run checks that each template exists, unless --force, and saves them.
+func (c *FavAddCmd) Run(ctx context.Context, root *RootFlags) error {
+	if !root.Force {
+		for _, id := range c.Templates {
+			if _, err := lookupTemplate(ctx, id, false); err != nil {
+				return withTemplateSuggestions(ctx, err, id, false)
+			}
+		}
+	}
+
+	path, err := config.FavoritesPath()
+	if err != nil {
+		return err
+	}
+
+	favs, err := favorites.Add(ctx, path, c.Templates...)
+	if err != nil {
+		return err
+	}
+
+	return printFavoritesResult(ctx, favs)
+}
+
+// FavRmCmd removes favorite templates.
+type FavRmCmd struct {
+	Templates []string `arg:"" name:"template" help:"Template IDs"`
+}
+
+// Run removes the templates; IDs that are not favorites are ignored.
+func (c *FavRmCmd) Run(ctx context.Context) error {
+	path, err := config.FavoritesPath()
+	if err != nil {
+		return err
+	}
+
+	favs, err := favorites.Remove(ctx, path, c.Templates...)
+	if err != nil {
+		return err
+	}
+
+	return printFavoritesResult(ctx, favs)
+}
+
+// printFavoritesResult reports the favorites after a change.
+func printFavoritesResult(ctx context.Context, favs []string) error {
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"favorites": nonNil(favs)})
+	}
+
+	fmt.Fprintf(os.Stderr, "%d favorite templates\n", len(favs))
+
+	return nil
+}
+
+// FavListCmd lists favorite templates.
+type FavListCmd struct{}
+
+// favoriteJSON is the --json shape of one favorite.
+type favoriteJSON struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Uses int    `json:"uses"`
+}
+
+// Run prints the favorites in the order they were added, with their names
+// from the template cache and their use counts from the history.
+func (c *FavListCmd) Run(ctx context.Context) error {
+	r := loadTemplateRanking()
+
+	names := make(map[string]string)
+	for _, t := range loadCachedTemplates(ctx) {
+		names[t.ID] = t.Name
+	}
+
+	favs := make([]favoriteJSON, 0, len(r.favorites))
+	for _, id := range r.favorites {
+		favs = append(favs, favoriteJSON{ID: id, Name: names[id], Uses: r.uses[id]})
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, favs)
+	}
+
+	if len(favs) == 0 {
+		fmt.Fprintln(os.Stderr, "No favorite templates (add one with 'memelink fav add <template>')")
+
+		return nil
+	}
+
+	rows := make([][]string, 0, len(favs))
+	for _, f := range favs {
+		rows = append(rows, []string{f.ID, f.Name, usesColumn(f.Uses)})
+	}
+
+	colorEnabled := false
+	if u := ui.FromContext(ctx); u != nil {
+		colorEnabled = u.Out().ColorEnabled()
+	}
+
+	fmt.Fprint(os.Stdout, ui.RenderTable([]string{"ID", "Name", "Uses"}, rows, colorEnabled))
+
+	return nil
+}
+
+// templateRanking orders templates for the picker and the templates table:
+// favorites first, in the order they were added, then by how often the
+// history used them, then in API order.
+type templateRanking struct {
+	favorites []string
+	uses      map[string]int
+}
+
+// loadTemplateRanking reads the favorites and history. Ranking is a
+// convenience, so unreadable files only log and rank nothing.
+func loadTemplateRanking() templateRanking {
+	r := templateRanking{uses: map[string]int{}}
+
+	if path, err := config.FavoritesPath(); err == nil {
+		favs, err := favorites.Load(path)
+		if err != nil {
+			slog.Debug("loading favorites", "error", err)
+		}
+
+		r.favorites = favs
+	}
+
+	entries, err := loadHistory()
+	if err != nil {
+		slog.Debug("loading history", "error", err)
+	}
+
+	r.uses = history.TemplateUses(entries)
+
+	return r
+}
+
+// isFavorite reports whether id is a favorite.
+func (r templateRanking) isFavorite(id string) bool {
+	return slices.Contains(r.favorites, id)
+}
+
+// sort returns templates in ranked order, leaving the input untouched.
+func (r templateRanking) sort(templates []api.Template) []api.Template {
+	rank := func(id string) int {
+		if i := slices.Index(r.favorites, id); i >= 0 {
+			return i
+		}
+
+		return len(r.favorites)
+	}
+
+	sorted := slices.Clone(templates)
+	slices.SortStableFunc(sorted, func(a, b api.Template) int {
+		return cmp.Or(
+			cmp.Compare(rank(a.ID), rank(b.ID)),
+			cmp.Compare(r.uses[b.ID], r.uses[a.ID]),
+		)
+	})
+
+	return sorted
+}
+
+// items wraps templates as ranked picker items. With favorites, they get
+// their own section above the rest.
+func (r templateRanking) items(templates []api.Template) []list.Item {
+	sorted := r.sort(templates)
+	items := make([]list.Item, 0, len(sorted)+2)
+
+	for i, t := range sorted {
+		fav := r.isFavorite(t.ID)
+
+		switch {
+		case i == 0 && fav:
+			items = append(items, tui.NewSectionItem("★ Favorites"))
+		case !fav && i > 0 && r.isFavorite(sorted[i-1].ID):
+			items = append(items, tui.NewSectionItem("All templates"))
+		}
+
+		items = append(items, tui.NewTemplateItem(t).WithUses(r.uses[t.ID]))
+	}
+
+	return items
+}
+
+// usesColumn formats a use count for tables, leaving unused templates blank.
+func usesColumn(n int) string {
+	if n == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%d", n)
+}
+
+// nonNil turns a nil slice into an empty one so JSON prints [] not null.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	return s
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/config"
+	"github.com/dedene/memelink-cli/internal/favorites"
+	"github.com/dedene/memelink-cli/internal/history"
+	"github.com/dedene/memelink-cli/internal/outfmt"
+	"github.com/dedene/memelink-cli/internal/tui"
+)
+
+func TestFav_AddListRm(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"Template not found"}`))
+	}))
+	defer srv.Close()
+
+	seedTemplateCache(t, srv.URL)
+
+	ctx := testCtxWithConfig(t, srv.URL)
+
+	captureStderr(t, func() {
+		require.NoError(t, (&FavAddCmd{Templates: []string{"fry", "drake"}}).Run(ctx, &RootFlags{}))
+	})
+
+	err := (&FavAddCmd{Templates: []string{"drak"}}).Run(ctx, &RootFlags{})
+	require.Error(t, err, "unknown templates are rejected")
+	assert.Contains(t, err.Error(), "did you mean: drake")
+
+	captureStderr(t, func() {
+		require.NoError(t, (&FavAddCmd{Templates: []string{"mine"}}).Run(ctx, &RootFlags{Force: true}))
+	})
+
+	path, err := config.FavoritesPath()
+	require.NoError(t, err)
+	require.NoError(t, writeHistory(t, history.Entry{Mode: history.ModeTemplate, Template: "drake"}))
+
+	var listed []favoriteJSON
+
+	out := captureStdout(t, func() {
+		require.NoError(t, (&FavListCmd{}).Run(outfmt.WithMode(ctx, outfmt.Mode{JSON: true})))
+	})
+	require.NoError(t, json.Unmarshal([]byte(out), &listed))
+	assert.Equal(t, []favoriteJSON{
+		{ID: "fry", Name: "Futurama Fry"},
+		{ID: "drake", Name: "Drake Hotline Bling", Uses: 1},
+		{ID: "mine"},
+	}, listed)
+
+	captureStderr(t, func() { require.NoError(t, (&FavRmCmd{Templates: []string{"fry", "mine"}}).Run(ctx)) })
+
+	favs, err := favorites.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"drake"}, favs)
+}
+
+func TestFav_ListEmpty(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	ctx := testCtxNoClient(t, false)
+
+	stderr := captureStderr(t, func() { require.NoError(t, (&FavListCmd{}).Run(ctx)) })
+	assert.Contains(t, stderr, "No favorite templates")
+
+	out := captureStdout(t, func() {
+		require.NoError(t, (&FavListCmd{}).Run(outfmt.WithMode(ctx, outfmt.Mode{JSON: true})))
+	})
+	assert.JSONEq(t, "[]", out)
+}
+
+func TestTemplateRanking_Sort(t *testing.T) {
+	templates := []api.Template{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}, {ID: "e"}}
+	r := templateRanking{favorites: []string{"d", "b"}, uses: map[string]int{"b": 1, "c": 2, "e": 5}}
+
+	ids := func(ts []api.Template) []string {
+		out := make([]string, len(ts))
+		for i, t := range ts {
+			out[i] = t.ID
+		}
+
+		return out
+	}
+
+	assert.Equal(t, []string{"d", "b", "e", "c", "a"}, ids(r.sort(templates)))
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, ids(templates), "input is untouched")
+}
+
+func TestTemplateRanking_Items(t *testing.T) {
+	templates := []api.Template{{ID: "a", Name: "A"}, {ID: "b", Name: "B"}, {ID: "c", Name: "C"}}
+
+	titles := func(r templateRanking) []string {
+		var out []string
+		for _, item := range r.items(templates) {
+			switch it := item.(type) {
+			case tui.SectionItem:
+				out = append(out, "# "+it.Title())
+			case tui.TemplateItem:
+				out = append(out, it.Title())
+			}
+		}
+
+		return out
+	}
+
+	assert.Equal(t, []string{"C", "A", "B"}, titles(templateRanking{uses: map[string]int{"c": 3}}),
+		"no sections without favorites")
+	assert.Equal(t, []string{"# ★ Favorites", "B", "# All templates", "C", "A"},
+		titles(templateRanking{favorites: []string{"b"}, uses: map[string]int{"c": 3}}))
+	assert.Equal(t, []string{"# ★ Favorites", "A", "B", "C"},
+		titles(templateRanking{favorites: []string{"a", "b", "c"}}), "no empty section")
+}
+
+func TestTemplatesCmd_List_Ranked(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(templatesListJSON))
+	}))
+	defer srv.Close()
+
+	path, err := config.FavoritesPath()
+	require.NoError(t, err)
+
+	_, err = favorites.Add(t.Context(), path, "fry")
+	require.NoError(t, err)
+	require.NoError(t, writeHistory(t,
+		history.Entry{Mode: history.ModeTemplate, Template: "buzz"},
+		history.Entry{Mode: history.ModeTemplate, Template: "buzz"},
+	))
+
+	ctx := testCtx(t, srv.URL, false)
+
+	out := captureStdout(t, func() { require.NoError(t, (&TemplatesCmd{}).Run(ctx, &RootFlags{NoInput: true})) })
+
+	fry, buzz, drake := strings.Index(out, "fry"), strings.Index(out, "buzz"), strings.Index(out, "drake")
+	assert.Less(t, fry, buzz, "favorites first")
+	assert.Less(t, buzz, drake, "then most used")
+	assert.Contains(t, out, "★")
+
+	jsonOut := captureStdout(t, func() {
+		require.NoError(t, (&TemplatesCmd{}).Run(outfmt.WithMode(ctx, outfmt.Mode{JSON: true}), &RootFlags{}))
+	})
+
+	var listed []api.Template
+	require.NoError(t, json.Unmarshal([]byte(jsonOut), &listed))
+	require.Len(t, listed, 3)
+	assert.Equal(t, "drake", listed[0].ID, "JSON keeps the API order")
+}
+
+// writeHistory appends entries to the history log.
+func writeHistory(t *testing.T, entries ...history.Entry) error {
+	t.Helper()
+
+	path, err := config.HistoryPath()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if _, err := history.Append(t.Context(), path, e, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
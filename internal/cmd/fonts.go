@@ -13,7 +13,8 @@ import (
 
 // FontsCmd lists or views fonts.
 type FontsCmd struct {
-	ID string `arg:"" optional:"" help:"Font ID for detail view"`
+	ID      string `arg:"" optional:"" help:"Font ID for detail view"`
+	Refresh bool   `help:"Force cache refresh" name:"refresh"`
 }
 
 // Run executes the fonts command, dispatching to detail or list view.
@@ -34,7 +35,7 @@ func (c *FontsCmd) runDetail(ctx context.Context) error {
 
 	font, err := client.GetFont(ctx, c.ID)
 	if err != nil {
-		return fmt.Errorf("getting font: %w", err)
+		return withFontSuggestions(ctx, fmt.Errorf("getting font: %w", err), c.ID)
 	}
 
 	if outfmt.IsJSON(ctx) {
@@ -54,14 +55,15 @@ func (c *FontsCmd) runDetail(ctx context.Context) error {
 	return nil
 }
 
-// runList fetches all fonts and prints them as a table.
+// runList fetches all fonts (from the cache while fresh) and prints them
+// as a table.
 func (c *FontsCmd) runList(ctx context.Context) error {
 	client := api.ClientFromContext(ctx)
 	if client == nil {
 		return errors.New("api client not found in context")
 	}
 
-	fonts, err := client.ListFonts(ctx)
+	fonts, err := fetchFonts(ctx, client, c.Refresh)
 	if err != nil {
 		return fmt.Errorf("listing fonts: %w", err)
 	}
@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -13,9 +15,11 @@ import (
 
 	"github.com/dedene/memelink-cli/internal/actions"
 	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/cache"
 	"github.com/dedene/memelink-cli/internal/config"
 	"github.com/dedene/memelink-cli/internal/outfmt"
 	"github.com/dedene/memelink-cli/internal/preview"
+	"github.com/dedene/memelink-cli/internal/upload"
 )
 
 // validFormats lists accepted image formats.
@@ -33,7 +37,15 @@ var validLayouts = map[string]bool{
 type GenerateCmd struct {
 	// Positional: first is template ID or auto-generate text; rest are text lines.
 	Template string   `arg:"" optional:"" help:"Template ID (omit for auto-generate, 'custom' for custom background)"`
-	Text     []string `arg:"" optional:"" help:"Text lines for the meme"`
+	Text     []string `arg:"" optional:"" help:"Text lines for the meme ('-' reads stdin)"`
+
+	GenerateFlags
+}
+
+// GenerateFlags are the text, customization and output flags shared by
+// generate and edit.
+type GenerateFlags struct {
+	TextFile string `help:"Read text lines from a file ('-' for stdin)" name:"text-file" type:"path"`
 
 	// Customization flags -- defaults empty; cascade fills from config/hardcoded.
 	Format     string   `help:"Image format (jpg,png,gif,webp)" short:"f"`
@@ -45,21 +57,25 @@ type GenerateCmd struct {
 	Height     int      `help:"Image height in pixels" name:"height"`
 	Center     string   `help:"Overlay center position (x,y)" name:"center"`
 	Scale      string   `help:"Overlay scale ratio" name:"scale"`
-	Safe       bool     `help:"Filter NSFW content" name:"safe"`
-	Background string   `help:"Custom background image URL (use with 'custom' template)" name:"background"`
+	Safe       *bool    `help:"Filter NSFW content" name:"safe" negatable:""`
+	Background string   `help:"Custom background image URL or local file (use with 'custom' template)" name:"background"`
+	Offline    bool     `help:"Build the meme URL locally without calling the API" name:"offline" aliases:"url-only"`
+	Render     string   `help:"Render with the Memegen API (api) or locally from cached blanks (local)" name:"render" enum:"api,local" default:"api"`
+	FromFrame  int      `help:"First GIF frame to caption with --render local (from 1)" name:"from-frame"`
+	ToFrame    int      `help:"Last GIF frame to caption with --render local" name:"to-frame"`
 
 	// Output action flags.
 	Copy       bool   `help:"Copy URL to clipboard" name:"copy" short:"c"`
 	Open       bool   `help:"Open URL in browser" name:"open" short:"o"`
 	Output     string `help:"Download image to file path" name:"output"`
-	AutoOutput bool   `help:"Download image to CWD with auto-generated name" short:"O"`
+	AutoOutput bool   `help:"Download image to output_dir (or CWD) with auto-generated name" short:"O"`
 
 	// Preview flag.
 	Preview *bool `help:"Show inline image preview" name:"preview" negatable:""`
 }
 
 // shouldPreview determines if inline preview should be shown.
-// Cascade: explicit flag > config preview > true (default ON for TTY).
+// Cascade: explicit flag > config preview (env, project, user) > true.
 // Always false when stderr is not a TTY or --no-input is set.
 func shouldPreview(flag *bool, cfg *config.Config, root *RootFlags) bool {
 	if !isatty.IsTerminal(os.Stderr.Fd()) {
@@ -70,285 +86,400 @@ func shouldPreview(flag *bool, cfg *config.Config, root *RootFlags) bool {
 		return false
 	}
 
-	if flag != nil {
-		return *flag
-	}
-
-	if cfg != nil && cfg.Preview != nil {
-		return *cfg.Preview
-	}
+	return config.Resolve(cfg, config.Flags{Preview: flag}).Preview
+}
 
-	return true
+// generateResult is the outcome of a single generation. Generator and
+// Confidence are only set by auto-generate mode; Path (instead of URL) only
+// by local rendering.
+type generateResult struct {
+	URL        string
+	Path       string
+	Automatic  bool
+	Generator  string
+	Confidence float64
 }
 
 // Run executes the generate command, dispatching to one of three modes:
 // auto-generate, template-based, or custom-background.
 func (c *GenerateCmd) Run(ctx context.Context, root *RootFlags) error {
-	if c.Template == "" && len(c.Text) == 0 {
-		return errors.New("provide text or template ID; run 'memelink --help' for usage")
-	}
-
 	cfg := config.FromContext(ctx)
 
-	// Validate effective format.
-	format := c.effectiveFormat(cfg)
-	if !validFormats[format] {
-		return fmt.Errorf("invalid format %q: must be one of jpg, png, gif, webp", format)
+	if err := c.applyPreset(cfg); err != nil {
+		return err
 	}
 
-	// Validate effective layout.
-	layout := c.effectiveLayout(cfg)
-	if !validLayouts[layout] {
-		return fmt.Errorf("invalid layout %q: must be one of default, top", layout)
+	if err := c.resolveText(ctx); err != nil {
+		return err
 	}
 
-	// Auto-generate mode: single positional arg is the text.
-	if c.Template != "" && len(c.Text) == 0 {
-		return c.runAutomatic(ctx, cfg, root)
+	res, err := c.generate(ctx, cfg, root != nil && root.Force)
+	if err != nil {
+		return err
 	}
 
-	// Custom background mode.
-	if c.Template == "custom" {
-		return c.runCustom(ctx, cfg, root)
-	}
+	recordHistory(ctx, c.historyEntry(cfg, res))
 
-	// Template-based mode.
-	return c.runTemplate(ctx, cfg, root)
+	return c.output(ctx, res, cfg, root)
 }
 
-// effectiveFormat returns: explicit flag > config default > "jpg".
-func (c *GenerateCmd) effectiveFormat(cfg *config.Config) string {
-	if c.Format != "" {
-		return c.Format
+// applyPreset expands a config preset named by the template argument. Preset
+// values only fill options left empty by flags, so the usual cascade (flag >
+// preset > config default > hardcoded) holds.
+func (c *GenerateCmd) applyPreset(cfg *config.Config) error {
+	if cfg == nil || c.Template == "" {
+		return nil
 	}
 
-	if cfg != nil && cfg.DefaultFormat != "" {
-		return cfg.DefaultFormat
+	name := c.Template
+
+	p, ok := cfg.Presets[name]
+	if !ok {
+		return nil
 	}
 
-	return "jpg"
-}
+	if p.Template == "" {
+		return fmt.Errorf("preset %q has no template; set presets.%s.template", name, name)
+	}
 
-// effectiveLayout returns: explicit flag > config default > "default".
-func (c *GenerateCmd) effectiveLayout(cfg *config.Config) string {
-	if c.Layout != "" {
-		return c.Layout
+	if len(c.Text) == 0 && c.TextFile == "" {
+		return fmt.Errorf("preset %q needs text lines", name)
 	}
 
-	if cfg != nil && cfg.DefaultLayout != "" {
-		return cfg.DefaultLayout
+	c.Template = p.Template
+	c.Font = cmp.Or(c.Font, p.Font)
+	c.Format = cmp.Or(c.Format, p.Format)
+	c.Layout = cmp.Or(c.Layout, p.Layout)
+	c.TextColor = firstNonEmptySlice(c.TextColor, p.TextColor)
+	c.Style = firstNonEmptySlice(c.Style, p.Style)
+	c.Background = cmp.Or(c.Background, p.Background)
+
+	if c.Safe == nil {
+		c.Safe = p.Safe
 	}
 
-	return "default"
+	return nil
 }
 
-// effectiveFont returns: explicit flag > config default > "" (API default).
-func (c *GenerateCmd) effectiveFont(cfg *config.Config) string {
-	if c.Font != "" {
-		return c.Font
+// generate validates the effective options, dispatches to the matching mode
+// and returns the meme URL with presentation query params appended. It has no
+// output side effects, so batch runs share it with Run. force skips the
+// client-side text and style checks against template metadata.
+func (c *GenerateCmd) generate(ctx context.Context, cfg *config.Config, force bool) (*generateResult, error) {
+	if c.Template == "" && len(c.Text) == 0 {
+		return nil, errors.New("provide text or template ID; run 'memelink --help' for usage")
 	}
 
-	if cfg != nil && cfg.DefaultFont != "" {
-		return cfg.DefaultFont
+	if err := c.checkOptions(cfg); err != nil {
+		return nil, err
 	}
 
-	return ""
-}
-
-// effectiveSafe returns: explicit --safe flag > config safe > false.
-// Since bool default is false, config safe=true applies when flag not passed.
-func (c *GenerateCmd) effectiveSafe(cfg *config.Config) bool {
-	if c.Safe {
-		return true
+	// Local rendering resolves the template itself and validates against it.
+	if c.Render == renderLocal {
+		return c.runLocal(ctx, cfg, force)
 	}
 
-	if cfg != nil && cfg.Safe != nil {
-		return *cfg.Safe
+	if !force {
+		if err := c.validate(ctx); err != nil {
+			return nil, err
+		}
 	}
 
-	return false
-}
+	var (
+		res *generateResult
+		err error
+	)
 
-// effectiveCopy returns: explicit --copy flag > config auto_copy > false.
-func (c *GenerateCmd) effectiveCopy(cfg *config.Config) bool {
-	if c.Copy {
-		return true
+	switch {
+	case c.Offline:
+		// Offline mode: construct the URL locally, no API round trip.
+		res, err = c.runOffline(ctx, cfg)
+	case c.Template != "" && len(c.Text) == 0:
+		// Auto-generate mode: single positional arg is the text.
+		res, err = c.runAutomatic(ctx, cfg)
+	case c.Template == "custom":
+		// Custom background mode.
+		res, err = c.runCustom(ctx, cfg)
+	default:
+		// Template-based mode.
+		res, err = c.runTemplate(ctx, cfg)
 	}
 
-	if cfg != nil && cfg.AutoCopy != nil {
-		return *cfg.AutoCopy
+	if err != nil {
+		return nil, err
 	}
 
-	return false
+	res.URL, err = api.AppendQueryParams(res.URL, c.queryParams(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("appending query params: %w", err)
+	}
+
+	return res, nil
 }
 
-// effectiveOpen returns: explicit --open flag > config auto_open > false.
-func (c *GenerateCmd) effectiveOpen(cfg *config.Config) bool {
-	if c.Open {
-		return true
+// checkOptions validates the effective format and layout and the frame
+// flags.
+func (c *GenerateCmd) checkOptions(cfg *config.Config) error {
+	opts := c.options(cfg)
+
+	if !validFormats[opts.Format] {
+		return fmt.Errorf("invalid format %q: must be one of jpg, png, gif, webp", opts.Format)
 	}
 
-	if cfg != nil && cfg.AutoOpen != nil {
-		return *cfg.AutoOpen
+	if !validLayouts[opts.Layout] {
+		return fmt.Errorf("invalid layout %q: must be one of default, top", opts.Layout)
 	}
 
-	return false
+	if (c.FromFrame != 0 || c.ToFrame != 0) && c.Render != renderLocal {
+		return errors.New("--from-frame and --to-frame need --render local")
+	}
+
+	return nil
+}
+
+// options resolves effective settings: flag > env > project config > user
+// config > default (see config.Resolve).
+func (c *GenerateCmd) options(cfg *config.Config) config.Options {
+	return config.Resolve(cfg, config.Flags{
+		Format:  c.Format,
+		Font:    c.Font,
+		Layout:  c.Layout,
+		Safe:    c.Safe,
+		Copy:    c.Copy,
+		Open:    c.Open,
+		Preview: c.Preview,
+	})
 }
 
 // runActions fires post-generation actions (clipboard, browser, download).
+// Downloads go through images, so a previewed meme is not fetched twice.
 // Errors are non-fatal warnings to stderr.
-func (c *GenerateCmd) runActions(memeURL string, cfg *config.Config) {
-	if c.effectiveCopy(cfg) {
+func (c *GenerateCmd) runActions(ctx context.Context, images *cache.Images, memeURL string, cfg *config.Config) {
+	opts := c.options(cfg)
+
+	if opts.AutoCopy {
 		if err := actions.CopyToClipboard(memeURL); err != nil {
 			fmt.Fprintf(os.Stderr, "warning: clipboard: %v\n", err)
 		}
 	}
 
-	if c.effectiveOpen(cfg) {
+	if opts.AutoOpen {
 		if err := actions.OpenInBrowser(memeURL); err != nil {
 			fmt.Fprintf(os.Stderr, "warning: browser: %v\n", err)
 		}
 	}
 
 	if c.Output != "" {
-		if err := actions.DownloadFile(memeURL, c.Output); err != nil {
+		if err := saveImage(ctx, images, memeURL, c.Output); err != nil {
 			fmt.Fprintf(os.Stderr, "warning: download: %v\n", err)
 		}
 	}
 
 	if c.AutoOutput {
-		if err := actions.DownloadFile(memeURL, actions.AutoFilename(memeURL)); err != nil {
+		if err := saveImage(ctx, images, memeURL, autoOutputPath(memeURL, cfg)); err != nil {
 			fmt.Fprintf(os.Stderr, "warning: download: %v\n", err)
 		}
 	}
 }
 
+// autoOutputPath names the -O download: the URL's file name inside config
+// output_dir (created on demand) or the working directory.
+func autoOutputPath(memeURL string, cfg *config.Config) string {
+	name := actions.AutoFilename(memeURL)
+	if cfg == nil || cfg.OutputDir == "" {
+		return name
+	}
+
+	if err := os.MkdirAll(cfg.OutputDir, 0o750); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: output dir: %v\n", err)
+	}
+
+	return filepath.Join(cfg.OutputDir, name)
+}
+
 // runAutomatic calls POST /images/automatic with the provided text.
-func (c *GenerateCmd) runAutomatic(ctx context.Context, cfg *config.Config, root *RootFlags) error {
+func (c *GenerateCmd) runAutomatic(ctx context.Context, cfg *config.Config) (*generateResult, error) {
 	client := api.ClientFromContext(ctx)
 	if client == nil {
-		return errors.New("api client not found in context")
+		return nil, errors.New("api client not found in context")
 	}
 
 	resp, err := client.GenerateAutomatic(ctx, api.AutomaticRequest{
 		Text: c.Template,
-		Safe: c.effectiveSafe(cfg),
+		Safe: c.options(cfg).Safe,
 	})
 	if err != nil {
-		return fmt.Errorf("generating meme: %w", err)
-	}
-
-	memeURL, err := api.AppendQueryParams(resp.URL, c.queryParams(cfg))
-	if err != nil {
-		return fmt.Errorf("appending query params: %w", err)
-	}
-
-	if shouldPreview(c.Preview, cfg, root) {
-		_ = preview.Show(ctx, memeURL, preview.Options{
-			Writer: os.Stderr,
-		})
+		return nil, fmt.Errorf("generating meme: %w", err)
 	}
 
-	if outfmt.IsJSON(ctx) {
-		if err := outfmt.WriteJSON(os.Stdout, map[string]any{
-			"url":        memeURL,
-			"generator":  resp.Generator,
-			"confidence": resp.Confidence,
-		}); err != nil {
-			return err
-		}
-
-		c.runActions(memeURL, cfg)
-
-		return nil
-	}
-
-	fmt.Fprintln(os.Stdout, memeURL)
-	c.runActions(memeURL, cfg)
-
-	return nil
+	return &generateResult{
+		URL:        resp.URL,
+		Automatic:  true,
+		Generator:  resp.Generator,
+		Confidence: resp.Confidence,
+	}, nil
 }
 
 // runTemplate calls POST /images for template-based meme generation.
-func (c *GenerateCmd) runTemplate(ctx context.Context, cfg *config.Config, root *RootFlags) error {
+func (c *GenerateCmd) runTemplate(ctx context.Context, cfg *config.Config) (*generateResult, error) {
+	opts := c.options(cfg)
+
 	client := api.ClientFromContext(ctx)
 	if client == nil {
-		return errors.New("api client not found in context")
+		return nil, errors.New("api client not found in context")
 	}
 
 	resp, err := client.Generate(ctx, api.GenerateRequest{
 		TemplateID: c.Template,
 		Text:       c.Text,
-		Extension:  c.effectiveFormat(cfg),
-		Font:       c.effectiveFont(cfg),
-		Layout:     c.effectiveLayout(cfg),
+		Extension:  opts.Format,
+		Font:       opts.Font,
+		Layout:     opts.Layout,
 		Style:      c.Style,
 		Redirect:   false,
 	})
 	if err != nil {
-		return fmt.Errorf("generating meme: %w", err)
+		err = fmt.Errorf("generating meme: %w", err)
+
+		// A 404 is the template's unless the font is the unknown ID.
+		if opts.Font != "" && isNotFound(err) && !knownFont(ctx, client, opts.Font) {
+			return nil, withFontSuggestions(ctx, err, opts.Font)
+		}
+
+		return nil, withTemplateSuggestions(ctx, err, c.Template, false)
 	}
 
-	return c.outputURL(ctx, resp.URL, cfg, root)
+	return &generateResult{URL: resp.URL}, nil
 }
 
 // runCustom calls POST /images/custom for custom-background meme generation.
-func (c *GenerateCmd) runCustom(ctx context.Context, cfg *config.Config, root *RootFlags) error {
+// A local background is first published through the configured uploader,
+// since Memegen can only fetch backgrounds by URL.
+func (c *GenerateCmd) runCustom(ctx context.Context, cfg *config.Config) (*generateResult, error) {
+	opts := c.options(cfg)
+
 	if c.Background == "" {
-		return errors.New("--background required when using 'custom' template")
+		return nil, errors.New("--background required when using 'custom' template")
 	}
 
 	client := api.ClientFromContext(ctx)
 	if client == nil {
-		return errors.New("api client not found in context")
+		return nil, errors.New("api client not found in context")
+	}
+
+	background := c.Background
+	if upload.IsLocal(background) {
+		if cfg.Uploader == "" {
+			return nil, fmt.Errorf("--background %s is a local file: use --render local, or set an uploader with 'memelink config set uploader'", background)
+		}
+
+		var err error
+
+		background, err = upload.Upload(ctx, cfg.Uploader, upload.LocalPath(background))
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// CustomRequest.Style is a single string; join repeatable flag values.
 	style := strings.Join(c.Style, ",")
 
 	resp, err := client.GenerateCustom(ctx, api.CustomRequest{
-		Background: c.Background,
+		Background: background,
 		Text:       c.Text,
-		Extension:  c.effectiveFormat(cfg),
-		Font:       c.effectiveFont(cfg),
-		Layout:     c.effectiveLayout(cfg),
+		Extension:  opts.Format,
+		Font:       opts.Font,
+		Layout:     opts.Layout,
 		Style:      style,
 		Redirect:   false,
 	})
 	if err != nil {
-		return fmt.Errorf("generating meme: %w", err)
+		return nil, fmt.Errorf("generating meme: %w", err)
 	}
 
-	return c.outputURL(ctx, resp.URL, cfg, root)
+	return &generateResult{URL: resp.URL}, nil
 }
 
-// outputURL appends query params, prints the meme URL, and fires actions.
-func (c *GenerateCmd) outputURL(ctx context.Context, rawURL string, cfg *config.Config, root *RootFlags) error {
-	memeURL, err := api.AppendQueryParams(rawURL, c.queryParams(cfg))
-	if err != nil {
-		return fmt.Errorf("appending query params: %w", err)
+// runOffline builds the meme URL locally with api.Client.BuildURL.
+// Auto-generate needs the API to pick a template, so it is rejected here.
+func (c *GenerateCmd) runOffline(ctx context.Context, cfg *config.Config) (*generateResult, error) {
+	opts := c.options(cfg)
+
+	if len(c.Text) == 0 {
+		return nil, errors.New("--offline requires a template ID and text lines; auto-generate needs the API")
+	}
+
+	if c.Template == "custom" && c.Background == "" {
+		return nil, errors.New("--background required when using 'custom' template")
 	}
 
+	if c.Template == "custom" && upload.IsLocal(c.Background) {
+		return nil, fmt.Errorf("--offline needs a background URL, not the local file %s; use --render local", c.Background)
+	}
+
+	client := api.ClientFromContext(ctx)
+	if client == nil {
+		return nil, errors.New("api client not found in context")
+	}
+
+	memeURL := client.BuildURL(api.GenerateRequest{
+		TemplateID: c.Template,
+		Text:       c.Text,
+		Extension:  opts.Format,
+		Font:       opts.Font,
+		Layout:     opts.Layout,
+		Style:      c.Style,
+	})
+
+	if c.Template == "custom" {
+		var err error
+
+		memeURL, err = api.AppendQueryParams(memeURL, url.Values{"background": {c.Background}})
+		if err != nil {
+			return nil, fmt.Errorf("appending background: %w", err)
+		}
+	}
+
+	return &generateResult{URL: memeURL}, nil
+}
+
+// output previews and prints the meme URL, then fires actions.
+func (c *GenerateCmd) output(ctx context.Context, res *generateResult, cfg *config.Config, root *RootFlags) error {
+	if res.Path != "" {
+		return c.outputLocal(ctx, res, cfg, root)
+	}
+
+	images := imageCache(ctx)
+
 	if shouldPreview(c.Preview, cfg, root) {
-		_ = preview.Show(ctx, memeURL, preview.Options{
+		_ = preview.Show(ctx, res.URL, preview.Options{
 			Writer: os.Stderr,
+			Images: images,
 		})
 	}
 
 	if outfmt.IsJSON(ctx) {
-		if err := outfmt.WriteJSON(os.Stdout, map[string]any{
-			"url": memeURL,
-		}); err != nil {
+		out := map[string]any{
+			"url": res.URL,
+		}
+
+		// Template and custom modes have no generator/confidence.
+		if res.Automatic {
+			out["generator"] = res.Generator
+			out["confidence"] = res.Confidence
+		}
+
+		if err := outfmt.WriteJSON(os.Stdout, out); err != nil {
 			return err
 		}
 
-		c.runActions(memeURL, cfg)
+		c.runActions(ctx, images, res.URL, cfg)
 
 		return nil
 	}
 
-	fmt.Fprintln(os.Stdout, memeURL)
-	c.runActions(memeURL, cfg)
+	fmt.Fprintln(os.Stdout, res.URL)
+	c.runActions(ctx, images, res.URL, cfg)
 
 	return nil
 }
@@ -377,7 +508,7 @@ func (c *GenerateCmd) queryParams(cfg *config.Config) url.Values {
 		v.Set("scale", c.Scale)
 	}
 
-	if c.effectiveSafe(cfg) {
+	if c.options(cfg).Safe {
 		v.Set("safe", "true")
 	}
 
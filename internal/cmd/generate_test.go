@@ -8,6 +8,8 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,9 +20,39 @@ import (
 	"github.com/dedene/memelink-cli/internal/outfmt"
 )
 
+// sharedCacheDir is the package-wide XDG_CACHE_HOME set by TestMain.
+var sharedCacheDir string
+
+// TestMain points the cache and config at a throwaway directory so commands
+// that read or revalidate caches, or record history, never touch the real
+// user files. Tests that seed either still override XDG_CACHE_HOME or
+// XDG_CONFIG_HOME with t.Setenv.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "memelink-cmd-test-")
+	if err != nil {
+		panic(err)
+	}
+
+	sharedCacheDir = dir
+	_ = os.Setenv("XDG_CACHE_HOME", dir)
+	_ = os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+
+	code := m.Run()
+
+	_ = os.RemoveAll(dir)
+
+	os.Exit(code)
+}
+
 func testCtx(t *testing.T, baseURL string, jsonMode bool) context.Context {
 	t.Helper()
 
+	// Give each test its own cache unless it already chose one, so cached
+	// entries never leak between test servers.
+	if os.Getenv("XDG_CACHE_HOME") == sharedCacheDir {
+		t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	}
+
 	client := api.NewClient(api.ClientOptions{
 		BaseURL:   baseURL,
 		UserAgent: "memelink-cli/test",
@@ -83,6 +115,27 @@ func captureStdout(t *testing.T, fn func()) string {
 	return string(buf)
 }
 
+// captureStderr runs fn while capturing os.Stderr and returns the output.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	origStderr := os.Stderr
+	os.Stderr = w
+
+	fn()
+
+	_ = w.Close()
+	os.Stderr = origStderr
+
+	buf, _ := io.ReadAll(r)
+	_ = r.Close()
+
+	return string(buf)
+}
+
 // --- Auto-generate mode tests (from Plan 01) ---
 
 func TestGenerateCmd_AutoGenerate(t *testing.T) {
@@ -165,8 +218,10 @@ func TestGenerateCmd_TemplateMode(t *testing.T) {
 	cmd := &GenerateCmd{
 		Template: "drake",
 		Text:     []string{"top", "bottom"},
-		Format:   "jpg",
-		Layout:   "default",
+		GenerateFlags: GenerateFlags{
+			Format: "jpg",
+			Layout: "default",
+		},
 	}
 
 	var runErr error
@@ -191,10 +246,12 @@ func TestGenerateCmd_TemplateMode_WithFlags(t *testing.T) {
 	cmd := &GenerateCmd{
 		Template: "drake",
 		Text:     []string{"a", "b"},
-		Format:   "png",
-		Font:     "impact",
-		Layout:   "top",
-		Style:    []string{"default"},
+		GenerateFlags: GenerateFlags{
+			Format: "png",
+			Font:   "impact",
+			Layout: "top",
+			Style:  []string{"default"},
+		},
 	}
 
 	var runErr error
@@ -222,13 +279,15 @@ func TestGenerateCmd_TemplateMode_QueryParams(t *testing.T) {
 
 	ctx := testCtx(t, srv.URL, false)
 	cmd := &GenerateCmd{
-		Template:  "drake",
-		Text:      []string{"a", "b"},
-		Format:    "jpg",
-		Layout:    "default",
-		Width:     400,
-		Height:    300,
-		TextColor: []string{"red", "blue"},
+		Template: "drake",
+		Text:     []string{"a", "b"},
+		GenerateFlags: GenerateFlags{
+			Format:    "jpg",
+			Layout:    "default",
+			Width:     400,
+			Height:    300,
+			TextColor: []string{"red", "blue"},
+		},
 	}
 
 	var runErr error
@@ -254,8 +313,10 @@ func TestGenerateCmd_TemplateMode_JSON(t *testing.T) {
 	cmd := &GenerateCmd{
 		Template: "drake",
 		Text:     []string{"a", "b"},
-		Format:   "jpg",
-		Layout:   "default",
+		GenerateFlags: GenerateFlags{
+			Format: "jpg",
+			Layout: "default",
+		},
 	}
 
 	var runErr error
@@ -284,11 +345,13 @@ func TestGenerateCmd_CustomMode(t *testing.T) {
 
 	ctx := testCtx(t, srv.URL, false)
 	cmd := &GenerateCmd{
-		Template:   "custom",
-		Text:       []string{"hello"},
-		Background: "https://example.com/img.jpg",
-		Format:     "jpg",
-		Layout:     "default",
+		Template: "custom",
+		Text:     []string{"hello"},
+		GenerateFlags: GenerateFlags{
+			Background: "https://example.com/img.jpg",
+			Format:     "jpg",
+			Layout:     "default",
+		},
 	}
 
 	var runErr error
@@ -303,8 +366,10 @@ func TestGenerateCmd_CustomMode_NoBackground(t *testing.T) {
 	cmd := &GenerateCmd{
 		Template: "custom",
 		Text:     []string{"hello"},
-		Format:   "jpg",
-		Layout:   "default",
+		GenerateFlags: GenerateFlags{
+			Format: "jpg",
+			Layout: "default",
+		},
 	}
 	ctx := testCtx(t, "http://unused", false)
 	err := cmd.Run(ctx, &RootFlags{})
@@ -312,6 +377,53 @@ func TestGenerateCmd_CustomMode_NoBackground(t *testing.T) {
 	assert.Contains(t, err.Error(), "--background required")
 }
 
+func TestGenerateCmd_CustomMode_LocalBackground(t *testing.T) {
+	var gotBackground string
+
+	var uploads atomic.Int32
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/uploads/shot.png":
+			uploads.Add(1)
+			w.Header().Set("Location", srv.URL+"/public/shot.png")
+			w.WriteHeader(http.StatusCreated)
+		case "/images/custom":
+			var req map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			gotBackground, _ = req["background"].(string)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"url":"https://api.memegen.link/images/custom/hello.jpg"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	bg := filepath.Join(t.TempDir(), "shot.png")
+	require.NoError(t, os.WriteFile(bg, blankPNG(t, 8, 8), 0o644))
+
+	cmd := &GenerateCmd{Template: "custom", Text: []string{"hello"}, GenerateFlags: GenerateFlags{Background: bg}}
+
+	// Without an uploader the local file cannot reach the API.
+	err := cmd.Run(testCtxWithConfig(t, srv.URL), &RootFlags{})
+	require.ErrorContains(t, err, "--render local")
+	assert.Zero(t, uploads.Load())
+
+	ctx := testCtxWithCfg(t, srv.URL, &config.Config{Uploader: srv.URL + "/uploads/{name}"})
+	output := captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, &RootFlags{})) })
+
+	assert.Equal(t, int32(1), uploads.Load())
+	assert.Equal(t, srv.URL+"/public/shot.png", gotBackground)
+	assert.Contains(t, output, "https://api.memegen.link/images/custom/hello.jpg")
+
+	cmd.Offline = true
+	require.ErrorContains(t, cmd.Run(ctx, &RootFlags{}), "--offline needs a background URL")
+}
+
 func TestGenerateCmd_CustomMode_StyleJoined(t *testing.T) {
 	var gotBody []byte
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -324,12 +436,14 @@ func TestGenerateCmd_CustomMode_StyleJoined(t *testing.T) {
 
 	ctx := testCtx(t, srv.URL, false)
 	cmd := &GenerateCmd{
-		Template:   "custom",
-		Text:       []string{"hello"},
-		Background: "https://example.com/img.jpg",
-		Style:      []string{"default", "animated"},
-		Format:     "jpg",
-		Layout:     "default",
+		Template: "custom",
+		Text:     []string{"hello"},
+		GenerateFlags: GenerateFlags{
+			Background: "https://example.com/img.jpg",
+			Style:      []string{"default", "animated"},
+			Format:     "jpg",
+			Layout:     "default",
+		},
 	}
 
 	var runErr error
@@ -355,9 +469,12 @@ func TestGenerateCmd_SafeFlag_AutoMode(t *testing.T) {
 	defer srv.Close()
 
 	ctx := testCtx(t, srv.URL, false)
+	safe := true
 	cmd := &GenerateCmd{
 		Template: "test text",
-		Safe:     true,
+		GenerateFlags: GenerateFlags{
+			Safe: &safe,
+		},
 	}
 
 	var runErr error
@@ -379,12 +496,15 @@ func TestGenerateCmd_SafeFlag_TemplateMode(t *testing.T) {
 	defer srv.Close()
 
 	ctx := testCtx(t, srv.URL, false)
+	safe := true
 	cmd := &GenerateCmd{
 		Template: "drake",
 		Text:     []string{"a", "b"},
-		Safe:     true,
-		Format:   "jpg",
-		Layout:   "default",
+		GenerateFlags: GenerateFlags{
+			Safe:   &safe,
+			Format: "jpg",
+			Layout: "default",
+		},
 	}
 
 	var runErr error
@@ -440,7 +560,9 @@ func TestGenerateCmd_FlagOverridesConfig(t *testing.T) {
 	cmd := &GenerateCmd{
 		Template: "drake",
 		Text:     []string{"a", "b"},
-		Format:   "gif",
+		GenerateFlags: GenerateFlags{
+			Format: "gif",
+		},
 	}
 
 	var runErr error
@@ -556,3 +678,157 @@ func TestGenerateCmd_DefaultsWithoutConfig(t *testing.T) {
 	assert.Equal(t, "jpg", parsed["extension"], "hardcoded default format is jpg")
 	assert.Equal(t, "default", parsed["layout"], "hardcoded default layout is default")
 }
+
+// --- Offline mode tests ---
+
+func TestGenerateCmd_Offline_NoRequest(t *testing.T) {
+	requestCount := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	ctx := testCtx(t, srv.URL, false)
+	cmd := &GenerateCmd{
+		Template: "drake",
+		Text:     []string{"writing memes", "using memelink?"},
+		GenerateFlags: GenerateFlags{
+			Format:    "png",
+			Font:      "impact",
+			TextColor: []string{"white"},
+			Offline:   true,
+		},
+	}
+
+	var runErr error
+	output := captureStdout(t, func() { runErr = cmd.Run(ctx, &RootFlags{}) })
+	require.NoError(t, runErr)
+
+	assert.Equal(t, 0, requestCount, "offline mode must not call the API")
+
+	u, err := url.Parse(output[:len(output)-1])
+	require.NoError(t, err)
+	assert.Equal(t, "/images/drake/writing_memes/using_memelink~q.png", u.Path)
+	assert.Equal(t, "impact", u.Query().Get("font"))
+	assert.Equal(t, "white", u.Query().Get("color"))
+}
+
+func TestGenerateCmd_Offline_Custom(t *testing.T) {
+	ctx := testCtx(t, "https://api.memegen.link", false)
+	cmd := &GenerateCmd{
+		Template: "custom",
+		Text:     []string{"hello"},
+		GenerateFlags: GenerateFlags{
+			Background: "https://example.com/img.jpg",
+			Offline:    true,
+		},
+	}
+
+	var runErr error
+	output := captureStdout(t, func() { runErr = cmd.Run(ctx, &RootFlags{}) })
+	require.NoError(t, runErr)
+
+	u, err := url.Parse(output[:len(output)-1])
+	require.NoError(t, err)
+	assert.Equal(t, "/images/custom/hello.jpg", u.Path)
+	assert.Equal(t, "https://example.com/img.jpg", u.Query().Get("background"))
+}
+
+func TestGenerateCmd_Offline_RejectsAutomatic(t *testing.T) {
+	ctx := testCtx(t, "http://unused", false)
+	cmd := &GenerateCmd{Template: "one does not simply", GenerateFlags: GenerateFlags{Offline: true}}
+
+	err := cmd.Run(ctx, &RootFlags{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--offline requires a template ID")
+}
+
+// --- Preset tests ---
+
+func TestGenerateCmd_Preset(t *testing.T) {
+	var gotBody []byte
+	srv := bodyCapturingServer(t, &gotBody)
+	defer srv.Close()
+
+	tr := true
+	cfg := &config.Config{
+		DefaultFont: "arial",
+		Presets: map[string]config.Preset{
+			"standup": {Template: "fine", Font: "impact", Format: "png", TextColor: []string{"white"}, Safe: &tr},
+		},
+	}
+
+	cmd := &GenerateCmd{Template: "standup", Text: []string{"line1"}, GenerateFlags: GenerateFlags{Format: "webp"}}
+
+	var runErr error
+	output := captureStdout(t, func() { runErr = cmd.Run(testCtxWithCfg(t, srv.URL, cfg), &RootFlags{Force: true}) })
+	require.NoError(t, runErr)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(gotBody, &body))
+	assert.Equal(t, "fine", body["template_id"])
+	assert.Equal(t, "impact", body["font"], "preset beats config default")
+	assert.Equal(t, "webp", body["extension"], "flag beats preset")
+
+	u, err := url.Parse(output[:len(output)-1])
+	require.NoError(t, err)
+	assert.Equal(t, "white", u.Query().Get("color"))
+	assert.Equal(t, "true", u.Query().Get("safe"))
+}
+
+func TestGenerateCmd_PresetSafeOverridesConfig(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"url":"https://api.memegen.link/images/fine/a.jpg"}`))
+	}))
+	defer srv.Close()
+
+	tr, fa := true, false
+	cfg := &config.Config{
+		Safe:    &tr,
+		Presets: map[string]config.Preset{"work": {Template: "fine", Safe: &fa}},
+	}
+
+	run := func(flags GenerateFlags) string {
+		cmd := &GenerateCmd{Template: "work", Text: []string{"a"}, GenerateFlags: flags}
+
+		var runErr error
+		output := captureStdout(t, func() { runErr = cmd.Run(testCtxWithCfg(t, srv.URL, cfg), &RootFlags{Force: true}) })
+		require.NoError(t, runErr)
+
+		u, err := url.Parse(output[:len(output)-1])
+		require.NoError(t, err)
+
+		return u.Query().Get("safe")
+	}
+
+	assert.Empty(t, run(GenerateFlags{}), "preset safe=false beats config safe=true")
+	assert.Equal(t, "true", run(GenerateFlags{Safe: &tr}), "--safe beats the preset")
+}
+
+func TestGenerateCmd_PresetNeedsText(t *testing.T) {
+	cfg := &config.Config{Presets: map[string]config.Preset{"standup": {Template: "fine"}}}
+
+	err := (&GenerateCmd{Template: "standup"}).Run(testCtxWithCfg(t, "http://unused", cfg), &RootFlags{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `preset "standup" needs text lines`)
+}
+
+func TestGenerateCmd_PresetWithoutTemplate(t *testing.T) {
+	cfg := &config.Config{Presets: map[string]config.Preset{"standup": {Font: "impact"}}}
+
+	err := (&GenerateCmd{Template: "standup", Text: []string{"a"}}).Run(testCtxWithCfg(t, "http://unused", cfg), &RootFlags{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "set presets.standup.template")
+}
+
+func TestAutoOutputPath(t *testing.T) {
+	assert.Equal(t, "b.jpg", autoOutputPath("https://api.memegen.link/images/drake/a/b.jpg", nil))
+
+	dir := filepath.Join(t.TempDir(), "memes")
+	got := autoOutputPath("https://api.memegen.link/images/drake/a/b.jpg", &config.Config{OutputDir: dir})
+	assert.Equal(t, filepath.Join(dir, "b.jpg"), got)
+	assert.DirExists(t, dir)
+}
@@ -0,0 +1,372 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dedene/memelink-cli/internal/config"
+	"github.com/dedene/memelink-cli/internal/history"
+	"github.com/dedene/memelink-cli/internal/outfmt"
+	"github.com/dedene/memelink-cli/internal/ui"
+	"github.com/dedene/memelink-cli/internal/upload"
+)
+
+// HistoryCmd groups meme history subcommands.
+type HistoryCmd struct {
+	List   HistoryListCmd   `cmd:"" default:"1" help:"List recently generated memes"`
+	Search HistorySearchCmd `cmd:"" help:"Find memes by template, text or URL"`
+	Show   HistoryShowCmd   `cmd:"" help:"Show one history entry"`
+	Rerun  HistoryRerunCmd  `cmd:"" help:"Generate a history entry again"`
+	Clear  HistoryClearCmd  `cmd:"" help:"Delete the meme history"`
+}
+
+// recordHistory appends e to the history log, unless history_retention is
+// 0. History is a convenience, so failures only warn.
+func recordHistory(ctx context.Context, e history.Entry) {
+	cfg := config.FromContext(ctx)
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	retention := cfg.HistoryRetentionDuration()
+	if retention <= 0 {
+		return
+	}
+
+	path, err := config.HistoryPath()
+	if err == nil {
+		_, err = history.Append(ctx, path, e, retention)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: history: %v\n", err)
+	}
+}
+
+// historyEntry describes a finished generation. Options are recorded as
+// resolved, and local paths made absolute, so a rerun reproduces the meme
+// whatever the config or working directory is by then.
+func (c *GenerateCmd) historyEntry(cfg *config.Config, res *generateResult) history.Entry {
+	opts := c.options(cfg)
+
+	e := history.Entry{
+		Mode:     history.ModeTemplate,
+		Template: c.Template,
+		Text:     c.Text,
+		URL:      res.URL,
+		Path:     absPath(res.Path),
+		Flags: history.Flags{
+			Format:     opts.Format,
+			Font:       opts.Font,
+			Layout:     opts.Layout,
+			TextColor:  c.TextColor,
+			Style:      c.Style,
+			Width:      c.Width,
+			Height:     c.Height,
+			Center:     c.Center,
+			Scale:      c.Scale,
+			Safe:       opts.Safe,
+			Background: c.Background,
+			Offline:    c.Offline,
+			FromFrame:  c.FromFrame,
+			ToFrame:    c.ToFrame,
+		},
+	}
+
+	if c.Render == renderLocal {
+		e.Flags.Render = renderLocal
+	}
+
+	if upload.IsLocal(c.Background) {
+		e.Flags.Background = absPath(upload.LocalPath(c.Background))
+	}
+
+	switch {
+	case len(c.Text) == 0:
+		e.Mode, e.Template, e.Text = history.ModeAutomatic, "", []string{c.Template}
+		e.Generator, e.Confidence = res.Generator, res.Confidence
+	case c.Template == "custom":
+		e.Mode = history.ModeCustom
+	}
+
+	return e
+}
+
+// historyGenerateCmd rebuilds the GenerateCmd of a history entry.
+func historyGenerateCmd(e history.Entry) *GenerateCmd {
+	f := e.Flags
+
+	// Options were recorded as resolved, so safe is explicit either way.
+	safe := f.Safe
+
+	gen := &GenerateCmd{
+		Template: e.Template,
+		Text:     e.Text,
+		GenerateFlags: GenerateFlags{
+			Format:     f.Format,
+			Font:       f.Font,
+			TextColor:  f.TextColor,
+			Layout:     f.Layout,
+			Style:      f.Style,
+			Width:      f.Width,
+			Height:     f.Height,
+			Center:     f.Center,
+			Scale:      f.Scale,
+			Safe:       &safe,
+			Background: f.Background,
+			Offline:    f.Offline,
+			Render:     f.Render,
+			FromFrame:  f.FromFrame,
+			ToFrame:    f.ToFrame,
+		},
+	}
+
+	if e.Mode == history.ModeAutomatic {
+		gen.Template, gen.Text = strings.Join(e.Text, " "), nil
+	}
+
+	return gen
+}
+
+// absPath makes a local path absolute, keeping "" and unresolvable paths
+// as they are.
+func absPath(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return abs
+}
+
+// loadHistory reads the history log.
+func loadHistory() ([]history.Entry, error) {
+	path, err := config.HistoryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return history.Load(path)
+}
+
+// newestFirst reverses entries and keeps at most limit of them (0 = all).
+func newestFirst(entries []history.Entry, limit int) []history.Entry {
+	entries = slices.Clone(entries)
+	slices.Reverse(entries)
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries
+}
+
+// printHistory writes entries as JSON or a table.
+func printHistory(ctx context.Context, entries []history.Entry) error {
+	if outfmt.IsJSON(ctx) {
+		if entries == nil {
+			entries = []history.Entry{}
+		}
+
+		return outfmt.WriteJSON(os.Stdout, entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "No memes in history")
+
+		return nil
+	}
+
+	rows := make([][]string, 0, len(entries))
+	for _, e := range entries {
+		result := e.URL
+		if e.Path != "" {
+			result = e.Path
+		}
+
+		template := e.Template
+		if e.Mode == history.ModeAutomatic {
+			template = "(auto)"
+		}
+
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", e.ID),
+			e.Time.Local().Format("2006-01-02 15:04"),
+			template,
+			strings.Join(e.Text, " / "),
+			result,
+		})
+	}
+
+	colorEnabled := false
+	if u := ui.FromContext(ctx); u != nil {
+		colorEnabled = u.Out().ColorEnabled()
+	}
+
+	fmt.Fprint(os.Stdout, ui.RenderTable(
+		[]string{"ID", "Time", "Template", "Text", "Meme"},
+		rows,
+		colorEnabled,
+	))
+
+	return nil
+}
+
+// HistoryListCmd lists recent memes.
+type HistoryListCmd struct {
+	Limit int `help:"Show at most N entries (0 for all)" short:"n" default:"20"`
+}
+
+// Run prints the newest entries first.
+func (c *HistoryListCmd) Run(ctx context.Context) error {
+	entries, err := loadHistory()
+	if err != nil {
+		return err
+	}
+
+	return printHistory(ctx, newestFirst(entries, c.Limit))
+}
+
+// HistorySearchCmd finds memes in the history.
+type HistorySearchCmd struct {
+	Query string `arg:"" help:"Text to find in templates, text lines and URLs (case-insensitive)"`
+	Limit int    `help:"Show at most N entries (0 for all)" short:"n" default:"20"`
+}
+
+// Run prints matching entries, newest first.
+func (c *HistorySearchCmd) Run(ctx context.Context) error {
+	entries, err := loadHistory()
+	if err != nil {
+		return err
+	}
+
+	return printHistory(ctx, newestFirst(history.Search(entries, c.Query), c.Limit))
+}
+
+// HistoryShowCmd prints one entry.
+type HistoryShowCmd struct {
+	ID int `arg:"" help:"History entry ID (see 'memelink history list')"`
+}
+
+// Run prints the entry's details.
+func (c *HistoryShowCmd) Run(ctx context.Context) error {
+	entries, err := loadHistory()
+	if err != nil {
+		return err
+	}
+
+	e, err := history.Find(entries, c.ID)
+	if err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, e)
+	}
+
+	fmt.Fprintf(os.Stdout, "ID:         %d\n", e.ID)
+	fmt.Fprintf(os.Stdout, "Time:       %s\n", e.Time.Local().Format(time.RFC3339))
+	fmt.Fprintf(os.Stdout, "Mode:       %s\n", e.Mode)
+
+	if e.Template != "" {
+		fmt.Fprintf(os.Stdout, "Template:   %s\n", e.Template)
+	}
+
+	for i, line := range e.Text {
+		fmt.Fprintf(os.Stdout, "Line %d:     %s\n", i+1, line)
+	}
+
+	f := e.Flags
+	for _, field := range [][2]string{
+		{"Format", f.Format},
+		{"Font", f.Font},
+		{"Layout", f.Layout},
+		{"Color", strings.Join(f.TextColor, ", ")},
+		{"Style", strings.Join(f.Style, ", ")},
+		{"Background", f.Background},
+		{"Render", f.Render},
+		{"Generator", e.Generator},
+		{"URL", e.URL},
+		{"Path", e.Path},
+	} {
+		if field[1] != "" {
+			fmt.Fprintf(os.Stdout, "%-11s %s\n", field[0]+":", field[1])
+		}
+	}
+
+	if e.Confidence > 0 {
+		fmt.Fprintf(os.Stdout, "Confidence: %.2f\n", e.Confidence)
+	}
+
+	return nil
+}
+
+// HistoryRerunCmd generates a history entry again.
+type HistoryRerunCmd struct {
+	ID int `arg:"" help:"History entry ID (see 'memelink history list')"`
+
+	// Output action flags, as on generate.
+	Copy       bool   `help:"Copy URL to clipboard" name:"copy" short:"c"`
+	Open       bool   `help:"Open URL in browser" name:"open" short:"o"`
+	Output     string `help:"Download image to file path" name:"output"`
+	AutoOutput bool   `help:"Download image to output_dir (or CWD) with auto-generated name" short:"O"`
+
+	// Preview flag.
+	Preview *bool `help:"Show inline image preview" name:"preview" negatable:""`
+}
+
+// Run runs generate with the entry's template, text and options. The new
+// meme is recorded as a new entry.
+func (c *HistoryRerunCmd) Run(ctx context.Context, root *RootFlags) error {
+	entries, err := loadHistory()
+	if err != nil {
+		return err
+	}
+
+	e, err := history.Find(entries, c.ID)
+	if err != nil {
+		return err
+	}
+
+	gen := historyGenerateCmd(e)
+	gen.Copy = c.Copy
+	gen.Open = c.Open
+	gen.Output = c.Output
+	gen.AutoOutput = c.AutoOutput
+	gen.Preview = c.Preview
+
+	return gen.Run(ctx, root)
+}
+
+// HistoryClearCmd deletes the history.
+type HistoryClearCmd struct{}
+
+// Run removes the history log.
+func (c *HistoryClearCmd) Run(ctx context.Context) error {
+	path, err := config.HistoryPath()
+	if err != nil {
+		return err
+	}
+
+	n, err := history.Clear(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	if outfmt.IsJSON(ctx) {
+		return outfmt.WriteJSON(os.Stdout, map[string]any{"cleared": n})
+	}
+
+	fmt.Fprintf(os.Stderr, "Cleared %d history entries\n", n)
+
+	return nil
+}
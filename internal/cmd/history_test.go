@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/config"
+	"github.com/dedene/memelink-cli/internal/history"
+	"github.com/dedene/memelink-cli/internal/outfmt"
+)
+
+// historyServer answers template and automatic generation, counting calls.
+func historyServer(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+
+		switch r.URL.Path {
+		case "/images/automatic":
+			_, _ = w.Write([]byte(`{"url":"https://api.memegen.link/images/fry/not_sure.jpg","generator":"Pattern","confidence":0.75}`))
+		default:
+			_, _ = w.Write([]byte(`{"url":"https://api.memegen.link/images/drake/tabs/spaces.png"}`))
+		}
+	}))
+}
+
+func TestHistory_RecordListSearchShow(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var calls atomic.Int32
+
+	srv := historyServer(t, &calls)
+	defer srv.Close()
+
+	ctx := testCtxWithConfig(t, srv.URL)
+	root := &RootFlags{Force: true}
+
+	captureStdout(t, func() {
+		require.NoError(t, (&GenerateCmd{Template: "drake", Text: []string{"tabs", "spaces"}, GenerateFlags: GenerateFlags{Format: "png", Width: 300}}).Run(ctx, root))
+		require.NoError(t, (&GenerateCmd{Template: "not sure if tabs"}).Run(ctx, root))
+	})
+
+	jsonCtx := outfmt.WithMode(ctx, outfmt.Mode{JSON: true})
+
+	var listed []history.Entry
+
+	out := captureStdout(t, func() { require.NoError(t, (&HistoryListCmd{Limit: 20}).Run(jsonCtx)) })
+	require.NoError(t, json.Unmarshal([]byte(out), &listed))
+	require.Len(t, listed, 2)
+
+	auto, tmpl := listed[0], listed[1]
+	assert.Equal(t, 2, auto.ID, "newest first")
+	assert.Equal(t, history.ModeAutomatic, auto.Mode)
+	assert.Equal(t, []string{"not sure if tabs"}, auto.Text)
+	assert.Equal(t, "Pattern", auto.Generator)
+	assert.InDelta(t, 0.75, auto.Confidence, 0.001)
+
+	assert.Equal(t, history.ModeTemplate, tmpl.Mode)
+	assert.Equal(t, "drake", tmpl.Template)
+	assert.Equal(t, "png", tmpl.Flags.Format)
+	assert.Equal(t, 300, tmpl.Flags.Width)
+	assert.Equal(t, "https://api.memegen.link/images/drake/tabs/spaces.png?width=300", tmpl.URL)
+
+	out = captureStdout(t, func() { require.NoError(t, (&HistoryListCmd{Limit: 1}).Run(jsonCtx)) })
+	require.NoError(t, json.Unmarshal([]byte(out), &listed))
+	assert.Len(t, listed, 1)
+
+	out = captureStdout(t, func() { require.NoError(t, (&HistorySearchCmd{Query: "SPACES"}).Run(jsonCtx)) })
+	require.NoError(t, json.Unmarshal([]byte(out), &listed))
+	require.Len(t, listed, 1)
+	assert.Equal(t, 1, listed[0].ID)
+
+	out = captureStdout(t, func() { require.NoError(t, (&HistorySearchCmd{Query: "nothing"}).Run(jsonCtx)) })
+	assert.JSONEq(t, "[]", out)
+
+	out = captureStdout(t, func() { require.NoError(t, (&HistoryShowCmd{ID: 2}).Run(ctx)) })
+	assert.Contains(t, out, "Mode:       automatic")
+	assert.Contains(t, out, "Generator:  Pattern")
+	assert.Contains(t, out, "Confidence: 0.75")
+
+	out = captureStdout(t, func() { require.NoError(t, (&HistoryListCmd{Limit: 20}).Run(ctx)) })
+	assert.Contains(t, out, "tabs / spaces")
+	assert.Contains(t, out, "(auto)")
+
+	require.ErrorIs(t, (&HistoryShowCmd{ID: 9}).Run(ctx), history.ErrNotFound)
+}
+
+func TestHistory_Rerun(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var calls atomic.Int32
+
+	srv := historyServer(t, &calls)
+	defer srv.Close()
+
+	ctx := testCtxWithConfig(t, srv.URL)
+	root := &RootFlags{Force: true}
+
+	captureStdout(t, func() {
+		require.NoError(t, (&GenerateCmd{Template: "drake", Text: []string{"tabs", "spaces"}, GenerateFlags: GenerateFlags{Width: 300}}).Run(ctx, root))
+	})
+
+	before := calls.Load()
+	out := captureStdout(t, func() { require.NoError(t, (&HistoryRerunCmd{ID: 1}).Run(ctx, root)) })
+
+	assert.Equal(t, before+1, calls.Load(), "rerun calls the API again")
+	assert.Equal(t, "https://api.memegen.link/images/drake/tabs/spaces.png?width=300\n", out)
+
+	path, err := config.HistoryPath()
+	require.NoError(t, err)
+
+	entries, err := history.Load(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "the rerun is recorded too")
+	assert.Equal(t, entries[0].Flags, entries[1].Flags)
+}
+
+func TestHistory_AutomaticRoundTrip(t *testing.T) {
+	gen := &GenerateCmd{Template: "one does not simply"}
+	e := gen.historyEntry(&config.Config{}, &generateResult{URL: "u", Automatic: true})
+
+	back := historyGenerateCmd(e)
+	assert.Equal(t, "one does not simply", back.Template)
+	assert.Empty(t, back.Text)
+}
+
+func TestHistory_LocalPathsAbsolute(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	gen := &GenerateCmd{Template: "custom", Text: []string{"a"}, GenerateFlags: GenerateFlags{Background: "shot.png", Render: renderLocal}}
+	e := gen.historyEntry(&config.Config{}, &generateResult{Path: "meme.jpg"})
+
+	assert.Equal(t, history.ModeCustom, e.Mode)
+	assert.Equal(t, renderLocal, e.Flags.Render)
+	assert.Equal(t, filepath.Join(dir, "shot.png"), e.Flags.Background)
+	assert.Equal(t, filepath.Join(dir, "meme.jpg"), e.Path)
+}
+
+func TestHistory_RetentionOffAndClear(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var calls atomic.Int32
+
+	srv := historyServer(t, &calls)
+	defer srv.Close()
+
+	path, err := config.HistoryPath()
+	require.NoError(t, err)
+
+	off := testCtxWithCfg(t, srv.URL, &config.Config{HistoryRetention: "0"})
+	captureStdout(t, func() {
+		require.NoError(t, (&GenerateCmd{Template: "drake", Text: []string{"a"}}).Run(off, &RootFlags{Force: true}))
+	})
+	assert.NoFileExists(t, path, "history_retention 0 records nothing")
+
+	ctx := testCtxWithConfig(t, srv.URL)
+	captureStdout(t, func() {
+		require.NoError(t, (&GenerateCmd{Template: "drake", Text: []string{"a"}}).Run(ctx, &RootFlags{Force: true}))
+	})
+	assert.FileExists(t, path)
+
+	stderr := captureStderr(t, func() { require.NoError(t, (&HistoryClearCmd{}).Run(ctx)) })
+	assert.Equal(t, "Cleared 1 history entries\n", stderr)
+
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dedene/memelink-cli/internal/actions"
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/cache"
+	"github.com/dedene/memelink-cli/internal/config"
+)
+
+// imageCache returns the image cache shared by preview, downloads and the
+// TUI. Returns nil when image_cache_mb is 0 or there is no API client to
+// fetch with; callers then go straight to the network.
+func imageCache(ctx context.Context) *cache.Images {
+	client := api.ClientFromContext(ctx)
+	if client == nil {
+		return nil
+	}
+
+	cfg := config.FromContext(ctx)
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	maxBytes := cfg.ImageCacheBytes()
+	if maxBytes <= 0 {
+		return nil
+	}
+
+	dir, err := config.ImageCacheDir(apiBaseURL(ctx))
+	if err != nil {
+		slog.Debug("image cache unavailable", "error", err)
+
+		return nil
+	}
+
+	return cache.NewImages(dir, maxBytes, client.FetchImage)
+}
+
+// saveImage writes the image at rawURL to dest, through the image cache
+// when it is enabled.
+func saveImage(ctx context.Context, images *cache.Images, rawURL, dest string) error {
+	if images == nil {
+		return actions.DownloadFile(rawURL, dest)
+	}
+
+	src, err := images.Fetch(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+
+	return actions.CopyFile(src, dest)
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/config"
+)
+
+// imageServer answers POST /images with a meme URL on itself, template
+// lookups with drake, and serves the image, counting image downloads.
+func imageServer(t *testing.T, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"url":"` + srv.URL + `/images/drake/a/b.png"}`))
+
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/templates/") {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(templateDetailJSON))
+
+			return
+		}
+
+		hits.Add(1)
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("PNGDATA"))
+	}))
+
+	return srv
+}
+
+func TestGenerateCmd_DownloadsShareImageCache(t *testing.T) {
+	var hits atomic.Int32
+
+	srv := imageServer(t, &hits)
+	defer srv.Close()
+
+	outDir := t.TempDir()
+	ctx := testCtxWithCfg(t, srv.URL, &config.Config{OutputDir: outDir})
+
+	explicit := filepath.Join(t.TempDir(), "meme.png")
+	cmd := &GenerateCmd{Template: "drake", Text: []string{"a", "b"}, GenerateFlags: GenerateFlags{Output: explicit, AutoOutput: true}}
+
+	captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, &RootFlags{NoInput: true})) })
+
+	for _, p := range []string{explicit, filepath.Join(outDir, "b.png")} {
+		data, err := os.ReadFile(p)
+		require.NoError(t, err)
+		assert.Equal(t, "PNGDATA", string(data))
+	}
+
+	assert.Equal(t, int32(1), hits.Load(), "the image is downloaded once")
+
+	// A later run for the same meme is served from the cache.
+	captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, &RootFlags{NoInput: true})) })
+	assert.Equal(t, int32(1), hits.Load())
+}
+
+func TestImageCache_Disabled(t *testing.T) {
+	assert.Nil(t, imageCache(testCtxNoClient(t, false)), "no client to fetch with")
+
+	zero := 0
+	ctx := testCtxWithCfg(t, "http://unused", &config.Config{ImageCacheMB: &zero})
+	assert.Nil(t, imageCache(ctx), "image_cache_mb 0 disables the cache")
+
+	assert.NotNil(t, imageCache(testCtx(t, "http://unused", false)))
+}
+
+func TestSaveImage_WithoutCache(t *testing.T) {
+	var hits atomic.Int32
+
+	srv := imageServer(t, &hits)
+	defer srv.Close()
+
+	ctx := testCtx(t, srv.URL, false)
+	dest := filepath.Join(t.TempDir(), "out.png")
+
+	for range 2 {
+		require.NoError(t, saveImage(ctx, nil, srv.URL+"/images/x.png", dest))
+	}
+
+	assert.Equal(t, int32(2), hits.Load(), "without a cache every save downloads")
+}
@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dedene/memelink-cli/internal/actions"
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/cache"
+	"github.com/dedene/memelink-cli/internal/config"
+	"github.com/dedene/memelink-cli/internal/outfmt"
+	"github.com/dedene/memelink-cli/internal/preview"
+	"github.com/dedene/memelink-cli/internal/render"
+	"github.com/dedene/memelink-cli/internal/upload"
+)
+
+// renderLocal selects the local renderer (--render local).
+const renderLocal = "local"
+
+// runLocal draws the text onto the template's blank image (or the custom
+// background, a URL or local file) with internal/render and writes the
+// image to --output, or to the -O location named after the equivalent meme
+// URL. With --format gif the background must be a GIF, and every frame in
+// --from-frame..--to-frame is captioned. The template metadata and blank
+// come from the caches, so `cache warm` makes this work fully offline.
+// Unless force, the text is validated against the resolved template.
+func (c *GenerateCmd) runLocal(ctx context.Context, cfg *config.Config, force bool) (*generateResult, error) {
+	opts := c.options(cfg)
+
+	if len(c.Text) == 0 {
+		return nil, errors.New("--render local requires a template ID and text lines; auto-generate needs the API")
+	}
+
+	if opts.Format != "jpg" && opts.Format != "png" && opts.Format != "gif" {
+		return nil, fmt.Errorf("--render local supports jpg, png and gif, not %q", opts.Format)
+	}
+
+	if (c.FromFrame != 0 || c.ToFrame != 0) && opts.Format != "gif" {
+		return nil, errors.New("--from-frame and --to-frame need --format gif")
+	}
+
+	client := api.ClientFromContext(ctx)
+	if client == nil {
+		return nil, errors.New("api client not found in context")
+	}
+
+	if c.Font != "" {
+		fmt.Fprintf(os.Stderr, "warning: --render local uses its built-in font; ignoring --font %s\n", c.Font)
+	}
+
+	for _, s := range c.Style {
+		if s != "default" {
+			fmt.Fprintf(os.Stderr, "warning: --render local does not support styles; ignoring --style %s\n", s)
+		}
+	}
+
+	if !force {
+		if err := validateLineLengths(c.Text); err != nil {
+			return nil, err
+		}
+	}
+
+	bgURL := c.Background
+	if c.Template != "custom" {
+		tmpl, err := localTemplate(ctx, client, c.Template)
+		if err != nil {
+			return nil, err
+		}
+
+		if !force {
+			if err := validateAgainstTemplate(tmpl, c.Text, c.Style); err != nil {
+				return nil, err
+			}
+		}
+
+		bgURL = tmpl.Blank
+	} else if bgURL == "" {
+		return nil, errors.New("--background required when using 'custom' template")
+	}
+
+	var (
+		bg  io.ReadCloser
+		err error
+	)
+
+	if upload.IsLocal(bgURL) {
+		bg, err = openLocalBackground(upload.LocalPath(bgURL))
+	} else {
+		bg, err = openBackground(ctx, client, bgURL)
+		if err != nil {
+			err = fmt.Errorf("loading background for %s: %w (run 'memelink cache warm' to cache blanks)", c.Template, err)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer bg.Close()
+
+	ropts := render.Options{
+		Lines:     c.Text,
+		Colors:    c.TextColor,
+		Layout:    opts.Layout,
+		Width:     c.Width,
+		Height:    c.Height,
+		FromFrame: c.FromFrame,
+		ToFrame:   c.ToFrame,
+	}
+
+	var encode func(io.Writer) error
+
+	if opts.Format == "gif" {
+		encode, err = renderAnimation(bg, ropts)
+	} else {
+		encode, err = renderStill(bg, ropts, opts.Format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	dest := c.Output
+	if dest == "" {
+		dest = autoOutputPath(client.BuildURL(api.GenerateRequest{
+			TemplateID: c.Template,
+			Text:       c.Text,
+			Extension:  opts.Format,
+		}), cfg)
+	}
+
+	if err := writeRendered(dest, encode); err != nil {
+		return nil, err
+	}
+
+	return &generateResult{Path: dest}, nil
+}
+
+// localTemplate returns template metadata for local rendering from the
+// caches whatever their age: the template list, then the details cache.
+// Only a template cached in neither is fetched, since its blank is needed.
+func localTemplate(ctx context.Context, client *api.Client, id string) (*api.Template, error) {
+	if cached, _ := readTemplateCache(ctx); cached != nil {
+		for i := range cached.Data {
+			if cached.Data[i].ID == id {
+				return &cached.Data[i], nil
+			}
+		}
+	}
+
+	if store, err := cacheStore(ctx); err == nil {
+		if entry, _, _ := cache.Load[*api.Template](store, cache.KindDetails, id); entry != nil && entry.Data != nil {
+			return entry.Data, nil
+		}
+	}
+
+	tmpl, err := fetchTemplate(ctx, client, id, false)
+	if err != nil {
+		return nil, withTemplateSuggestions(ctx, fmt.Errorf("looking up template %q: %w", id, err), id, false)
+	}
+
+	return tmpl, nil
+}
+
+// openBackground opens the image at rawURL through the image cache, or
+// downloads it into memory when the cache is disabled.
+func openBackground(ctx context.Context, client *api.Client, rawURL string) (io.ReadCloser, error) {
+	if rawURL == "" {
+		return nil, errors.New("template has no blank image")
+	}
+
+	if images := imageCache(ctx); images != nil {
+		return images.Open(ctx, rawURL)
+	}
+
+	var buf bytes.Buffer
+	if err := client.FetchImage(ctx, rawURL, &buf); err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(&buf), nil
+}
+
+// openLocalBackground opens a local background file once it passes the
+// same type and size checks as an upload.
+func openLocalBackground(path string) (io.ReadCloser, error) {
+	if _, err := upload.Validate(path); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path) //nolint:gosec // user-provided background path
+	if err != nil {
+		return nil, fmt.Errorf("opening background: %w", err)
+	}
+
+	return f, nil
+}
+
+// renderStill draws the caption onto a still background and returns its
+// jpg or png encoder.
+func renderStill(bg io.Reader, ropts render.Options, format string) (func(io.Writer) error, error) {
+	src, err := render.Decode(bg)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := render.Render(src, ropts)
+	if err != nil {
+		return nil, fmt.Errorf("rendering meme: %w", err)
+	}
+
+	return func(w io.Writer) error { return render.Encode(w, img, format) }, nil
+}
+
+// renderAnimation captions the frames of a GIF background and returns its
+// encoder. Other backgrounds are rejected: there is no animation to keep.
+func renderAnimation(bg io.Reader, ropts render.Options) (func(io.Writer) error, error) {
+	br := bufio.NewReader(bg)
+	if head, _ := br.Peek(4); string(head) != "GIF8" {
+		return nil, errors.New("--format gif with --render local needs a GIF background")
+	}
+
+	src, err := render.DecodeGIF(br)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := render.RenderGIF(src, ropts)
+	if err != nil {
+		return nil, fmt.Errorf("rendering meme: %w", err)
+	}
+
+	return func(w io.Writer) error { return render.EncodeGIF(w, g) }, nil
+}
+
+// writeRendered writes dest with encode.
+func writeRendered(dest string, encode func(io.Writer) error) error {
+	f, err := os.Create(dest) //nolint:gosec // dest is the user-provided output path
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", dest, err)
+	}
+
+	if err := encode(f); err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", dest, err)
+	}
+
+	return nil
+}
+
+// outputLocal previews and prints the rendered file's path, then fires the
+// clipboard and browser actions with that path. The file is already at its
+// destination, so there is nothing to download.
+func (c *GenerateCmd) outputLocal(ctx context.Context, res *generateResult, cfg *config.Config, root *RootFlags) error {
+	if shouldPreview(c.Preview, cfg, root) {
+		_ = preview.ShowFile(res.Path, preview.Options{Writer: os.Stderr})
+	}
+
+	if outfmt.IsJSON(ctx) {
+		if err := outfmt.WriteJSON(os.Stdout, map[string]any{"path": res.Path}); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(os.Stdout, res.Path)
+	}
+
+	opts := c.options(cfg)
+
+	if opts.AutoCopy {
+		if err := actions.CopyToClipboard(res.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: clipboard: %v\n", err)
+		}
+	}
+
+	if opts.AutoOpen {
+		if err := actions.OpenInBrowser(res.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: browser: %v\n", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/cache"
+	"github.com/dedene/memelink-cli/internal/config"
+	"github.com/dedene/memelink-cli/internal/render"
+	"github.com/dedene/memelink-cli/internal/upload"
+)
+
+// blankPNG encodes a plain w x h PNG.
+func blankPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+
+	return buf.Bytes()
+}
+
+// blankServer serves drake's details, with its blank on the server, and
+// the blank image itself, counting blank downloads.
+func blankServer(t *testing.T, blankHits *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	blank := blankPNG(t, 120, 80)
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/templates/drake":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"drake","name":"Drake","lines":2,"blank":"` + srv.URL + `/images/drake.png"}`))
+		case "/images/drake.png":
+			blankHits.Add(1)
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write(blank)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return srv
+}
+
+// decodeFile decodes the image at path.
+func decodeFile(t *testing.T, path string) image.Image {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	img, err := render.Decode(f)
+	require.NoError(t, err)
+
+	return img
+}
+
+func TestGenerateCmd_RenderLocal(t *testing.T) {
+	var blankHits atomic.Int32
+
+	srv := blankServer(t, &blankHits)
+	defer srv.Close()
+
+	ctx := testCtxWithConfig(t, srv.URL)
+	dest := filepath.Join(t.TempDir(), "meme.png")
+	cmd := &GenerateCmd{
+		Template: "drake",
+		Text:     []string{"top", "bottom"},
+		GenerateFlags: GenerateFlags{
+			Format:    "png",
+			TextColor: []string{"yellow"},
+			Output:    dest,
+			Width:     60,
+			Render:    renderLocal,
+		},
+	}
+
+	output := captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, &RootFlags{NoInput: true})) })
+
+	assert.Equal(t, dest+"\n", output)
+	assert.Equal(t, image.Rect(0, 0, 60, 40), decodeFile(t, dest).Bounds(), "--width resizes locally")
+
+	// The blank now comes from the image cache.
+	captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, &RootFlags{NoInput: true})) })
+	assert.Equal(t, int32(1), blankHits.Load())
+}
+
+func TestGenerateCmd_RenderLocal_Offline(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var blankHits atomic.Int32
+
+	srv := blankServer(t, &blankHits)
+	blankURL := srv.URL + "/images/drake.png"
+
+	ctx := testCtx(t, srv.URL, true)
+	outDir := t.TempDir()
+	ctx = config.WithConfig(ctx, &config.Config{OutputDir: outDir})
+
+	// Warm the caches while online: the blank in the image cache and drake
+	// only in the template list.
+	images := imageCache(ctx)
+	require.NotNil(t, images)
+
+	_, err := images.Fetch(ctx, blankURL)
+	require.NoError(t, err)
+
+	store, err := cacheStore(ctx)
+	require.NoError(t, err)
+	require.NoError(t, cache.Save(store, cache.KindTemplates, "", []api.Template{{ID: "drake", Lines: 2, Blank: blankURL}}, api.Validators{}))
+
+	srv.Close()
+
+	cmd := &GenerateCmd{Template: "drake", Text: []string{"no", "network"}, GenerateFlags: GenerateFlags{Format: "jpg", Render: renderLocal}}
+	output := captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, &RootFlags{NoInput: true})) })
+
+	var res map[string]string
+	require.NoError(t, json.Unmarshal([]byte(output), &res))
+	assert.Equal(t, filepath.Join(outDir, "network.jpg"), res["path"])
+	assert.Equal(t, image.Rect(0, 0, 120, 80), decodeFile(t, res["path"]).Bounds())
+}
+
+func TestGenerateCmd_RenderLocal_ResolvesTemplateFromCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var blankHits, lookups atomic.Int32
+
+	blanks := blankServer(t, &blankHits)
+	defer blanks.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups.Add(1)
+		blanks.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	// An expired list would make a regular generate look drake up again.
+	ctx := testCtxWithCfg(t, srv.URL, &config.Config{CacheTTL: "1ns"})
+
+	store, err := cacheStore(ctx)
+	require.NoError(t, err)
+	require.NoError(t, cache.Save(store, cache.KindTemplates, "", []api.Template{
+		{ID: "drake", Lines: 2, Blank: blanks.URL + "/images/drake.png"},
+	}, api.Validators{}))
+
+	dest := filepath.Join(t.TempDir(), "meme.png")
+	cmd := &GenerateCmd{Template: "drake", Text: []string{"a", "b"}, GenerateFlags: GenerateFlags{Format: "png", Output: dest, Render: renderLocal}}
+	captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, &RootFlags{NoInput: true})) })
+
+	assert.Zero(t, lookups.Load(), "the cached list is used whatever its age")
+	assert.FileExists(t, dest)
+
+	// The resolved template also validates the text.
+	cmd.Text = []string{"a", "b", "c"}
+	err = cmd.Run(ctx, &RootFlags{NoInput: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `template "drake" takes 2 lines, got 3`)
+	assert.Zero(t, lookups.Load())
+}
+
+func TestGenerateCmd_RenderLocal_Errors(t *testing.T) {
+	var blankHits atomic.Int32
+
+	srv := blankServer(t, &blankHits)
+	defer srv.Close()
+
+	ctx := testCtxWithConfig(t, srv.URL)
+	out := filepath.Join(t.TempDir(), "x")
+
+	tests := []struct {
+		name string
+		cmd  *GenerateCmd
+		want string
+	}{
+		{"webp", &GenerateCmd{Template: "drake", Text: []string{"a"}, GenerateFlags: GenerateFlags{Format: "webp", Render: renderLocal, Output: out}}, "supports jpg, png and gif"},
+		{"gif of a png", &GenerateCmd{Template: "drake", Text: []string{"a"}, GenerateFlags: GenerateFlags{Format: "gif", Render: renderLocal, Output: out}}, "needs a GIF background"},
+		{"frames of a png", &GenerateCmd{Template: "drake", Text: []string{"a"}, GenerateFlags: GenerateFlags{Format: "png", FromFrame: 2, Render: renderLocal, Output: out}}, "need --format gif"},
+		{"frames via api", &GenerateCmd{Template: "drake", Text: []string{"a"}, GenerateFlags: GenerateFlags{Format: "gif", ToFrame: 2}}, "need --render local"},
+		{"automatic", &GenerateCmd{Template: "just some text", GenerateFlags: GenerateFlags{Render: renderLocal}}, "auto-generate needs the API"},
+		{"custom", &GenerateCmd{Template: "custom", Text: []string{"a"}, GenerateFlags: GenerateFlags{Render: renderLocal, Output: out}}, "--background required"},
+		{"unknown", &GenerateCmd{Template: "nope", Text: []string{"a"}, GenerateFlags: GenerateFlags{Render: renderLocal, Output: out}}, `looking up template "nope"`},
+		{"color", &GenerateCmd{Template: "drake", Text: []string{"a"}, GenerateFlags: GenerateFlags{TextColor: []string{"nocolor"}, Render: renderLocal, Output: out}}, "invalid color"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cmd.Run(ctx, &RootFlags{NoInput: true, Force: true})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestGenerateCmd_RenderLocal_LocalBackground(t *testing.T) {
+	dir := t.TempDir()
+	bg := filepath.Join(dir, "screenshot.png")
+	require.NoError(t, os.WriteFile(bg, blankPNG(t, 90, 30), 0o644))
+
+	// No request may reach the API: the background never leaves the machine.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	}))
+	defer srv.Close()
+
+	ctx := testCtxWithConfig(t, srv.URL)
+	dest := filepath.Join(dir, "meme.png")
+	cmd := &GenerateCmd{
+		Template: "custom",
+		Text:     []string{"works on", "my machine"},
+		GenerateFlags: GenerateFlags{
+			Background: "file://" + bg,
+			Format:     "png",
+			Output:     dest,
+			Render:     renderLocal,
+		},
+	}
+
+	captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, &RootFlags{NoInput: true})) })
+	assert.Equal(t, image.Rect(0, 0, 90, 30), decodeFile(t, dest).Bounds())
+
+	notImage := filepath.Join(dir, "notes.png")
+	require.NoError(t, os.WriteFile(notImage, []byte("plain text"), 0o644))
+
+	cmd.Background = notImage
+	require.ErrorIs(t, cmd.Run(ctx, &RootFlags{NoInput: true, Force: true}), upload.ErrNotImage)
+}
+
+func TestGenerateCmd_RenderLocal_AnimatedGIF(t *testing.T) {
+	dir := t.TempDir()
+
+	// Four 60x40 frames in alternating grays, 70ms apart.
+	pal := color.Palette{color.Gray{Y: 60}, color.Gray{Y: 120}}
+	src := &gif.GIF{LoopCount: 0}
+
+	for i := range 4 {
+		fr := image.NewPaletted(image.Rect(0, 0, 60, 40), pal)
+		for p := range fr.Pix {
+			fr.Pix[p] = uint8(i % 2)
+		}
+
+		src.Image = append(src.Image, fr)
+		src.Delay = append(src.Delay, 7)
+	}
+
+	bg := filepath.Join(dir, "loop.gif")
+	f, err := os.Create(bg)
+	require.NoError(t, err)
+	require.NoError(t, gif.EncodeAll(f, src))
+	require.NoError(t, f.Close())
+
+	ctx := testCtxWithConfig(t, "http://unused")
+	dest := filepath.Join(dir, "meme.gif")
+	cmd := &GenerateCmd{
+		Template: "custom",
+		Text:     []string{"wait for it"},
+		GenerateFlags: GenerateFlags{
+			Background: bg,
+			Format:     "gif",
+			Output:     dest,
+			Render:     renderLocal,
+			FromFrame:  3,
+		},
+	}
+
+	captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, &RootFlags{NoInput: true})) })
+
+	out, err := os.Open(dest)
+	require.NoError(t, err)
+
+	defer out.Close()
+
+	got, err := gif.DecodeAll(out)
+	require.NoError(t, err)
+	require.Len(t, got.Image, 4)
+	assert.Equal(t, []int{7, 7, 7, 7}, got.Delay)
+
+	for i, fr := range got.Image {
+		hasWhite := slices.ContainsFunc(fr.Pix, func(idx uint8) bool {
+			r, g, b, _ := fr.Palette[idx].RGBA()
+
+			return r == 0xffff && g == 0xffff && b == 0xffff
+		})
+		assert.Equal(t, i >= 2, hasWhite, "frame %d captioned only from --from-frame 3", i+1)
+	}
+}
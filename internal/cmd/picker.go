@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"image"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/config"
+	"github.com/dedene/memelink-cli/internal/preview"
+	"github.com/dedene/memelink-cli/internal/render"
+	"github.com/dedene/memelink-cli/internal/tui"
+)
+
+// pickerPreview renders the picker's preview pane. While picking it shows
+// the template's example image (or its blank); while typing and choosing
+// options it draws the text, in the chosen layout and colors, onto the
+// blank with the local renderer, so a live preview costs no API calls.
+// Images come through the image cache.
+func pickerPreview(ctx context.Context, client *api.Client, cfg *config.Config) tui.PreviewFunc {
+	opts := config.Resolve(cfg, config.Flags{})
+
+	// The last decoded image, since typing re-renders the same blank.
+	var (
+		mu      sync.Mutex
+		lastURL string
+		lastImg image.Image
+	)
+
+	load := func(rawURL string) (image.Image, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if rawURL == lastURL && lastImg != nil {
+			return lastImg, nil
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+
+		r, err := openBackground(ctx, client, rawURL)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		img, err := render.Decode(r)
+		if err != nil {
+			return nil, err
+		}
+
+		lastURL, lastImg = rawURL, img
+
+		return img, nil
+	}
+
+	return func(req tui.PreviewRequest) (string, error) {
+		t := req.Template
+
+		if req.Texts == nil {
+			img, err := load(cmp.Or(t.Example.URL, t.Blank))
+			if err != nil {
+				return "", err
+			}
+
+			return preview.Blocks(img, req.Width, req.Height), nil
+		}
+
+		img, err := load(t.Blank)
+		if err != nil {
+			return "", err
+		}
+
+		if slices.ContainsFunc(req.Texts, func(s string) bool { return s != "" }) {
+			img, err = render.Render(img, render.Options{
+				Lines:  req.Texts,
+				Colors: req.Options.Colors,
+				Layout: cmp.Or(req.Options.Layout, opts.Layout),
+			})
+			if err != nil {
+				return "", fmt.Errorf("rendering preview: %w", err)
+			}
+		}
+
+		return preview.Blocks(img, req.Width, req.Height), nil
+	}
+}
+
+// pickerOptions configures the picker's options step: the configured font,
+// layout and format preselected, and GenerateCmd's checks as validation.
+func pickerOptions(cfg *config.Config, root *RootFlags) tui.OptionsConfig {
+	opts := config.Resolve(cfg, config.Flags{})
+
+	return tui.OptionsConfig{
+		Defaults: tui.Options{Font: opts.Font, Layout: opts.Layout, Format: opts.Format},
+		Layouts:  slices.Sorted(maps.Keys(validLayouts)),
+		Formats:  slices.Sorted(maps.Keys(validFormats)),
+		Validate: func(t api.Template, texts []string, o tui.Options) error {
+			gen := pickedGenerateCmd(t, texts, o)
+			if err := gen.checkOptions(cfg); err != nil {
+				return err
+			}
+
+			if root.Force {
+				return nil
+			}
+
+			if err := validateLineLengths(gen.Text); err != nil {
+				return err
+			}
+
+			// The picked template is the metadata, no lookup needed.
+			return validateAgainstTemplate(&t, gen.Text, gen.Style)
+		},
+	}
+}
+
+// pickedGenerateCmd builds the GenerateCmd for a picker result. A template
+// without text lines gets one empty line, since no text at all would mean
+// auto-generate. Lines left without a color are white, Memegen's default,
+// as --text-color takes a color per line in order.
+func pickedGenerateCmd(t api.Template, texts []string, o tui.Options) *GenerateCmd {
+	if len(texts) == 0 {
+		texts = []string{""}
+	}
+
+	colors := slices.Clone(o.Colors)
+	for len(colors) > 0 && colors[len(colors)-1] == "" {
+		colors = colors[:len(colors)-1]
+	}
+
+	for i, c := range colors {
+		if c == "" {
+			colors[i] = "white"
+		}
+	}
+
+	gen := &GenerateCmd{
+		Template: t.ID,
+		Text:     texts,
+		GenerateFlags: GenerateFlags{
+			Font:      o.Font,
+			Layout:    o.Layout,
+			Format:    o.Format,
+			TextColor: colors,
+			Width:     o.Width,
+			Height:    o.Height,
+		},
+	}
+
+	if o.Style != "" {
+		gen.Style = []string{o.Style}
+	}
+
+	return gen
+}
+
+// loadPickerFonts loads the font list, from the cache when fresh, into the
+// running picker's options step. Failures leave only the default font.
+func loadPickerFonts(ctx context.Context, client *api.Client, p *tea.Program) {
+	fonts, err := fetchFonts(ctx, client, false)
+	if err != nil {
+		slog.Debug("loading fonts for the picker", "error", err)
+
+		return
+	}
+
+	ids := make([]string, len(fonts))
+	for i, f := range fonts {
+		ids[i] = f.ID
+	}
+
+	p.Send(tui.FontsMsg{Fonts: ids})
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/config"
+	"github.com/dedene/memelink-cli/internal/tui"
+)
+
+func TestPickerPreview(t *testing.T) {
+	var blankHits atomic.Int32
+
+	srv := blankServer(t, &blankHits)
+	defer srv.Close()
+
+	ctx := testCtxWithConfig(t, srv.URL)
+	render := pickerPreview(ctx, api.ClientFromContext(ctx), config.FromContext(ctx))
+	tmpl := api.Template{ID: "drake", Lines: 2, Blank: srv.URL + "/images/drake.png"}
+
+	blank, err := render(tui.PreviewRequest{Template: tmpl, Width: 30, Height: 20})
+	require.NoError(t, err)
+	assert.Equal(t, 30, lipgloss.Width(blank), "no example falls back to the blank")
+
+	typed, err := render(tui.PreviewRequest{Template: tmpl, Texts: []string{"tabs", ""}, Width: 30, Height: 20})
+	require.NoError(t, err)
+	assert.NotEqual(t, blank, typed, "the text is drawn")
+
+	empty, err := render(tui.PreviewRequest{Template: tmpl, Texts: []string{"", ""}, Width: 30, Height: 20})
+	require.NoError(t, err)
+	assert.Equal(t, blank, empty)
+
+	assert.Equal(t, int32(1), blankHits.Load(), "the blank is fetched once")
+
+	_, err = render(tui.PreviewRequest{Template: api.Template{ID: "nope", Blank: srv.URL + "/images/nope.png"}, Width: 30, Height: 20})
+	require.Error(t, err)
+}
+
+func TestPickerOptions(t *testing.T) {
+	cfg := &config.Config{DefaultFont: "impact", DefaultFormat: "png"}
+	oc := pickerOptions(cfg, &RootFlags{})
+
+	assert.Equal(t, tui.Options{Font: "impact", Layout: "default", Format: "png"}, oc.Defaults)
+	assert.Equal(t, []string{"default", "top"}, oc.Layouts)
+	assert.Equal(t, []string{"gif", "jpg", "png", "webp"}, oc.Formats)
+
+	tmpl := api.Template{ID: "drake", Lines: 2, Styles: []string{"default", "animated"}}
+
+	require.NoError(t, oc.Validate(tmpl, []string{"a", "b"}, tui.Options{Style: "animated"}))
+
+	err := oc.Validate(tmpl, []string{"a", "b"}, tui.Options{Style: "maga"})
+	require.ErrorContains(t, err, `style "maga" not available for template "drake"`)
+
+	err = oc.Validate(tmpl, []string{strings.Repeat("x", maxLineChars+1)}, tui.Options{})
+	require.ErrorContains(t, err, "line 1 is")
+
+	err = oc.Validate(tmpl, []string{"a"}, tui.Options{Format: "bmp"})
+	require.ErrorContains(t, err, `invalid format "bmp"`)
+
+	forced := pickerOptions(cfg, &RootFlags{Force: true})
+	require.NoError(t, forced.Validate(tmpl, []string{"a"}, tui.Options{Style: "maga"}), "--force skips template checks")
+}
+
+func TestPickedGenerateCmd(t *testing.T) {
+	gen := pickedGenerateCmd(api.Template{ID: "drake"}, []string{"a", "b"}, tui.Options{
+		Font: "comic", Layout: "top", Format: "gif", Style: "animated", Colors: []string{"", "red", ""}, Width: 300,
+	})
+
+	assert.Equal(t, &GenerateCmd{
+		Template: "drake",
+		Text:     []string{"a", "b"},
+		GenerateFlags: GenerateFlags{
+			Font:      "comic",
+			Layout:    "top",
+			Format:    "gif",
+			Style:     []string{"animated"},
+			TextColor: []string{"white", "red"},
+			Width:     300,
+		},
+	}, gen)
+
+	gen = pickedGenerateCmd(api.Template{ID: "noline"}, []string{}, tui.Options{})
+	assert.Equal(t, []string{""}, gen.Text, "no text would mean auto-generate")
+	assert.Nil(t, gen.Style)
+}
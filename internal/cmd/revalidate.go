@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/cache"
+	"github.com/dedene/memelink-cli/internal/config"
+	"github.com/dedene/memelink-cli/internal/filelock"
+)
+
+// refreshLockWait bounds how long a refresh waits for another process's
+// refresh of the same cache before fetching on its own.
+const refreshLockWait = 30 * time.Second
+
+// lockRefresh takes the refresh lock at path so concurrent processes
+// refreshing the same cache run one at a time. It returns the release
+// func; when the lock cannot be taken the refresh proceeds unlocked.
+func lockRefresh(ctx context.Context, path string) func() {
+	ctx, cancel := context.WithTimeout(ctx, refreshLockWait)
+	defer cancel()
+
+	lock, err := filelock.Acquire(ctx, path)
+	if err != nil {
+		slog.Debug("refreshing without lock", "path", path, "error", err)
+
+		return func() {}
+	}
+
+	return func() {
+		if err := lock.Release(); err != nil {
+			slog.Debug("refresh lock release", "path", path, "error", err)
+		}
+	}
+}
+
+// apiBaseURL returns the base URL of the client in ctx for
+// config.ServerCacheDir: "" for the public API, also without a client.
+// Caches are kept per base URL.
+func apiBaseURL(ctx context.Context) string {
+	client := api.ClientFromContext(ctx)
+	if client == nil {
+		return ""
+	}
+
+	baseURL := strings.TrimRight(client.BaseURL(), "/")
+	if baseURL == api.DefaultBaseURL {
+		return ""
+	}
+
+	return baseURL
+}
+
+// cacheStore returns the keyed cache store with TTLs from the config in ctx.
+func cacheStore(ctx context.Context) (*cache.Store, error) {
+	dir, err := config.ServerCacheDir(apiBaseURL(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := config.FromContext(ctx)
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	return cache.NewStore(dir, map[cache.Kind]time.Duration{
+		cache.KindTemplates: cfg.CacheTTLFor(string(cache.KindTemplates)),
+		cache.KindFonts:     cfg.CacheTTLFor(string(cache.KindFonts)),
+		cache.KindDetails:   cfg.CacheTTLFor(string(cache.KindDetails)),
+	}, cfg.CacheMaxStaleDuration()), nil
+}
+
+// fetchTemplates returns the full template list from the cache while
+// fresh, otherwise revalidates it. refresh forces the revalidation.
+func fetchTemplates(ctx context.Context, client *api.Client, refresh bool) ([]api.Template, error) {
+	entry, err := fetchTemplateList(ctx, client, refresh)
+
+	return orStale(cache.KindTemplates, entry, err)
+}
+
+// fetchTemplateList is cachedEntry for the template list. Without a cache
+// directory the list comes straight from the API.
+func fetchTemplateList(ctx context.Context, client *api.Client, refresh bool) (*cache.Entry[[]api.Template], error) {
+	store, err := cacheStore(ctx)
+	if err != nil {
+		templates, err := client.ListTemplates(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+
+		return &cache.Entry[[]api.Template]{Data: templates, FetchedAt: time.Now()}, nil
+	}
+
+	return cachedEntry(ctx, store, cache.KindTemplates, "", refresh, func(v api.Validators) ([]api.Template, api.Validators, error) {
+		return client.ListTemplatesIfModified(ctx, "", v)
+	})
+}
+
+// fetchFonts returns the font list from the cache while fresh, otherwise
+// revalidates it. refresh forces the revalidation.
+func fetchFonts(ctx context.Context, client *api.Client, refresh bool) ([]api.Font, error) {
+	store, err := cacheStore(ctx)
+	if err != nil {
+		return client.ListFonts(ctx)
+	}
+
+	return cachedFetch(ctx, store, cache.KindFonts, "", refresh, func(v api.Validators) ([]api.Font, api.Validators, error) {
+		return client.ListFontsIfModified(ctx, v)
+	})
+}
+
+// fetchTemplate returns one template's metadata from the cache while fresh,
+// otherwise revalidates it. refresh forces the revalidation.
+func fetchTemplate(ctx context.Context, client *api.Client, id string, refresh bool) (*api.Template, error) {
+	store, err := cacheStore(ctx)
+	if err != nil {
+		return client.GetTemplate(ctx, id)
+	}
+
+	return cachedFetch(ctx, store, cache.KindDetails, id, refresh, func(v api.Validators) (*api.Template, api.Validators, error) {
+		return client.GetTemplateIfModified(ctx, id, v)
+	})
+}
+
+// cachedFetch answers (kind, key) like cachedEntry, falling back to a stale
+// entry with a warning on stderr when the refresh fails.
+func cachedFetch[T any](
+	ctx context.Context, store *cache.Store, kind cache.Kind, key string, refresh bool,
+	fetch func(api.Validators) (T, api.Validators, error),
+) (T, error) {
+	entry, err := cachedEntry(ctx, store, kind, key, refresh, fetch)
+
+	return orStale(kind, entry, err)
+}
+
+// orStale returns the data of a cachedEntry result. After a failed refresh
+// it serves the stale entry, if any, with a warning on stderr.
+func orStale[T any](kind cache.Kind, entry *cache.Entry[T], err error) (T, error) {
+	if err != nil {
+		if entry == nil {
+			var zero T
+
+			return zero, err
+		}
+
+		fmt.Fprintf(os.Stderr, "warning: using cached %s from %s ago: %v\n",
+			kind, formatAge(time.Since(entry.FetchedAt)), err)
+	}
+
+	return entry.Data, nil
+}
+
+// cachedEntry answers (kind, key) from store while the entry is fresh.
+// Otherwise it runs fetch with the entry's validators: a 304 answers from
+// the entry and restarts its TTL, a 200 replaces it (best-effort). When
+// the fetch fails, the error comes with the entry if it is stale, for the
+// caller to fall back on. Concurrent refreshes of the same entry are
+// coalesced under a lock: a caller that waited for another process's
+// refresh uses its result instead of fetching again.
+func cachedEntry[T any](
+	ctx context.Context, store *cache.Store, kind cache.Kind, key string, refresh bool,
+	fetch func(api.Validators) (T, api.Validators, error),
+) (*cache.Entry[T], error) {
+	entry, freshness, err := cache.Load[T](store, kind, key)
+	if err != nil {
+		slog.Debug("cache load error", "kind", kind, "key", key, "error", err)
+	}
+
+	if !refresh && freshness == cache.Fresh {
+		slog.Debug("using cached entry", "kind", kind, "key", key)
+
+		return entry, nil
+	}
+
+	start := time.Now()
+	defer lockRefresh(ctx, store.LockPath(kind, key))()
+
+	// Reload: another process may have refreshed while we waited.
+	entry, freshness, err = cache.Load[T](store, kind, key)
+	if err != nil {
+		slog.Debug("cache load error", "kind", kind, "key", key, "error", err)
+	}
+
+	if entry != nil && entry.FetchedAt.After(start) {
+		slog.Debug("refresh coalesced", "kind", kind, "key", key)
+
+		return entry, nil
+	}
+
+	var v api.Validators
+	if entry != nil {
+		v = entry.Validators
+	}
+
+	data, nv, err := fetch(v)
+	if errors.Is(err, api.ErrNotModified) && entry != nil {
+		slog.Debug("cache revalidated", "kind", kind, "key", key)
+		data, nv, err = entry.Data, v, nil
+	}
+
+	if err != nil {
+		if freshness != cache.Stale {
+			entry = nil
+		}
+
+		return entry, err
+	}
+
+	if err := cache.Save(store, kind, key, data, nv); err != nil {
+		slog.Debug("cache save error", "kind", kind, "key", key, "error", err)
+	}
+
+	return &cache.Entry[T]{Data: data, FetchedAt: time.Now(), Validators: nv}, nil
+}
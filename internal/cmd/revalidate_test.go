@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/cache"
+	"github.com/dedene/memelink-cli/internal/config"
+)
+
+// etagServer serves body with ETag etag and answers 304 when the request
+// carries it. It counts full (200) responses.
+func etagServer(t *testing.T, etag, body string, full *int) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		*full++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func TestTemplatesCmd_RefreshRevalidates(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	full := 0
+	srv := etagServer(t, `"t1"`, templatesListJSON, &full)
+	defer srv.Close()
+
+	ctx := testCtxWithConfig(t, srv.URL)
+
+	captureStdout(t, func() { require.NoError(t, (&TemplatesCmd{Refresh: true}).Run(ctx, &RootFlags{})) })
+	assert.Equal(t, 1, full)
+
+	store, err := cacheStore(ctx)
+	require.NoError(t, err)
+
+	entry, _, err := cache.Load[[]api.Template](store, cache.KindTemplates, "")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, `"t1"`, entry.ETag)
+
+	// Age the entry so the second refresh proves the 304 restamps it.
+	entry.FetchedAt = time.Now().Add(-time.Hour)
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(store.Path(cache.KindTemplates, ""), data, 0o644))
+
+	output := captureStdout(t, func() { require.NoError(t, (&TemplatesCmd{Refresh: true}).Run(ctx, &RootFlags{})) })
+	assert.Equal(t, 1, full, "second refresh is a 304")
+	assert.Contains(t, output, "3 templates")
+
+	entry, _, err = cache.Load[[]api.Template](store, cache.KindTemplates, "")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), entry.FetchedAt, time.Minute)
+}
+
+func TestTemplatesCmd_ConfiguredTTL(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	full := 0
+	srv := etagServer(t, `"t1"`, templatesListJSON, &full)
+	defer srv.Close()
+
+	seedTemplateCacheAged(t, srv.URL, 2*time.Hour)
+
+	// Within the default 24h the seeded list is fresh; cache_ttl 1h expires it.
+	ctx := testCtxWithCfg(t, srv.URL, &config.Config{CacheTTL: "1h"})
+
+	output := captureStdout(t, func() { require.NoError(t, (&TemplatesCmd{}).Run(ctx, &RootFlags{})) })
+	assert.Equal(t, 1, full)
+	assert.Contains(t, output, "3 templates")
+}
+
+func TestFontsCmd_FreshCacheSkipsNetwork(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	full := 0
+	srv := etagServer(t, `"f1"`, fontsListJSON, &full)
+	defer srv.Close()
+
+	ctx := testCtx(t, srv.URL, false)
+
+	captureStdout(t, func() { require.NoError(t, (&FontsCmd{}).Run(ctx)) })
+	srv.Close()
+
+	output := captureStdout(t, func() { require.NoError(t, (&FontsCmd{}).Run(ctx)) })
+	assert.Equal(t, 1, full)
+	assert.Contains(t, output, "2 fonts", "fresh entry is served without a request")
+}
+
+func TestFontsCmd_RefreshRevalidates(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.Header.Get("If-None-Match") == `"f1"` {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		w.Header().Set("ETag", `"f1"`)
+		_, _ = w.Write([]byte(fontsListJSON))
+	}))
+	defer srv.Close()
+
+	ctx := testCtx(t, srv.URL, false)
+
+	captureStdout(t, func() { require.NoError(t, (&FontsCmd{}).Run(ctx)) })
+	output := captureStdout(t, func() { require.NoError(t, (&FontsCmd{Refresh: true}).Run(ctx)) })
+
+	assert.Equal(t, 2, requests, "--refresh bypasses the fresh entry")
+	assert.Contains(t, output, "2 fonts")
+}
+
+func TestFontsCmd_StaleFallback(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	ctx := testCtx(t, srv.URL, false)
+
+	store, err := cacheStore(ctx)
+	require.NoError(t, err)
+
+	var fonts []api.Font
+	require.NoError(t, json.Unmarshal([]byte(fontsListJSON), &fonts))
+	require.NoError(t, cache.Save(store, cache.KindFonts, "", fonts, api.Validators{}))
+
+	// Age the entry past its TTL but inside cache_max_stale.
+	path := store.Path(cache.KindFonts, "")
+	old := time.Now().Add(-8 * 24 * time.Hour)
+	entry, _, err := cache.Load[[]api.Font](store, cache.KindFonts, "")
+	require.NoError(t, err)
+	entry.FetchedAt = old
+	raw, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+
+	var output string
+
+	stderr := captureStderr(t, func() {
+		output = captureStdout(t, func() { require.NoError(t, (&FontsCmd{}).Run(ctx)) })
+	})
+
+	assert.Contains(t, output, "2 fonts")
+	assert.Contains(t, stderr, "warning: using cached fonts from")
+}
+
+func TestFontsCmd_ListRevalidates(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	full := 0
+	srv := etagServer(t, `"f1"`, fontsListJSON, &full)
+	defer srv.Close()
+
+	ctx := testCtx(t, srv.URL, false)
+
+	first := captureStdout(t, func() { require.NoError(t, (&FontsCmd{}).Run(ctx)) })
+	second := captureStdout(t, func() { require.NoError(t, (&FontsCmd{}).Run(ctx)) })
+
+	assert.Equal(t, 1, full)
+	assert.Equal(t, first, second, "304 answers from the cached list")
+}
+
+func TestLookupTemplate_Revalidates(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	full := 0
+	srv := etagServer(t, `"d1"`, templateDetailJSON, &full)
+	defer srv.Close()
+
+	ctx := testCtx(t, srv.URL, false)
+
+	first, err := lookupTemplate(ctx, "drake", false)
+	require.NoError(t, err)
+
+	second, err := lookupTemplate(ctx, "drake", false)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, full)
+	assert.Equal(t, first, second)
+}
+
+func TestFetch_CoalescesConcurrentRefreshes(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var templateHits, fontHits atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Slow responses keep every caller waiting on the first refresh.
+		time.Sleep(50 * time.Millisecond)
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/fonts" {
+			fontHits.Add(1)
+			_, _ = w.Write([]byte(fontsListJSON))
+
+			return
+		}
+
+		templateHits.Add(1)
+		_, _ = w.Write([]byte(templatesListJSON))
+	}))
+	defer srv.Close()
+
+	ctx := testCtx(t, srv.URL, false)
+	client := api.ClientFromContext(ctx)
+
+	var wg sync.WaitGroup
+
+	for range 6 {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			templates, err := fetchTemplates(ctx, client, true)
+			assert.NoError(t, err)
+			assert.Len(t, templates, 3)
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			fonts, err := fetchFonts(ctx, client, false)
+			assert.NoError(t, err)
+			assert.Len(t, fonts, 2)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), templateHits.Load(), "one template fetch for all callers")
+	assert.Equal(t, int32(1), fontHits.Load(), "one font fetch for all callers")
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/alecthomas/kong"
 
@@ -21,7 +22,9 @@ type RootFlags struct {
 	JSON    bool   `help:"JSON output" default:"false"`
 	Verbose bool   `help:"Verbose logging" default:"false"`
 	NoInput bool   `help:"Never prompt; fail instead" name:"no-input" default:"false"`
-	Force   bool   `help:"Skip confirmations" default:"false"`
+	Force   bool   `help:"Skip confirmations and client-side validation" default:"false"`
+	Profile string `help:"Config profile to use (env: MEMELINK_PROFILE)" name:"profile"`
+	APIURL  string `help:"Memegen API base URL (env: MEMEGEN_BASE_URL)" name:"api-url"`
 }
 
 // CLI is the top-level Kong command struct.
@@ -31,9 +34,16 @@ type CLI struct {
 	Version    kong.VersionFlag `help:"Print version and exit"`
 	VersionCmd VersionCmd       `cmd:"" name:"version" help:"Print version info"`
 	Generate   GenerateCmd      `cmd:"" name:"generate" aliases:"gen,g" default:"withargs" help:"Generate a meme"`
+	Batch      BatchCmd         `cmd:"" name:"batch" help:"Generate memes from a CSV, JSONL or YAML manifest"`
+	Decode     DecodeCmd        `cmd:"" name:"decode" help:"Parse a meme URL into template, text and options"`
+	Edit       EditCmd          `cmd:"" name:"edit" help:"Re-generate a meme URL with overrides"`
 	Templates  TemplatesCmd     `cmd:"" name:"templates" aliases:"ls" help:"List or view templates"`
+	Fav        FavCmd           `cmd:"" name:"fav" help:"Manage favorite templates"`
 	Fonts      FontsCmd         `cmd:"" name:"fonts" help:"List or view fonts"`
 	Config     ConfigCmd        `cmd:"" name:"config" help:"Manage configuration"`
+	Cache      CacheCmd         `cmd:"" name:"cache" help:"Inspect and manage cached data"`
+	History    HistoryCmd       `cmd:"" name:"history" help:"List, search and re-run generated memes"`
+	Doctor     DoctorCmd        `cmd:"" name:"doctor" help:"Check connectivity to the Memegen API"`
 }
 
 // Execute parses CLI args, sets up context, and runs the matched command.
@@ -102,20 +112,47 @@ func Execute(args []string) (err error) {
 	}
 	ctx = ui.WithUI(ctx, u)
 
-	// Config
-	cfgPath, _ := config.Path()
-	cfg, cfgErr := config.Load(cfgPath)
+	// Config profile: --profile > MEMELINK_PROFILE > `config profiles use` > default.
+	// Only `config set` may name a new profile (it creates it); profile
+	// management also runs when the selected profile is gone.
+	command := kctx.Command()
+	allowNew := strings.HasPrefix(command, "config set ") || strings.HasPrefix(command, "config profiles")
+	profile, err := config.ActiveProfile(cli.Profile, allowNew)
+	if err != nil {
+		return err
+	}
+	ctx = config.WithProfile(ctx, profile)
+
+	// Config: user/profile file with the nearest .memelink.json5 merged over it
+	cfgPath, _ := config.ProfilePath(profile)
+	cwd, _ := os.Getwd()
+	cfg, cfgErr := config.LoadEffective(cfgPath, cwd)
+	if errors.Is(cfgErr, config.ErrInvalidValue) {
+		// A bad MEMELINK_<KEY> value: fail instead of silently ignoring it.
+		return cfgErr
+	}
 	if cfgErr != nil {
 		slog.Warn("loading config", "error", cfgErr)
 		cfg = &config.Config{}
 	}
 	ctx = config.WithConfig(ctx, cfg)
 
-	// API client
+	// API client: --api-url > MEMELINK_API_BASE_URL/MEMEGEN_BASE_URL > config > public API
+	if cli.APIURL != "" {
+		if err := config.Validate("api_base_url", cli.APIURL); err != nil {
+			return fmt.Errorf("invalid --api-url: %w", err)
+		}
+	}
+	opts := config.Resolve(cfg, config.Flags{APIURL: cli.APIURL})
 	client := api.NewClient(api.ClientOptions{
-		APIKey:    os.Getenv("MEMEGEN_API_KEY"),
-		Verbose:   cli.Verbose,
-		UserAgent: "memelink-cli/" + version,
+		BaseURL:      opts.APIBaseURL,
+		APIKey:       os.Getenv("MEMEGEN_API_KEY"),
+		Verbose:      cli.Verbose,
+		UserAgent:    "memelink-cli/" + version,
+		MaxRetries:   opts.MaxRetries,
+		MaxRetryWait: opts.RetryMaxWait,
+		RateLimit:    opts.RateLimit,
+		RateBurst:    opts.RateBurst,
 	})
 	ctx = api.WithClient(ctx, client)
 
@@ -123,5 +160,17 @@ func Execute(args []string) (err error) {
 	kctx.BindTo(ctx, (*context.Context)(nil))
 	kctx.Bind(&cli.RootFlags)
 
-	return kctx.Run()
+	if err := kctx.Run(); err != nil {
+		var se *suggestionError
+		if outfmt.IsJSON(ctx) && errors.As(err, &se) {
+			_ = outfmt.WriteJSON(os.Stdout, map[string]any{
+				"error":       se.Err.Error(),
+				"suggestions": se.Suggestions,
+			})
+		}
+
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/suggest"
+)
+
+// maxSuggestions caps the "did you mean" list.
+const maxSuggestions = 3
+
+// suggestionError decorates a not-found error with close matches. In JSON
+// mode Execute also writes the suggestions to stdout.
+type suggestionError struct {
+	Err         error
+	Suggestions []string
+}
+
+func (e *suggestionError) Error() string {
+	return fmt.Sprintf("%s (did you mean: %s?)", e.Err, strings.Join(e.Suggestions, ", "))
+}
+
+func (e *suggestionError) Unwrap() error {
+	return e.Err
+}
+
+// isNotFound reports whether err carries a 404 from the Memegen API.
+func isNotFound(err error) bool {
+	var apiErr *api.Error
+
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// withTemplateSuggestions adds close template IDs to a 404 error. The cached
+// template list is used when fresh; otherwise the list is fetched unless
+// cacheOnly is set. Other errors, and 404s without matches, pass through.
+func withTemplateSuggestions(ctx context.Context, err error, id string, cacheOnly bool) error {
+	if !isNotFound(err) {
+		return err
+	}
+
+	templates := loadCachedTemplates(ctx)
+	if templates == nil && !cacheOnly {
+		if client := api.ClientFromContext(ctx); client != nil {
+			// A stale list, if the refresh fails, is good enough here.
+			entry, listErr := fetchTemplateList(ctx, client, false)
+			if listErr != nil {
+				slog.Debug("listing templates for suggestions", "error", listErr)
+			}
+
+			if entry != nil {
+				templates = entry.Data
+			}
+		}
+	}
+
+	candidates := make([]suggest.Candidate, 0, len(templates))
+	for _, t := range templates {
+		candidates = append(candidates, suggest.Candidate{
+			ID:    t.ID,
+			Words: append([]string{t.Name}, t.Keywords...),
+		})
+	}
+
+	return withSuggestions(err, suggest.Rank(id, candidates, maxSuggestions))
+}
+
+// withFontSuggestions adds close font IDs and aliases to a 404 error.
+func withFontSuggestions(ctx context.Context, err error, id string) error {
+	if !isNotFound(err) {
+		return err
+	}
+
+	client := api.ClientFromContext(ctx)
+	if client == nil {
+		return err
+	}
+
+	fonts, listErr := fetchFonts(ctx, client, false)
+	if listErr != nil {
+		slog.Debug("listing fonts for suggestions", "error", listErr)
+
+		return err
+	}
+
+	candidates := make([]suggest.Candidate, 0, len(fonts))
+	for _, f := range fonts {
+		c := suggest.Candidate{ID: f.ID}
+		if f.Alias != nil {
+			c.Words = []string{*f.Alias}
+		}
+
+		candidates = append(candidates, c)
+	}
+
+	return withSuggestions(err, suggest.Rank(id, candidates, maxSuggestions))
+}
+
+// knownFont reports whether id is a font ID or alias. When the font list
+// cannot be loaded it assumes the font exists.
+func knownFont(ctx context.Context, client *api.Client, id string) bool {
+	fonts, err := fetchFonts(ctx, client, false)
+	if err != nil {
+		slog.Debug("listing fonts to check --font", "error", err)
+
+		return true
+	}
+
+	return slices.ContainsFunc(fonts, func(f api.Font) bool {
+		return f.ID == id || (f.Alias != nil && *f.Alias == id)
+	})
+}
+
+// withSuggestions wraps err when there is anything to suggest.
+func withSuggestions(err error, suggestions []string) error {
+	if len(suggestions) == 0 {
+		return err
+	}
+
+	return &suggestionError{Err: err, Suggestions: suggestions}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/api"
+)
+
+func TestSuggestionError(t *testing.T) {
+	base := &api.Error{StatusCode: http.StatusNotFound, Message: "template not found"}
+	err := withSuggestions(base, []string{"drake", "ds"})
+
+	assert.Equal(t, "memegen api: template not found (HTTP 404) (did you mean: drake, ds?)", err.Error())
+	assert.True(t, errors.Is(err, base))
+	assert.Same(t, base, withSuggestions(base, nil))
+}
+
+func TestTemplatesCmd_Detail_NotFoundSuggests(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	seedTemplateCache(t, srv.URL)
+
+	err := (&TemplatesCmd{ID: "darke"}).Run(testCtx(t, srv.URL, false), &RootFlags{})
+	require.Error(t, err)
+
+	var se *suggestionError
+	require.ErrorAs(t, err, &se)
+	assert.Equal(t, []string{"drake"}, se.Suggestions)
+	assert.Contains(t, err.Error(), "did you mean: drake?")
+}
+
+func TestGenerateCmd_NotFoundSuggestsFromFetchedList(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == http.MethodGet && r.URL.Path == "/templates" {
+			_, _ = w.Write([]byte(templatesListJSON))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	cmd := &GenerateCmd{Template: "frry", Text: []string{"a", "b"}}
+	err := cmd.Run(testCtx(t, srv.URL, false), &RootFlags{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did you mean: fry")
+}
+
+func TestGenerateCmd_NotFoundNoSuggestions(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	seedTemplateCache(t, srv.URL)
+
+	cmd := &GenerateCmd{Template: "qqqqqqqq", Text: []string{"a"}}
+	err := cmd.Run(testCtx(t, srv.URL, false), &RootFlags{})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "did you mean")
+}
+
+func TestGenerateCmd_UnknownFontSuggests(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/fonts":
+			_, _ = w.Write([]byte(fontsListJSON))
+		case r.Method == http.MethodGet && r.URL.Path == "/templates":
+			_, _ = w.Write([]byte(templatesListJSON))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cmd := &GenerateCmd{Template: "fry", Text: []string{"a", "b"}, GenerateFlags: GenerateFlags{Font: "impakt"}}
+	err := cmd.Run(testCtx(t, srv.URL, false), &RootFlags{Force: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did you mean: impact?")
+}
+
+func TestFontsCmd_Detail_NotFoundSuggests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Path == "/fonts" {
+			_, _ = w.Write([]byte(fontsListJSON))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"font not found"}`))
+	}))
+	defer srv.Close()
+
+	err := (&FontsCmd{ID: "impakt"}).Run(testCtx(t, srv.URL, false))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did you mean: impact?")
+}
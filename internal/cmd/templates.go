@@ -9,16 +9,13 @@ import (
 	"strings"
 	"time"
 
-	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/mattn/go-isatty"
 
-	"github.com/dedene/memelink-cli/internal/actions"
 	"github.com/dedene/memelink-cli/internal/api"
 	"github.com/dedene/memelink-cli/internal/cache"
 	"github.com/dedene/memelink-cli/internal/config"
 	"github.com/dedene/memelink-cli/internal/outfmt"
-	"github.com/dedene/memelink-cli/internal/preview"
 	"github.com/dedene/memelink-cli/internal/tui"
 	"github.com/dedene/memelink-cli/internal/ui"
 )
@@ -52,9 +49,9 @@ func (c *TemplatesCmd) runDetail(ctx context.Context) error {
 		return errors.New("api client not found in context")
 	}
 
-	tmpl, err := client.GetTemplate(ctx, c.ID)
+	tmpl, err := fetchTemplate(ctx, client, c.ID, c.Refresh)
 	if err != nil {
-		return fmt.Errorf("getting template: %w", err)
+		return withTemplateSuggestions(ctx, fmt.Errorf("getting template: %w", err), c.ID, false)
 	}
 
 	if outfmt.IsJSON(ctx) {
@@ -89,127 +86,115 @@ func (c *TemplatesCmd) runDetail(ctx context.Context) error {
 	return nil
 }
 
-// runInteractive launches the bubbletea fuzzy template picker with text input,
-// then calls the generate API and prints the meme URL to stdout.
+// runInteractive launches the bubbletea fuzzy template picker with text input
+// and an options step, then generates the meme like 'memelink generate'. A stale
+// template cache opens the picker immediately and is refreshed in the
+// background, as is the font list for the options step. Where previews are
+// on, the picker shows the highlighted template, and the meme while typing,
+// in a pane beside the list.
 func (c *TemplatesCmd) runInteractive(ctx context.Context, root *RootFlags) error {
-	templates, err := c.loadTemplates(ctx)
-	if err != nil {
-		return err
-	}
-
-	items := make([]list.Item, len(templates))
-	for i, t := range templates {
-		items[i] = tui.NewTemplateItem(t)
-	}
-
-	m := tui.NewPicker(items)
+	var templates []api.Template
 
-	p := tea.NewProgram(m, tea.WithOutput(os.Stderr), tea.WithInputTTY())
+	cached, freshness := readTemplateCache(ctx)
+	revalidate := !c.Refresh && freshness == cache.Stale
 
-	result, err := p.Run()
-	if err != nil {
-		return fmt.Errorf("interactive picker: %w", err)
-	}
+	if revalidate {
+		templates = cached.Data
+	} else {
+		loaded, err := c.loadTemplates(ctx)
+		if err != nil {
+			return err
+		}
 
-	picker, ok := result.(tui.Model)
-	if !ok {
-		return errors.New("unexpected picker result type")
+		templates = loaded.Templates
 	}
 
-	if picker.Cancelled() || picker.Selected() == nil {
-		return nil
-	}
+	m := tui.NewPicker(loadTemplateRanking().items(templates))
 
-	// Generate meme with selected template + entered text.
+	cfg := config.FromContext(ctx)
 	client := api.ClientFromContext(ctx)
 	if client == nil {
 		return errors.New("api client not found in context")
 	}
 
-	cfg := config.FromContext(ctx)
-
-	resp, err := client.Generate(ctx, api.GenerateRequest{
-		TemplateID: picker.Selected().ID,
-		Text:       picker.Texts(),
-		Extension:  effectiveFormatFromConfig(cfg),
-		Font:       effectiveFontFromConfig(cfg),
-		Layout:     effectiveLayoutFromConfig(cfg),
-		Redirect:   false,
-	})
-	if err != nil {
-		return fmt.Errorf("generating meme: %w", err)
-	}
+	m = m.WithOptions(pickerOptions(cfg, root))
 
-	// Preview (config/default cascade only, no explicit flag on TemplatesCmd).
 	if shouldPreview(nil, cfg, root) {
-		_ = preview.Show(ctx, resp.URL, preview.Options{
-			Writer: os.Stderr,
-		})
+		m = m.WithPreview(pickerPreview(ctx, client, cfg))
 	}
 
-	fmt.Fprintln(os.Stdout, resp.URL)
+	p := tea.NewProgram(m, tea.WithOutput(os.Stderr), tea.WithInputTTY())
 
-	// Fire config-based auto actions (TUI flow has no explicit flags).
-	if cfg != nil && cfg.AutoCopy != nil && *cfg.AutoCopy {
-		if err := actions.CopyToClipboard(resp.URL); err != nil {
-			fmt.Fprintf(os.Stderr, "warning: clipboard: %v\n", err)
-		}
-	}
+	loadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	if cfg != nil && cfg.AutoOpen != nil && *cfg.AutoOpen {
-		if err := actions.OpenInBrowser(resp.URL); err != nil {
-			fmt.Fprintf(os.Stderr, "warning: browser: %v\n", err)
-		}
+	if revalidate {
+		go refreshPicker(loadCtx, p)
 	}
 
-	return nil
-}
+	go loadPickerFonts(loadCtx, client, p)
 
-// effectiveFormatFromConfig returns the config default format or "jpg".
-func effectiveFormatFromConfig(cfg *config.Config) string {
-	if cfg != nil && cfg.DefaultFormat != "" {
-		return cfg.DefaultFormat
+	result, err := p.Run()
+	if err != nil {
+		return fmt.Errorf("interactive picker: %w", err)
 	}
 
-	return "jpg"
-}
+	picker, ok := result.(tui.Model)
+	if !ok {
+		return errors.New("unexpected picker result type")
+	}
 
-// effectiveFontFromConfig returns the config default font or "" (API default).
-func effectiveFontFromConfig(cfg *config.Config) string {
-	if cfg != nil && cfg.DefaultFont != "" {
-		return cfg.DefaultFont
+	if picker.Cancelled() || picker.Selected() == nil {
+		return nil
 	}
 
-	return ""
-}
+	// Generate through the generate command's path, so history, preview
+	// and auto actions match 'memelink generate'. The options step already
+	// validated against the picked template, so its checks are skipped.
+	gen := pickedGenerateCmd(*picker.Selected(), picker.Texts(), picker.Options())
 
-// effectiveLayoutFromConfig returns the config default layout or "default".
-func effectiveLayoutFromConfig(cfg *config.Config) string {
-	if cfg != nil && cfg.DefaultLayout != "" {
-		return cfg.DefaultLayout
+	res, err := gen.generate(ctx, cfg, true)
+	if err != nil {
+		return err
 	}
 
-	return "default"
+	recordHistory(ctx, gen.historyEntry(cfg, res))
+
+	return gen.output(ctx, res, cfg, root)
 }
 
-// runList fetches all templates and prints them as a table.
+// runList fetches all templates and prints them as a table, favorites and
+// most-used first. JSON keeps the API order.
 // Uses cached results when available and not --refresh.
 func (c *TemplatesCmd) runList(ctx context.Context) error {
-	templates, err := c.loadTemplates(ctx)
+	loaded, err := c.loadTemplates(ctx)
 	if err != nil {
 		return err
 	}
 
+	templates := loaded.Templates
+
 	// Filter animated-capable if requested.
 	if c.Animated {
 		templates = filterAnimated(templates)
 	}
 
 	if outfmt.IsJSON(ctx) {
+		if loaded.Stale {
+			return outfmt.WriteJSON(os.Stdout, staleTemplatesJSON{
+				Stale:     true,
+				FetchedAt: loaded.FetchedAt,
+				Templates: templates,
+			})
+		}
+
 		return outfmt.WriteJSON(os.Stdout, templates)
 	}
 
-	// Build table rows.
+	// Build table rows, favorites and most-used templates first.
+	r := loadTemplateRanking()
+	templates = r.sort(templates)
+
 	rows := make([][]string, 0, len(templates))
 	for _, t := range templates {
 		animated := ""
@@ -217,7 +202,12 @@ func (c *TemplatesCmd) runList(ctx context.Context) error {
 			animated = "yes"
 		}
 
-		rows = append(rows, []string{t.ID, t.Name, fmt.Sprintf("%d", t.Lines), animated})
+		fav := ""
+		if r.isFavorite(t.ID) {
+			fav = "★"
+		}
+
+		rows = append(rows, []string{fav, t.ID, t.Name, fmt.Sprintf("%d", t.Lines), animated, usesColumn(r.uses[t.ID])})
 	}
 
 	colorEnabled := false
@@ -226,7 +216,7 @@ func (c *TemplatesCmd) runList(ctx context.Context) error {
 	}
 
 	fmt.Fprint(os.Stdout, ui.RenderTable(
-		[]string{"ID", "Name", "Lines", "Animated"},
+		[]string{"Fav", "ID", "Name", "Lines", "Animated", "Uses"},
 		rows,
 		colorEnabled,
 	))
@@ -235,74 +225,158 @@ func (c *TemplatesCmd) runList(ctx context.Context) error {
 	return nil
 }
 
+// templateList is a loaded template list. Stale marks data served from an
+// expired cache because the refresh failed; FetchedAt is then its age.
+type templateList struct {
+	Templates []api.Template
+	Stale     bool
+	FetchedAt time.Time
+}
+
+// staleTemplatesJSON is the --json shape of a stale template list.
+type staleTemplatesJSON struct {
+	Stale     bool           `json:"stale"`
+	FetchedAt time.Time      `json:"fetched_at"`
+	Templates []api.Template `json:"templates"`
+}
+
 // loadTemplates fetches templates from cache or API. Shared by runList and runInteractive.
-func (c *TemplatesCmd) loadTemplates(ctx context.Context) ([]api.Template, error) {
+// When the refresh fails, a stale cache within cache_max_stale is served
+// instead, with a warning on stderr. --filter is applied locally to the
+// full list.
+func (c *TemplatesCmd) loadTemplates(ctx context.Context) (templateList, error) {
+	list, err := c.loadAllTemplates(ctx)
+	if err != nil {
+		return templateList{}, err
+	}
+
+	if c.Filter != "" {
+		list.Templates = filterTemplates(list.Templates, c.Filter)
+	}
+
+	return list, nil
+}
+
+// loadAllTemplates returns the full template list: the cache while fresh,
+// otherwise a revalidation, falling back to a stale cache on failure.
+func (c *TemplatesCmd) loadAllTemplates(ctx context.Context) (templateList, error) {
 	client := api.ClientFromContext(ctx)
 	if client == nil {
-		return nil, errors.New("api client not found in context")
+		return templateList{}, errors.New("api client not found in context")
 	}
 
-	var templates []api.Template
-
-	// Try cache: only for unfiltered, non-refresh requests.
-	if !c.Refresh && c.Filter == "" {
-		if cached := c.loadCache(ctx); cached != nil {
-			templates = cached
-			slog.Debug("using cached templates", "count", len(templates))
-		}
+	entry, err := fetchTemplateList(ctx, client, c.Refresh)
+	if err != nil && entry == nil {
+		return templateList{}, fmt.Errorf("listing templates: %w", err)
 	}
 
-	// Cache miss or bypass -- fetch from API.
-	if templates == nil {
-		var err error
+	templates, _ := orStale(cache.KindTemplates, entry, err)
 
-		templates, err = client.ListTemplates(ctx, c.Filter)
-		if err != nil {
-			return nil, fmt.Errorf("listing templates: %w", err)
-		}
+	return templateList{Templates: templates, Stale: err != nil, FetchedAt: entry.FetchedAt}, nil
+}
 
-		// Persist unfiltered results to cache (best-effort).
-		if c.Filter == "" {
-			c.saveCache(templates)
-		}
+// readTemplateCache reads the cached template list regardless of its age
+// and classifies it. Returns (nil, cache.Missing) on any error.
+func readTemplateCache(ctx context.Context) (*cache.Entry[[]api.Template], cache.Freshness) {
+	store, err := cacheStore(ctx)
+	if err != nil {
+		return nil, cache.Missing
 	}
 
-	return templates, nil
+	entry, freshness, err := cache.Load[[]api.Template](store, cache.KindTemplates, "")
+	if err != nil {
+		slog.Debug("cache load error", "error", err)
+	}
+
+	return entry, freshness
 }
 
-// loadCache attempts to load templates from disk cache.
+// loadCachedTemplates attempts to load templates from disk cache.
 // Returns nil on any error or cache miss.
-func (c *TemplatesCmd) loadCache(ctx context.Context) []api.Template {
-	cachePath, err := config.CachePath()
-	if err != nil {
+func loadCachedTemplates(ctx context.Context) []api.Template {
+	entry, freshness := readTemplateCache(ctx)
+	if freshness != cache.Fresh {
 		return nil
 	}
 
-	ttl := 24 * time.Hour
-	if cfg := config.FromContext(ctx); cfg != nil {
-		ttl = cfg.CacheTTLDuration()
+	return entry.Data
+}
+
+// refreshPicker revalidates the template cache and, when it succeeds, swaps
+// the fresh list into the running picker. Failures keep the stale list.
+func refreshPicker(ctx context.Context, p *tea.Program) {
+	client := api.ClientFromContext(ctx)
+	if client == nil {
+		return
 	}
 
-	cached, err := cache.LoadTemplates(cachePath, ttl)
+	// No stale fallback: the picker already shows the stale list, and a
+	// warning on stderr would garble it.
+	entry, err := fetchTemplateList(ctx, client, true)
 	if err != nil {
-		slog.Debug("cache load error", "error", err)
+		slog.Debug("background template refresh failed", "error", err)
 
-		return nil
+		return
 	}
 
-	return cached
+	p.Send(tui.ItemsMsg{Items: loadTemplateRanking().items(entry.Data)})
 }
 
-// saveCache persists templates to disk cache (best-effort).
-func (c *TemplatesCmd) saveCache(templates []api.Template) {
-	cachePath, err := config.CachePath()
+// lookupTemplate returns metadata for a single template, answering from the
+// fresh template cache when possible and falling back to a revalidated
+// GET /templates/{id}.
+// With cacheOnly set (offline mode) a cache miss returns (nil, nil).
+func lookupTemplate(ctx context.Context, id string, cacheOnly bool) (*api.Template, error) {
+	for _, t := range loadCachedTemplates(ctx) {
+		if t.ID == id {
+			return &t, nil
+		}
+	}
+
+	if cacheOnly {
+		return nil, nil
+	}
+
+	client := api.ClientFromContext(ctx)
+	if client == nil {
+		return nil, errors.New("api client not found in context")
+	}
+
+	tmpl, err := fetchTemplate(ctx, client, id, false)
 	if err != nil {
-		return
+		return nil, fmt.Errorf("getting template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// filterTemplates keeps templates matching query the way the API's
+// ?filter= does: a case-insensitive substring of the ID, name, example
+// text or any keyword.
+func filterTemplates(templates []api.Template, query string) []api.Template {
+	query = strings.ToLower(query)
+	result := make([]api.Template, 0, len(templates))
+
+	for _, t := range templates {
+		if templateMatches(t, query) {
+			result = append(result, t)
+		}
 	}
 
-	if err := cache.SaveTemplates(cachePath, templates); err != nil {
-		slog.Debug("cache save error", "error", err)
+	return result
+}
+
+// templateMatches reports whether t matches the lowercased query.
+func templateMatches(t api.Template, query string) bool {
+	fields := append([]string{t.ID, t.Name, strings.Join(t.Example.Text, " ")}, t.Keywords...)
+
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), query) {
+			return true
+		}
 	}
+
+	return false
 }
 
 // hasAnimated checks if "animated" is present in a styles slice.
@@ -11,6 +11,10 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/api"
+	"github.com/dedene/memelink-cli/internal/cache"
+	"github.com/dedene/memelink-cli/internal/config"
 )
 
 const templatesListJSON = `[
@@ -83,18 +87,20 @@ func TestTemplatesCmd_List_Filter(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		gotQuery = r.URL.RawQuery
 		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write([]byte(`[{"id":"drake","name":"Drake Hotline Bling","lines":2,"styles":["default"]}]`))
+		_, _ = w.Write([]byte(templatesListJSON))
 	}))
 	defer srv.Close()
 
 	ctx := testCtx(t, srv.URL, false)
-	cmd := &TemplatesCmd{Filter: "drake"}
+	cmd := &TemplatesCmd{Filter: "DRAKE"}
 
 	output := captureStdout(t, func() {
 		require.NoError(t, cmd.Run(ctx, &RootFlags{NoInput: true}))
 	})
 
-	assert.Contains(t, gotQuery, "filter=drake")
+	assert.Empty(t, gotQuery, "the full list is fetched and filtered locally")
+	assert.Contains(t, output, "drake")
+	assert.NotContains(t, output, "buzz")
 	assert.Contains(t, output, "1 templates")
 }
 
@@ -205,8 +211,6 @@ func TestTemplatesCmd_List_UsesCache(t *testing.T) {
 	cacheDir := t.TempDir()
 	t.Setenv("XDG_CACHE_HOME", cacheDir)
 
-	seedTemplateCache(t, cacheDir)
-
 	requestCount := 0
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		requestCount++
@@ -215,6 +219,8 @@ func TestTemplatesCmd_List_UsesCache(t *testing.T) {
 	}))
 	defer srv.Close()
 
+	seedTemplateCache(t, srv.URL)
+
 	ctx := testCtxWithConfig(t, srv.URL)
 	cmd := &TemplatesCmd{}
 
@@ -231,8 +237,6 @@ func TestTemplatesCmd_List_RefreshBypassesCache(t *testing.T) {
 	cacheDir := t.TempDir()
 	t.Setenv("XDG_CACHE_HOME", cacheDir)
 
-	seedTemplateCache(t, cacheDir)
-
 	requestCount := 0
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		requestCount++
@@ -241,6 +245,8 @@ func TestTemplatesCmd_List_RefreshBypassesCache(t *testing.T) {
 	}))
 	defer srv.Close()
 
+	seedTemplateCache(t, srv.URL)
+
 	ctx := testCtxWithConfig(t, srv.URL)
 	cmd := &TemplatesCmd{Refresh: true}
 
@@ -252,12 +258,10 @@ func TestTemplatesCmd_List_RefreshBypassesCache(t *testing.T) {
 	assert.Contains(t, output, "3 templates")
 }
 
-func TestTemplatesCmd_List_FilterBypassesCache(t *testing.T) {
+func TestTemplatesCmd_List_FilterUsesCache(t *testing.T) {
 	cacheDir := t.TempDir()
 	t.Setenv("XDG_CACHE_HOME", cacheDir)
 
-	seedTemplateCache(t, cacheDir)
-
 	requestCount := 0
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		requestCount++
@@ -266,6 +270,8 @@ func TestTemplatesCmd_List_FilterBypassesCache(t *testing.T) {
 	}))
 	defer srv.Close()
 
+	seedTemplateCache(t, srv.URL)
+
 	ctx := testCtxWithConfig(t, srv.URL)
 	cmd := &TemplatesCmd{Filter: "drake"}
 
@@ -273,7 +279,9 @@ func TestTemplatesCmd_List_FilterBypassesCache(t *testing.T) {
 		require.NoError(t, cmd.Run(ctx, &RootFlags{}))
 	})
 
-	assert.Equal(t, 1, requestCount, "should hit API when --filter")
+	assert.Equal(t, 0, requestCount, "--filter is answered from the cached list")
+	assert.Contains(t, output, "drake")
+	assert.NotContains(t, output, "fry")
 	assert.Contains(t, output, "1 templates")
 }
 
@@ -295,31 +303,140 @@ func TestTemplatesCmd_List_PopulatesCache(t *testing.T) {
 	})
 
 	// Verify cache file was created.
-	cachePath := filepath.Join(cacheDir, "memelink", "templates.json")
-	_, err := os.Stat(cachePath)
+	_, err := os.Stat(templateCachePath(t, srv.URL))
 	assert.NoError(t, err, "cache file should exist after API fetch")
 }
 
-// seedTemplateCache writes a valid cache file with known templates.
-func seedTemplateCache(t *testing.T, cacheDir string) {
+// --- Stale cache tests ---
+
+func TestTemplatesCmd_List_StaleFallback(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close() // network failure
+
+	seedTemplateCacheAged(t, srv.URL, 48*time.Hour)
+
+	ctx := testCtxWithConfig(t, srv.URL)
+
+	var output string
+
+	stderr := captureStderr(t, func() {
+		output = captureStdout(t, func() {
+			require.NoError(t, (&TemplatesCmd{}).Run(ctx, &RootFlags{}))
+		})
+	})
+
+	assert.Contains(t, output, "2 templates")
+	assert.Contains(t, stderr, "warning: using cached templates from 48h0m0s ago")
+}
+
+func TestTemplatesCmd_List_StaleJSON(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	seedTemplateCacheAged(t, srv.URL, 48*time.Hour)
+
+	ctx := config.WithConfig(testCtx(t, srv.URL, true), &config.Config{})
+
+	var output string
+
+	captureStderr(t, func() {
+		output = captureStdout(t, func() {
+			require.NoError(t, (&TemplatesCmd{}).Run(ctx, &RootFlags{}))
+		})
+	})
+
+	var got staleTemplatesJSON
+	require.NoError(t, json.Unmarshal([]byte(output), &got))
+	assert.True(t, got.Stale)
+	assert.Len(t, got.Templates, 2)
+}
+
+func TestTemplatesCmd_List_BeyondMaxStale(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	seedTemplateCacheAged(t, srv.URL, 48*time.Hour)
+
+	ctx := testCtxWithCfg(t, srv.URL, &config.Config{CacheMaxStale: "1h"})
+
+	err := (&TemplatesCmd{}).Run(ctx, &RootFlags{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "listing templates")
+}
+
+// seedTemplateCache writes a valid cache file with known templates for the
+// API at baseURL, under the current XDG_CACHE_HOME.
+func seedTemplateCache(t *testing.T, baseURL string) {
+	t.Helper()
+
+	seedTemplateCacheAged(t, baseURL, 0)
+}
+
+// seedTemplateCacheAged writes the seeded cache as fetched age ago.
+func seedTemplateCacheAged(t *testing.T, baseURL string, age time.Duration) {
 	t.Helper()
 
-	dir := filepath.Join(cacheDir, "memelink")
-	require.NoError(t, os.MkdirAll(dir, 0o755))
+	cachePath := templateCachePath(t, baseURL)
+	require.NoError(t, os.MkdirAll(filepath.Dir(cachePath), 0o755))
 
 	tc := struct {
-		Templates []map[string]any `json:"templates"`
+		Data      []map[string]any `json:"data"`
 		FetchedAt string           `json:"fetched_at"`
 	}{
-		Templates: []map[string]any{
+		Data: []map[string]any{
 			{"id": "drake", "name": "Drake Hotline Bling", "lines": float64(2), "styles": []string{"default", "animated"}},
 			{"id": "fry", "name": "Futurama Fry", "lines": float64(2), "styles": []string{"default"}},
 		},
-		FetchedAt: time.Now().Format(time.RFC3339Nano),
+		FetchedAt: time.Now().Add(-age).Format(time.RFC3339Nano),
 	}
 
 	data, err := json.MarshalIndent(tc, "", "  ")
 	require.NoError(t, err)
 
-	require.NoError(t, os.WriteFile(filepath.Join(dir, "templates.json"), data, 0o644))
+	require.NoError(t, os.WriteFile(cachePath, data, 0o644))
+}
+
+// templateCachePath returns the template list's cache file for the API at
+// baseURL.
+func templateCachePath(t *testing.T, baseURL string) string {
+	t.Helper()
+
+	dir, err := config.ServerCacheDir(baseURL)
+	require.NoError(t, err)
+
+	return cache.NewStore(dir, nil, 0).Path(cache.KindTemplates, "")
+}
+
+func TestFilterTemplates(t *testing.T) {
+	templates := []api.Template{
+		{ID: "drake", Name: "Drake Hotline Bling", Keywords: []string{"prefer"}},
+		{ID: "buzz", Name: "X, X Everywhere"},
+		{ID: "fry", Name: "Futurama Fry"},
+	}
+	templates[1].Example.Text = []string{"memes", "memes everywhere"}
+
+	ids := func(ts []api.Template) []string {
+		out := make([]string, 0, len(ts))
+		for _, t := range ts {
+			out = append(out, t.ID)
+		}
+
+		return out
+	}
+
+	assert.Equal(t, []string{"drake"}, ids(filterTemplates(templates, "HOTLINE")))
+	assert.Equal(t, []string{"drake"}, ids(filterTemplates(templates, "prefer")), "keywords match")
+	assert.Equal(t, []string{"buzz"}, ids(filterTemplates(templates, "everywhere")))
+	assert.Equal(t, []string{"fry"}, ids(filterTemplates(templates, "futurama")))
+	assert.Empty(t, filterTemplates(templates, "nope"))
+	assert.Len(t, filterTemplates(templates, ""), 3)
 }
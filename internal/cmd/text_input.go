@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dedene/memelink-cli/internal/encoding"
+)
+
+// textStdin is the reader behind "-" text arguments (swappable in tests).
+var textStdin io.Reader = os.Stdin
+
+// errStdinReused is returned when more than one input asks for stdin.
+var errStdinReused = errors.New("stdin can only be used once ('-' argument or --text-file -)")
+
+// textSource reads "-" arguments and --text-file contents, guarding against
+// consuming stdin twice.
+type textSource struct {
+	stdinUsed bool
+}
+
+// stdin returns the full stdin contents.
+func (s *textSource) stdin() (string, error) {
+	if s.stdinUsed {
+		return "", errStdinReused
+	}
+
+	s.stdinUsed = true
+
+	data, err := io.ReadAll(textStdin)
+	if err != nil {
+		return "", fmt.Errorf("reading stdin: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// stdinLines returns the lines of stdin, which must have at least one.
+func (s *textSource) stdinLines() ([]string, error) {
+	content, err := s.stdin()
+	if err != nil {
+		return nil, err
+	}
+
+	return nonBlankLines(content, "stdin")
+}
+
+// fileLines returns the lines of path, or of stdin when path is "-". The
+// file must have at least one.
+func (s *textSource) fileLines(path string) ([]string, error) {
+	if path == "-" {
+		return s.stdinLines()
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // path is user-provided --text-file
+	if err != nil {
+		return nil, fmt.Errorf("reading text file: %w", err)
+	}
+
+	return nonBlankLines(string(data), "text file "+path)
+}
+
+// nonBlankLines splits content like splitTextLines, failing when no line
+// has text: an empty source would otherwise leave no text at all, which
+// means auto-generate.
+func nonBlankLines(content, source string) ([]string, error) {
+	lines := splitTextLines(content)
+	if !slices.ContainsFunc(lines, func(l string) bool { return strings.TrimSpace(l) != "" }) {
+		return nil, fmt.Errorf("%s has no lines", source)
+	}
+
+	return lines, nil
+}
+
+// splitTextLines splits content into normalized lines, dropping trailing
+// blank lines (files usually end with a newline).
+func splitTextLines(content string) []string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	lines := strings.Split(content, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	for i, l := range lines {
+		lines[i] = encoding.NormalizeQuotes(l)
+	}
+
+	return lines
+}
+
+// resolveText expands "-" arguments and --text-file into Template/Text so the
+// mode dispatch sees plain values. A lone "-" or a text file contributes one
+// line per input line; lines beyond the template's line count are folded into
+// its last line. A "-" mixed with other arguments is a single line.
+func (c *GenerateCmd) resolveText(ctx context.Context) error {
+	src := &textSource{}
+
+	// Auto-generate text from stdin: `echo "text" | memelink -`.
+	if c.Template == "-" && len(c.Text) == 0 && c.TextFile == "" {
+		lines, err := src.stdinLines()
+		if err != nil {
+			return err
+		}
+
+		c.Template = strings.Join(lines, " ")
+
+		return nil
+	}
+
+	split := false
+
+	switch {
+	case len(c.Text) == 1 && c.Text[0] == "-":
+		lines, err := src.stdinLines()
+		if err != nil {
+			return err
+		}
+
+		c.Text = lines
+		split = true
+	default:
+		for i, t := range c.Text {
+			if t != "-" {
+				continue
+			}
+
+			lines, err := src.stdinLines()
+			if err != nil {
+				return err
+			}
+
+			c.Text[i] = strings.Join(lines, "\n")
+		}
+	}
+
+	if c.TextFile != "" {
+		lines, err := src.fileLines(c.TextFile)
+		if err != nil {
+			return err
+		}
+
+		// No template given: the file is auto-generate text.
+		if c.Template == "" {
+			c.Template = strings.Join(lines, " ")
+
+			return nil
+		}
+
+		c.Text = append(c.Text, lines...)
+		split = true
+	}
+
+	if split && c.Template != "custom" {
+		c.Text = c.fitTemplateLines(ctx, c.Text)
+	}
+
+	return nil
+}
+
+// fitTemplateLines folds lines past the template's line count into its last
+// line. Unknown templates (lookup failure, offline cache miss) are left as-is;
+// the API reports those.
+func (c *GenerateCmd) fitTemplateLines(ctx context.Context, lines []string) []string {
+	tmpl, err := lookupTemplate(ctx, c.Template, c.Offline)
+	if err != nil || tmpl == nil || tmpl.Lines <= 0 || len(lines) <= tmpl.Lines {
+		return lines
+	}
+
+	n := tmpl.Lines
+	folded := append([]string(nil), lines[:n-1]...)
+
+	return append(folded, strings.Join(lines[n-1:], "\n"))
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withStdin swaps textStdin for the duration of a test.
+func withStdin(t *testing.T, content string) {
+	t.Helper()
+
+	orig := textStdin
+	textStdin = strings.NewReader(content)
+
+	t.Cleanup(func() { textStdin = orig })
+}
+
+// bodyCapturingServer records the last POST body and returns a fixed URL.
+func bodyCapturingServer(t *testing.T, gotBody *[]byte) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"url":"https://api.memegen.link/images/drake/a/b.jpg"}`))
+	}))
+}
+
+func TestGenerateCmd_StdinDashMixed(t *testing.T) {
+	withStdin(t, "fix: handle “quoted” subjects\n")
+
+	var gotBody []byte
+	srv := bodyCapturingServer(t, &gotBody)
+	defer srv.Close()
+
+	ctx := testCtx(t, srv.URL, false)
+	cmd := &GenerateCmd{Template: "drake", Text: []string{"-", "ship it"}}
+
+	captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, &RootFlags{})) })
+
+	var parsed map[string]any
+	require.NoError(t, json.Unmarshal(gotBody, &parsed))
+	assert.Equal(t, []any{`fix: handle "quoted" subjects`, "ship it"}, parsed["text"])
+}
+
+func TestGenerateCmd_StdinLoneDashSplitsLines(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	withStdin(t, "one\ntwo\nthree\n")
+
+	var gotBody []byte
+	srv := bodyCapturingServer(t, &gotBody)
+	defer srv.Close()
+
+	seedTemplateCache(t, srv.URL)
+
+	ctx := testCtxWithConfig(t, srv.URL)
+	cmd := &GenerateCmd{Template: "drake", Text: []string{"-"}}
+
+	captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, &RootFlags{})) })
+
+	// drake has 2 lines in the seeded cache: overflow folds into the last line.
+	var parsed map[string]any
+	require.NoError(t, json.Unmarshal(gotBody, &parsed))
+	assert.Equal(t, []any{"one", "two\nthree"}, parsed["text"])
+}
+
+func TestGenerateCmd_StdinAutoGenerate(t *testing.T) {
+	withStdin(t, "when the tests\npass first try\n")
+
+	var gotBody []byte
+	srv := bodyCapturingServer(t, &gotBody)
+	defer srv.Close()
+
+	ctx := testCtx(t, srv.URL, false)
+	cmd := &GenerateCmd{Template: "-"}
+
+	captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, &RootFlags{})) })
+
+	var parsed map[string]any
+	require.NoError(t, json.Unmarshal(gotBody, &parsed))
+	assert.Equal(t, "when the tests pass first try", parsed["text"])
+}
+
+func TestGenerateCmd_TextFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lines.txt")
+	require.NoError(t, os.WriteFile(path, []byte("top\r\nbottom\r\n\r\n"), 0o644))
+
+	var gotBody []byte
+	srv := bodyCapturingServer(t, &gotBody)
+	defer srv.Close()
+
+	// Template lookup misses (server has no template detail), so lines pass through.
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	ctx := testCtx(t, srv.URL, false)
+	cmd := &GenerateCmd{Template: "drake", GenerateFlags: GenerateFlags{TextFile: path}}
+
+	captureStdout(t, func() { require.NoError(t, cmd.Run(ctx, &RootFlags{})) })
+
+	var parsed map[string]any
+	require.NoError(t, json.Unmarshal(gotBody, &parsed))
+	assert.Equal(t, []any{"top", "bottom"}, parsed["text"])
+}
+
+func TestGenerateCmd_StdinUsedTwice(t *testing.T) {
+	withStdin(t, "text\n")
+
+	cmd := &GenerateCmd{Template: "drake", Text: []string{"-", "x"}, GenerateFlags: GenerateFlags{TextFile: "-"}}
+
+	err := cmd.Run(testCtx(t, "http://unused", false), &RootFlags{})
+	require.ErrorIs(t, err, errStdinReused)
+}
+
+func TestGenerateCmd_EmptyTextFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.txt")
+	require.NoError(t, os.WriteFile(path, []byte("\n  \n"), 0o644))
+
+	var gotBody []byte
+	srv := bodyCapturingServer(t, &gotBody)
+	defer srv.Close()
+
+	cmd := &GenerateCmd{Template: "drake", GenerateFlags: GenerateFlags{TextFile: path}}
+
+	err := cmd.Run(testCtx(t, srv.URL, false), &RootFlags{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "text file "+path+" has no lines")
+	assert.Nil(t, gotBody, "nothing is sent")
+}
+
+func TestGenerateCmd_EmptyStdin(t *testing.T) {
+	for name, cmd := range map[string]*GenerateCmd{
+		"dash":      {Template: "drake", Text: []string{"-"}},
+		"text-file": {Template: "drake", GenerateFlags: GenerateFlags{TextFile: "-"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			withStdin(t, "")
+
+			var gotBody []byte
+			srv := bodyCapturingServer(t, &gotBody)
+			defer srv.Close()
+
+			err := cmd.Run(testCtx(t, srv.URL, false), &RootFlags{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "stdin has no lines")
+			assert.Nil(t, gotBody, "nothing is sent")
+		})
+	}
+}
+
+func TestSplitTextLines(t *testing.T) {
+	assert.Equal(t, []string{"a", "", "b"}, splitTextLines("a\n\nb\n\n"))
+	assert.Equal(t, []string{"it's -- done"}, splitTextLines("it’s — done"))
+	assert.Empty(t, splitTextLines("\n"))
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dedene/memelink-cli/internal/api"
+)
+
+// maxLineChars is the per-line text limit enforced by the Memegen API
+// (it answers 414 beyond this).
+const maxLineChars = 200
+
+// forceHint is appended to validation errors that --force can bypass.
+const forceHint = " (use --force to skip validation)"
+
+// isOverlayURL reports whether a style value is an overlay image URL
+// rather than a named style.
+func isOverlayURL(style string) bool {
+	return strings.HasPrefix(style, "http://") || strings.HasPrefix(style, "https://")
+}
+
+// validateLineLengths rejects lines longer than the API limit.
+func validateLineLengths(text []string) error {
+	for i, line := range text {
+		if n := utf8.RuneCountInString(line); n > maxLineChars {
+			return fmt.Errorf("line %d is %d characters (max %d)%s", i+1, n, maxLineChars, forceHint)
+		}
+	}
+
+	return nil
+}
+
+// validateAgainstTemplate checks text lines and styles against template
+// metadata: line count, named styles, and overlay URL count.
+func validateAgainstTemplate(tmpl *api.Template, text, style []string) error {
+	if tmpl.Lines > 0 && len(text) > tmpl.Lines {
+		return fmt.Errorf("template %q takes %d lines, got %d%s", tmpl.ID, tmpl.Lines, len(text), forceHint)
+	}
+
+	overlays := 0
+
+	for _, s := range style {
+		if isOverlayURL(s) {
+			overlays++
+
+			continue
+		}
+
+		// "default" is accepted for every template.
+		if s != "default" && !containsString(tmpl.Styles, s) {
+			available := "none"
+			if len(tmpl.Styles) > 0 {
+				available = strings.Join(tmpl.Styles, ", ")
+			}
+
+			return fmt.Errorf("style %q not available for template %q (available: %s)%s", s, tmpl.ID, available, forceHint)
+		}
+	}
+
+	if overlays > tmpl.Overlays {
+		return fmt.Errorf("template %q supports %d overlay(s), got %d style URL(s)%s", tmpl.ID, tmpl.Overlays, overlays, forceHint)
+	}
+
+	return nil
+}
+
+// validate runs client-side checks before any POST. Template metadata comes
+// from the cache when fresh; a 404 from the lookup is returned with
+// suggestions, other lookup failures skip the template checks and leave them
+// to the API.
+func (c *GenerateCmd) validate(ctx context.Context) error {
+	if err := validateLineLengths(c.Text); err != nil {
+		return err
+	}
+
+	// Auto-generate and custom backgrounds have no template metadata.
+	if c.Template == "" || len(c.Text) == 0 || c.Template == "custom" {
+		return nil
+	}
+
+	tmpl, err := lookupTemplate(ctx, c.Template, c.Offline)
+	if err != nil {
+		if isNotFound(err) {
+			return withTemplateSuggestions(ctx, err, c.Template, c.Offline)
+		}
+
+		slog.Debug("skipping template validation", "template", c.Template, "error", err)
+
+		return nil
+	}
+
+	if tmpl == nil {
+		return nil
+	}
+
+	return validateAgainstTemplate(tmpl, c.Text, c.Style)
+}
+
+// containsString reports whether s is in list.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/api"
+)
+
+func TestValidateAgainstTemplate(t *testing.T) {
+	tmpl := &api.Template{ID: "drake", Lines: 2, Overlays: 1, Styles: []string{"animated"}}
+
+	tests := []struct {
+		name    string
+		text    []string
+		style   []string
+		wantErr string
+	}{
+		{name: "ok", text: []string{"a", "b"}},
+		{name: "fewer lines", text: []string{"a"}},
+		{name: "too many lines", text: []string{"a", "b", "c"}, wantErr: `template "drake" takes 2 lines, got 3`},
+		{name: "known style", text: []string{"a"}, style: []string{"animated"}},
+		{name: "default style", text: []string{"a"}, style: []string{"default"}},
+		{name: "unknown style", text: []string{"a"}, style: []string{"sparkly"}, wantErr: `style "sparkly" not available for template "drake" (available: animated)`},
+		{name: "one overlay", text: []string{"a"}, style: []string{"https://example.com/a.png"}},
+		{
+			name:    "too many overlays",
+			text:    []string{"a"},
+			style:   []string{"https://example.com/a.png", "https://example.com/b.png"},
+			wantErr: `template "drake" supports 1 overlay(s), got 2 style URL(s)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAgainstTemplate(tmpl, tt.text, tt.style)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Contains(t, err.Error(), "--force")
+		})
+	}
+}
+
+func TestValidateLineLengths(t *testing.T) {
+	require.NoError(t, validateLineLengths([]string{strings.Repeat("é", maxLineChars)}))
+
+	err := validateLineLengths([]string{"ok", strings.Repeat("x", maxLineChars+1)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2 is 201 characters (max 200)")
+}
+
+func TestGenerateCmd_ValidationFailsBeforePost(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	posts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	seedTemplateCache(t, srv.URL)
+
+	cmd := &GenerateCmd{Template: "drake", Text: []string{"a", "b", "c"}}
+	err := cmd.Run(testCtx(t, srv.URL, false), &RootFlags{})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `template "drake" takes 2 lines, got 3`)
+	assert.Equal(t, 0, posts)
+}
+
+func TestGenerateCmd_ValidationForce(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	var gotBody []byte
+	srv := bodyCapturingServer(t, &gotBody)
+	defer srv.Close()
+
+	seedTemplateCache(t, srv.URL)
+
+	cmd := &GenerateCmd{Template: "fry", Text: []string{"a"}, GenerateFlags: GenerateFlags{Style: []string{"animated"}}}
+
+	var runErr error
+	captureStdout(t, func() { runErr = cmd.Run(testCtx(t, srv.URL, false), &RootFlags{Force: true}) })
+
+	require.NoError(t, runErr)
+	assert.Contains(t, string(gotBody), "animated")
+}
+
+func TestGenerateCmd_ValidationUnknownTemplate(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	posts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	cmd := &GenerateCmd{Template: "nope", Text: []string{"a"}}
+	err := cmd.Run(testCtx(t, srv.URL, false), &RootFlags{})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "template not found")
+	assert.Equal(t, 0, posts)
+}
+
+func TestGenerateCmd_ValidationOfflineUsesCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	seedTemplateCache(t, "http://unused.invalid")
+
+	cmd := &GenerateCmd{Template: "drake", Text: []string{"a"}, GenerateFlags: GenerateFlags{Style: []string{"glitter"}, Offline: true}}
+	err := cmd.Run(testCtx(t, "http://unused.invalid", false), &RootFlags{})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `style "glitter" not available`)
+}
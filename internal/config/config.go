@@ -5,13 +5,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"net/url"
 	"os"
-	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/titanous/json5"
+
+	"github.com/dedene/memelink-cli/internal/atomicfile"
+	"github.com/dedene/memelink-cli/internal/filelock"
 )
 
 // Config holds user preferences.
@@ -24,22 +30,57 @@ type Config struct {
 	AutoOpen      *bool  `json:"auto_open,omitempty"`
 	Preview       *bool  `json:"preview,omitempty"`
 	CacheTTL      string `json:"cache_ttl,omitempty"`
+	CacheMaxStale string `json:"cache_max_stale,omitempty"`
+	OutputDir     string `json:"output_dir,omitempty"`
+	APIBaseURL    string `json:"api_base_url,omitempty"`
+
+	MaxRetries   *int     `json:"max_retries,omitempty"`
+	RetryMaxWait string   `json:"retry_max_wait,omitempty"`
+	RateLimit    *float64 `json:"rate_limit,omitempty"`
+	RateBurst    *int     `json:"rate_burst,omitempty"`
+
+	CacheTTLFonts   string `json:"cache_ttl_fonts,omitempty"`
+	CacheTTLDetails string `json:"cache_ttl_details,omitempty"`
+	ImageCacheMB    *int   `json:"image_cache_mb,omitempty"`
+
+	Uploader string `json:"uploader,omitempty"`
+
+	HistoryRetention string `json:"history_retention,omitempty"`
+
+	Presets map[string]Preset `json:"presets,omitempty"`
+
+	// origins maps keys to the file that set them; filled by LoadEffective.
+	origins map[string]string
 }
 
-// knownKey describes a config key and its optional validator.
+// knownKey describes a config key, its optional validator and an optional
+// extra environment variable read after MEMELINK_<KEY>.
 type knownKey struct {
 	validate func(string) error
+	env      string
 }
 
 var knownKeys = map[string]knownKey{
-	"default_format": {validate: validateEnum("jpg", "png", "gif", "webp")},
-	"default_font":   {validate: nil},
-	"default_layout": {validate: validateEnum("default", "top")},
-	"safe":           {validate: validateBool},
-	"auto_copy":      {validate: validateBool},
-	"auto_open":      {validate: validateBool},
-	"preview":        {validate: validateBool},
-	"cache_ttl":      {validate: validateDuration},
+	"default_format":    {validate: validateEnum("jpg", "png", "gif", "webp")},
+	"default_font":      {validate: nil},
+	"default_layout":    {validate: validateEnum("default", "top")},
+	"safe":              {validate: validateBool},
+	"auto_copy":         {validate: validateBool},
+	"auto_open":         {validate: validateBool},
+	"preview":           {validate: validateBool},
+	"cache_ttl":         {validate: validateDuration},
+	"cache_max_stale":   {validate: validateDuration},
+	"cache_ttl_fonts":   {validate: validateDuration},
+	"cache_ttl_details": {validate: validateDuration},
+	"image_cache_mb":    {validate: validateInt(0)},
+	"output_dir":        {validate: nil},
+	"api_base_url":      {validate: validateURL, env: "MEMEGEN_BASE_URL"},
+	"max_retries":       {validate: validateInt(0)},
+	"retry_max_wait":    {validate: validateDuration},
+	"rate_limit":        {validate: validateRate},
+	"rate_burst":        {validate: validateInt(1)},
+	"uploader":          {validate: nil},
+	"history_retention": {validate: validateDuration},
 }
 
 // ErrUnknownKey indicates an invalid config key.
@@ -70,10 +111,55 @@ func validateBool(val string) error {
 	return nil
 }
 
+func validateURL(val string) error {
+	u, err := url.Parse(val)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: must be an http(s) URL", ErrInvalidValue)
+	}
+
+	return nil
+}
+
+// validateInt accepts integers >= minimum.
+func validateInt(minimum int) func(string) error {
+	return func(val string) error {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < minimum {
+			return fmt.Errorf("%w: must be an integer >= %d", ErrInvalidValue, minimum)
+		}
+
+		return nil
+	}
+}
+
+// validateRate accepts a non-negative number of requests per second.
+func validateRate(val string) error {
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil || f < 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		return fmt.Errorf("%w: must be a number >= 0 (requests per second)", ErrInvalidValue)
+	}
+
+	return nil
+}
+
+// Validate checks value against the validator of a known key.
+func Validate(key, value string) error {
+	kk, ok := knownKeys[key]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, key)
+	}
+
+	if kk.validate == nil {
+		return nil
+	}
+
+	return kk.validate(value)
+}
+
 func validateDuration(val string) error {
 	_, err := time.ParseDuration(val)
 	if err != nil {
-		return fmt.Errorf("invalid duration: %w", err)
+		return fmt.Errorf("%w: invalid duration: %v", ErrInvalidValue, err)
 	}
 
 	return nil
@@ -94,6 +180,73 @@ func (cfg *Config) CacheTTLDuration() time.Duration {
 	return d
 }
 
+// CacheTTLFor returns the TTL of one cache kind: cache_ttl_<kind> when set
+// and valid, else 7 days for the rarely changing font list, else
+// CacheTTLDuration.
+func (cfg *Config) CacheTTLFor(kind string) time.Duration {
+	if val, ok := cfg.Get("cache_ttl_" + kind); ok {
+		if d, err := time.ParseDuration(val); err == nil {
+			return d
+		}
+	}
+
+	if kind == "fonts" {
+		return 7 * 24 * time.Hour
+	}
+
+	return cfg.CacheTTLDuration()
+}
+
+// CacheMaxStaleDuration parses CacheMaxStale: how long past cache_ttl
+// cached data may still be served while it is refreshed, or when the
+// refresh fails. Returns 7 days on empty or invalid values.
+func (cfg *Config) CacheMaxStaleDuration() time.Duration {
+	const def = 7 * 24 * time.Hour
+
+	if cfg.CacheMaxStale == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(cfg.CacheMaxStale)
+	if err != nil {
+		return def
+	}
+
+	return d
+}
+
+// HistoryRetentionDuration parses HistoryRetention as a time.Duration.
+// Returns 90 days on empty or invalid values; 0 turns history off.
+func (cfg *Config) HistoryRetentionDuration() time.Duration {
+	const def = 90 * 24 * time.Hour
+
+	if cfg.HistoryRetention == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(cfg.HistoryRetention)
+	if err != nil {
+		return def
+	}
+
+	return d
+}
+
+// DefaultImageCacheMB is the image cache size limit when image_cache_mb is
+// unset.
+const DefaultImageCacheMB = 256
+
+// ImageCacheBytes returns the image cache size limit in bytes. 0 disables
+// the image cache.
+func (cfg *Config) ImageCacheBytes() int64 {
+	mb := DefaultImageCacheMB
+	if cfg.ImageCacheMB != nil {
+		mb = *cfg.ImageCacheMB
+	}
+
+	return int64(mb) << 20
+}
+
 // Load reads config from the JSON5 file at path.
 // Returns an empty Config if the file does not exist.
 func Load(path string) (*Config, error) {
@@ -123,50 +276,51 @@ func Save(path string, cfg *Config) error {
 
 	data = append(data, '\n')
 
-	return atomicWrite(path, data)
+	return atomicfile.Write(path, data)
 }
 
-// atomicWrite writes data to path via temp-file + rename.
-func atomicWrite(path string, data []byte) error {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0o750); err != nil {
-		return fmt.Errorf("creating directory: %w", err)
-	}
+// updateLockWait bounds how long Update waits for another process's
+// update of the same config.
+const updateLockWait = 30 * time.Second
 
-	tmp, err := os.CreateTemp(dir, ".tmp-*")
+// Update applies fn to the config at path and saves the result while
+// holding an advisory lock on path.lock, so concurrent read-modify-write
+// cycles (e.g. parallel `config set` runs) never lose an update. Nothing is
+// saved when fn fails, or when the lock is not free within updateLockWait.
+func Update(ctx context.Context, path string, fn func(*Config) error) error {
+	lockCtx, cancel := context.WithTimeout(ctx, updateLockWait)
+	defer cancel()
+
+	lock, err := filelock.Acquire(lockCtx, path+".lock")
 	if err != nil {
-		return fmt.Errorf("creating temp file: %w", err)
+		return fmt.Errorf("updating config: %w", err)
 	}
 
-	tmpPath := tmp.Name()
-
 	defer func() {
-		if tmpPath != "" {
-			_ = os.Remove(tmpPath)
+		if err := lock.Release(); err != nil {
+			slog.Debug("config lock release", "error", err)
 		}
 	}()
 
-	if _, err := tmp.Write(data); err != nil {
-		_ = tmp.Close()
-
-		return fmt.Errorf("writing temp file: %w", err)
-	}
-
-	if err := tmp.Close(); err != nil {
-		return fmt.Errorf("closing temp file: %w", err)
+	cfg, err := Load(path)
+	if err != nil {
+		return err
 	}
 
-	if err := os.Rename(tmpPath, path); err != nil {
-		return fmt.Errorf("renaming temp file: %w", err)
+	if err := fn(cfg); err != nil {
+		return err
 	}
 
-	tmpPath = "" // prevent deferred cleanup
-
-	return nil
+	return Save(path, cfg)
 }
 
 // Get returns the string value for a config key and whether it is set.
+// Dotted keys (presets.<name>.<field>) address presets.
 func (cfg *Config) Get(key string) (string, bool) {
+	if isPresetKey(key) {
+		return cfg.getPreset(key)
+	}
+
 	switch key {
 	case "default_format":
 		return cfg.DefaultFormat, cfg.DefaultFormat != ""
@@ -200,6 +354,46 @@ func (cfg *Config) Get(key string) (string, bool) {
 		return fmt.Sprintf("%t", *cfg.Preview), true
 	case "cache_ttl":
 		return cfg.CacheTTL, cfg.CacheTTL != ""
+	case "cache_max_stale":
+		return cfg.CacheMaxStale, cfg.CacheMaxStale != ""
+	case "cache_ttl_fonts":
+		return cfg.CacheTTLFonts, cfg.CacheTTLFonts != ""
+	case "cache_ttl_details":
+		return cfg.CacheTTLDetails, cfg.CacheTTLDetails != ""
+	case "image_cache_mb":
+		if cfg.ImageCacheMB == nil {
+			return "", false
+		}
+
+		return strconv.Itoa(*cfg.ImageCacheMB), true
+	case "output_dir":
+		return cfg.OutputDir, cfg.OutputDir != ""
+	case "uploader":
+		return cfg.Uploader, cfg.Uploader != ""
+	case "history_retention":
+		return cfg.HistoryRetention, cfg.HistoryRetention != ""
+	case "api_base_url":
+		return cfg.APIBaseURL, cfg.APIBaseURL != ""
+	case "max_retries":
+		if cfg.MaxRetries == nil {
+			return "", false
+		}
+
+		return strconv.Itoa(*cfg.MaxRetries), true
+	case "retry_max_wait":
+		return cfg.RetryMaxWait, cfg.RetryMaxWait != ""
+	case "rate_limit":
+		if cfg.RateLimit == nil {
+			return "", false
+		}
+
+		return strconv.FormatFloat(*cfg.RateLimit, 'f', -1, 64), true
+	case "rate_burst":
+		if cfg.RateBurst == nil {
+			return "", false
+		}
+
+		return strconv.Itoa(*cfg.RateBurst), true
 	default:
 		return "", false
 	}
@@ -207,9 +401,13 @@ func (cfg *Config) Get(key string) (string, bool) {
 
 // Set sets a config key to a value after validation.
 func (cfg *Config) Set(key, value string) error {
+	if isPresetKey(key) {
+		return cfg.setPreset(key, value)
+	}
+
 	kk, ok := knownKeys[key]
 	if !ok {
-		return fmt.Errorf("%w: %s (valid keys: %s)", ErrUnknownKey, key, strings.Join(KnownKeys(), ", "))
+		return fmt.Errorf("%w: %s (valid keys: %s, presets.<name>.<field>)", ErrUnknownKey, key, strings.Join(KnownKeys(), ", "))
 	}
 
 	if kk.validate != nil {
@@ -218,6 +416,13 @@ func (cfg *Config) Set(key, value string) error {
 		}
 	}
 
+	cfg.assign(key, value)
+
+	return nil
+}
+
+// assign stores an already-validated value for a known key.
+func (cfg *Config) assign(key, value string) {
 	switch key {
 	case "default_format":
 		cfg.DefaultFormat = value
@@ -239,15 +444,46 @@ func (cfg *Config) Set(key, value string) error {
 		cfg.Preview = &b
 	case "cache_ttl":
 		cfg.CacheTTL = value
+	case "cache_max_stale":
+		cfg.CacheMaxStale = value
+	case "cache_ttl_fonts":
+		cfg.CacheTTLFonts = value
+	case "cache_ttl_details":
+		cfg.CacheTTLDetails = value
+	case "image_cache_mb":
+		n, _ := strconv.Atoi(value)
+		cfg.ImageCacheMB = &n
+	case "output_dir":
+		cfg.OutputDir = value
+	case "uploader":
+		cfg.Uploader = value
+	case "history_retention":
+		cfg.HistoryRetention = value
+	case "api_base_url":
+		cfg.APIBaseURL = value
+	case "max_retries":
+		n, _ := strconv.Atoi(value)
+		cfg.MaxRetries = &n
+	case "retry_max_wait":
+		cfg.RetryMaxWait = value
+	case "rate_limit":
+		f, _ := strconv.ParseFloat(value, 64)
+		cfg.RateLimit = &f
+	case "rate_burst":
+		n, _ := strconv.Atoi(value)
+		cfg.RateBurst = &n
 	}
-
-	return nil
 }
 
-// Unset removes a config key (resets to zero/nil).
+// Unset removes a config key (resets to zero/nil). "presets.<name>"
+// removes a whole preset.
 func (cfg *Config) Unset(key string) error {
+	if isPresetKey(key) {
+		return cfg.unsetPreset(key)
+	}
+
 	if _, ok := knownKeys[key]; !ok {
-		return fmt.Errorf("%w: %s (valid keys: %s)", ErrUnknownKey, key, strings.Join(KnownKeys(), ", "))
+		return fmt.Errorf("%w: %s (valid keys: %s, presets.<name>.<field>)", ErrUnknownKey, key, strings.Join(KnownKeys(), ", "))
 	}
 
 	switch key {
@@ -267,6 +503,30 @@ func (cfg *Config) Unset(key string) error {
 		cfg.Preview = nil
 	case "cache_ttl":
 		cfg.CacheTTL = ""
+	case "cache_max_stale":
+		cfg.CacheMaxStale = ""
+	case "cache_ttl_fonts":
+		cfg.CacheTTLFonts = ""
+	case "cache_ttl_details":
+		cfg.CacheTTLDetails = ""
+	case "image_cache_mb":
+		cfg.ImageCacheMB = nil
+	case "output_dir":
+		cfg.OutputDir = ""
+	case "uploader":
+		cfg.Uploader = ""
+	case "history_retention":
+		cfg.HistoryRetention = ""
+	case "api_base_url":
+		cfg.APIBaseURL = ""
+	case "max_retries":
+		cfg.MaxRetries = nil
+	case "retry_max_wait":
+		cfg.RetryMaxWait = ""
+	case "rate_limit":
+		cfg.RateLimit = nil
+	case "rate_burst":
+		cfg.RateBurst = nil
 	}
 
 	return nil
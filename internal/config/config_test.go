@@ -2,8 +2,11 @@ package config_test
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/dedene/memelink-cli/internal/config"
+	"github.com/dedene/memelink-cli/internal/filelock"
 )
 
 func TestLoadMissing(t *testing.T) {
@@ -50,6 +54,66 @@ func TestLoadSaveRoundTrip(t *testing.T) {
 	assert.Nil(t, loaded.AutoOpen)
 }
 
+func TestUpdate_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	var wg sync.WaitGroup
+
+	for i := range 16 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			assert.NoError(t, config.Update(context.Background(), path, func(cfg *config.Config) error {
+				return cfg.Set(fmt.Sprintf("presets.p%d.template", i), "drake")
+			}))
+		}()
+	}
+
+	wg.Wait()
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Len(t, cfg.Presets, 16, "no update is lost")
+}
+
+func TestUpdate_LockHeld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	lock, err := filelock.Acquire(context.Background(), path+".lock")
+	require.NoError(t, err)
+
+	defer func() { _ = lock.Release() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err = config.Update(ctx, path, func(cfg *config.Config) error {
+		return cfg.Set("default_format", "gif")
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, statErr := os.Stat(path)
+	assert.True(t, os.IsNotExist(statErr), "nothing is saved without the lock")
+}
+
+func TestUpdate_ErrorKeepsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, config.Save(path, &config.Config{DefaultFormat: "png"}))
+
+	err := config.Update(context.Background(), path, func(cfg *config.Config) error {
+		cfg.DefaultFormat = "gif"
+
+		return cfg.Set("nope", "x")
+	})
+	require.ErrorIs(t, err, config.ErrUnknownKey)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "png", cfg.DefaultFormat)
+}
+
 func TestLoadJSON5(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "config.json")
@@ -81,6 +145,10 @@ func TestGetSet(t *testing.T) {
 		{"auto_copy", "false"},
 		{"auto_open", "true"},
 		{"cache_ttl", "1h"},
+		{"output_dir", "memes"},
+		{"api_base_url", "https://memes.example.com"},
+		{"uploader", "upload-meme --public"},
+		{"history_retention", "720h"},
 	}
 
 	for _, tt := range tests {
@@ -106,6 +174,7 @@ func TestSetValidation(t *testing.T) {
 		{"safe", "yes", "must be true or false"},
 		{"auto_copy", "1", "must be true or false"},
 		{"cache_ttl", "forever", "invalid duration"},
+		{"api_base_url", "memes.example.com", "must be an http(s) URL"},
 		{"unknown_key", "foo", "unknown config key"},
 	}
 
@@ -195,19 +264,94 @@ func TestCacheTTLDuration(t *testing.T) {
 	}
 }
 
+func TestCacheMaxStaleDuration(t *testing.T) {
+	assert.Equal(t, 7*24*time.Hour, (&config.Config{}).CacheMaxStaleDuration())
+	assert.Equal(t, time.Hour, (&config.Config{CacheMaxStale: "1h"}).CacheMaxStaleDuration())
+	assert.Equal(t, time.Duration(0), (&config.Config{CacheMaxStale: "0s"}).CacheMaxStaleDuration())
+	assert.Equal(t, 7*24*time.Hour, (&config.Config{CacheMaxStale: "later"}).CacheMaxStaleDuration())
+}
+
+func TestCacheTTLFor(t *testing.T) {
+	cfg := &config.Config{CacheTTL: "2h"}
+
+	assert.Equal(t, 7*24*time.Hour, cfg.CacheTTLFor("fonts"))
+	assert.Equal(t, 2*time.Hour, cfg.CacheTTLFor("details"), "falls back to cache_ttl")
+
+	cfg.CacheTTLFonts = "1h"
+	cfg.CacheTTLDetails = "30m"
+	assert.Equal(t, time.Hour, cfg.CacheTTLFor("fonts"))
+	assert.Equal(t, 30*time.Minute, cfg.CacheTTLFor("details"))
+}
+
+func TestImageCacheBytes(t *testing.T) {
+	cfg := &config.Config{}
+	assert.Equal(t, int64(config.DefaultImageCacheMB)<<20, cfg.ImageCacheBytes())
+
+	require.NoError(t, cfg.Set("image_cache_mb", "0"))
+	assert.Equal(t, int64(0), cfg.ImageCacheBytes(), "0 disables the image cache")
+
+	require.NoError(t, cfg.Set("image_cache_mb", "10"))
+	assert.Equal(t, int64(10<<20), cfg.ImageCacheBytes())
+
+	require.Error(t, cfg.Set("image_cache_mb", "-1"))
+}
+
+func TestHistoryRetentionDuration(t *testing.T) {
+	cfg := &config.Config{}
+	assert.Equal(t, 90*24*time.Hour, cfg.HistoryRetentionDuration())
+
+	require.NoError(t, cfg.Set("history_retention", "0"))
+	assert.Zero(t, cfg.HistoryRetentionDuration(), "0 turns history off")
+
+	require.NoError(t, cfg.Set("history_retention", "720h"))
+	assert.Equal(t, 720*time.Hour, cfg.HistoryRetentionDuration())
+
+	require.Error(t, cfg.Set("history_retention", "forever"))
+}
+
 func TestKnownKeys(t *testing.T) {
 	keys := config.KnownKeys()
-	assert.Len(t, keys, 8)
+	assert.Len(t, keys, 20)
 
 	// Verify sorted
 	expected := []string{
-		"auto_copy", "auto_open", "cache_ttl",
-		"default_font", "default_format", "default_layout",
-		"preview", "safe",
+		"api_base_url", "auto_copy", "auto_open", "cache_max_stale", "cache_ttl",
+		"cache_ttl_details", "cache_ttl_fonts",
+		"default_font", "default_format", "default_layout", "history_retention", "image_cache_mb",
+		"max_retries", "output_dir", "preview", "rate_burst",
+		"rate_limit", "retry_max_wait", "safe", "uploader",
 	}
 	assert.Equal(t, expected, keys)
 }
 
+func TestConfig_RetryAndRateKeys(t *testing.T) {
+	cfg := &config.Config{}
+
+	require.NoError(t, cfg.Set("max_retries", "0"))
+	require.NoError(t, cfg.Set("retry_max_wait", "10s"))
+	require.NoError(t, cfg.Set("rate_limit", "2.5"))
+	require.NoError(t, cfg.Set("rate_burst", "4"))
+
+	require.NotNil(t, cfg.MaxRetries)
+	assert.Equal(t, 0, *cfg.MaxRetries)
+
+	val, ok := cfg.Get("rate_limit")
+	assert.True(t, ok)
+	assert.Equal(t, "2.5", val)
+
+	for key, bad := range map[string]string{
+		"max_retries":    "-1",
+		"retry_max_wait": "soon",
+		"rate_limit":     "-2",
+		"rate_burst":     "0",
+	} {
+		assert.Error(t, cfg.Set(key, bad), key)
+	}
+
+	require.NoError(t, cfg.Unset("rate_limit"))
+	assert.Nil(t, cfg.RateLimit)
+}
+
 func TestConfigPaths(t *testing.T) {
 	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
 	t.Setenv("XDG_CACHE_HOME", t.TempDir())
@@ -217,10 +361,33 @@ func TestConfigPaths(t *testing.T) {
 	assert.Contains(t, cfgPath, "memelink")
 	assert.Contains(t, cfgPath, "config.json")
 
-	cachePath, err := config.CachePath()
+	cacheDir, err := config.CacheDir()
+	require.NoError(t, err)
+	assert.Contains(t, cacheDir, "memelink")
+}
+
+func TestServerCacheDir(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+
+	root := filepath.Join(cacheHome, "memelink")
+
+	dir, err := config.ServerCacheDir("")
+	require.NoError(t, err)
+	assert.Equal(t, root, dir, "the public API uses the cache root")
+
+	local, err := config.ServerCacheDir("http://localhost:5000")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "servers"), filepath.Dir(local))
+	assert.True(t, strings.HasPrefix(filepath.Base(local), "localhost_5000-"))
+
+	other, err := config.ServerCacheDir("http://localhost:5001")
+	require.NoError(t, err)
+	assert.NotEqual(t, local, other)
+
+	images, err := config.ImageCacheDir("http://localhost:5000")
 	require.NoError(t, err)
-	assert.Contains(t, cachePath, "memelink")
-	assert.Contains(t, cachePath, "templates.json")
+	assert.Equal(t, filepath.Join(local, "images"), images)
 }
 
 func TestConfigPathsDefault(t *testing.T) {
@@ -232,10 +399,10 @@ func TestConfigPathsDefault(t *testing.T) {
 	assert.Contains(t, cfgPath, ".config")
 	assert.Contains(t, cfgPath, "memelink")
 
-	cachePath, err := config.CachePath()
+	cacheDir, err := config.CacheDir()
 	require.NoError(t, err)
-	assert.Contains(t, cachePath, ".cache")
-	assert.Contains(t, cachePath, "memelink")
+	assert.Contains(t, cacheDir, ".cache")
+	assert.Contains(t, cacheDir, "memelink")
 }
 
 func TestWithConfig_FromContext(t *testing.T) {
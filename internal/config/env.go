@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvPrefix prefixes per-key environment overrides, e.g. MEMELINK_DEFAULT_FORMAT.
+const EnvPrefix = "MEMELINK_"
+
+// EnvName returns the environment variable that overrides key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(key)
+}
+
+// applyEnv overlays MEMELINK_<KEY> variables (or a key's extra variable,
+// such as MEMEGEN_BASE_URL) onto cfg, validated like `config set`. Empty
+// variables are ignored.
+func (cfg *Config) applyEnv() error {
+	if cfg.origins == nil {
+		cfg.origins = make(map[string]string)
+	}
+
+	for _, key := range KnownKeys() {
+		name := EnvName(key)
+
+		val := os.Getenv(name)
+		if val == "" && knownKeys[key].env != "" {
+			name = knownKeys[key].env
+			val = os.Getenv(name)
+		}
+
+		if val == "" {
+			continue
+		}
+
+		if v := knownKeys[key].validate; v != nil {
+			if err := v(val); err != nil {
+				return fmt.Errorf("invalid value for %s: %w", name, err)
+			}
+		}
+
+		cfg.assign(key, val)
+		cfg.origins[key] = "env:" + name
+	}
+
+	return nil
+}
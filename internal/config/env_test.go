@@ -0,0 +1,75 @@
+package config_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/config"
+)
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "MEMELINK_DEFAULT_FORMAT", config.EnvName("default_format"))
+	assert.Equal(t, "MEMELINK_CACHE_TTL", config.EnvName("cache_ttl"))
+}
+
+func TestLoadEffective_EnvOverrides(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "config.json")
+	require.NoError(t, config.Save(userPath, &config.Config{DefaultFormat: "png", DefaultFont: "arial"}))
+
+	t.Setenv("MEMELINK_DEFAULT_FORMAT", "webp")
+	t.Setenv("MEMELINK_SAFE", "true")
+
+	cfg, err := config.LoadEffective(userPath, dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, "webp", cfg.DefaultFormat)
+	assert.Equal(t, "arial", cfg.DefaultFont)
+	require.NotNil(t, cfg.Safe)
+	assert.True(t, *cfg.Safe)
+
+	assert.Equal(t, "env:MEMELINK_DEFAULT_FORMAT", cfg.Origin("default_format"))
+	assert.Equal(t, "file:"+userPath, cfg.Origin("default_font"))
+}
+
+func TestLoadEffective_EnvValidated(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Setenv("MEMELINK_DEFAULT_LAYOUT", "sideways")
+
+	_, err := config.LoadEffective(filepath.Join(dir, "config.json"), dir)
+	require.ErrorIs(t, err, config.ErrInvalidValue)
+	assert.Contains(t, err.Error(), "MEMELINK_DEFAULT_LAYOUT")
+}
+
+func TestLoadEffective_EnvBadDuration(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Setenv("MEMELINK_CACHE_TTL", "bogus")
+
+	_, err := config.LoadEffective(filepath.Join(dir, "config.json"), dir)
+	require.ErrorIs(t, err, config.ErrInvalidValue)
+	assert.Contains(t, err.Error(), "MEMELINK_CACHE_TTL")
+}
+
+func TestLoadEffective_BaseURLEnv(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "config.json")
+	require.NoError(t, config.Save(userPath, &config.Config{APIBaseURL: "https://user.example.com"}))
+
+	t.Setenv("MEMEGEN_BASE_URL", "https://memes.internal")
+
+	cfg, err := config.LoadEffective(userPath, dir)
+	require.NoError(t, err)
+	assert.Equal(t, "https://memes.internal", cfg.APIBaseURL)
+	assert.Equal(t, "env:MEMEGEN_BASE_URL", cfg.Origin("api_base_url"))
+
+	t.Setenv("MEMELINK_API_BASE_URL", "https://other.internal")
+
+	cfg, err = config.LoadEffective(userPath, dir)
+	require.NoError(t, err)
+	assert.Equal(t, "https://other.internal", cfg.APIBaseURL, "MEMELINK_<KEY> wins over the extra variable")
+}
@@ -2,9 +2,14 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+
 )
 
 // Dir returns the memelink config directory.
@@ -24,7 +29,7 @@ func Dir() (string, error) {
 
 // CacheDir returns the memelink cache directory.
 // Respects XDG_CACHE_HOME; defaults to $HOME/.cache/memelink.
-func cacheDir() (string, error) {
+func CacheDir() (string, error) {
 	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
 		return filepath.Join(dir, "memelink"), nil
 	}
@@ -47,12 +52,60 @@ func Path() (string, error) {
 	return filepath.Join(dir, "config.json"), nil
 }
 
-// CachePath returns the full path to the template cache file.
-func CachePath() (string, error) {
-	dir, err := cacheDir()
+// ServerCacheDir returns the cache directory for the API at baseURL. The
+// public API, passed as "", uses CacheDir itself; any other
+// server gets a subdirectory named after its host and a hash of its URL,
+// so templates, validators and images from different servers never mix.
+func ServerCacheDir(baseURL string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	baseURL = strings.TrimRight(baseURL, "/")
+	if baseURL == "" {
+		return dir, nil
+	}
+
+	host := "server"
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		host = strings.NewReplacer(":", "_", "[", "", "]", "").Replace(u.Host)
+	}
+
+	sum := sha256.Sum256([]byte(baseURL))
+
+	return filepath.Join(dir, "servers", host+"-"+hex.EncodeToString(sum[:4])), nil
+}
+
+// ImageCacheDir returns the directory of the content-addressed image cache
+// (meme previews, downloads and blank template images) for the API at
+// baseURL.
+func ImageCacheDir(baseURL string) (string, error) {
+	dir, err := ServerCacheDir(baseURL)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "images"), nil
+}
+
+// HistoryPath returns the full path to the meme history log. It lives with
+// the config, not the cache, so clearing caches keeps it.
+func HistoryPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// FavoritesPath returns the full path to the favorite templates file.
+func FavoritesPath() (string, error) {
+	dir, err := Dir()
 	if err != nil {
 		return "", err
 	}
 
-	return filepath.Join(dir, "templates.json"), nil
+	return filepath.Join(dir, "favorites.json"), nil
 }
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Preset is a named bundle of generate options, invoked like a template ID
+// (`memelink standup "line"`). Explicit flags override preset values.
+type Preset struct {
+	Template   string   `json:"template,omitempty"`
+	Font       string   `json:"font,omitempty"`
+	Format     string   `json:"format,omitempty"`
+	Layout     string   `json:"layout,omitempty"`
+	TextColor  []string `json:"text_color,omitempty"`
+	Style      []string `json:"style,omitempty"`
+	Safe       *bool    `json:"safe,omitempty"`
+	Background string   `json:"background,omitempty"`
+}
+
+// presetPrefix starts dotted preset keys: presets.<name>.<field>.
+const presetPrefix = "presets."
+
+// presetFields maps preset field names to their validators. List fields
+// (text_color, style) take comma-separated values.
+var presetFields = map[string]knownKey{
+	"template":   {validate: nil},
+	"font":       {validate: nil},
+	"format":     {validate: validateEnum("jpg", "png", "gif", "webp")},
+	"layout":     {validate: validateEnum("default", "top")},
+	"text_color": {validate: nil},
+	"style":      {validate: nil},
+	"safe":       {validate: validateBool},
+	"background": {validate: nil},
+}
+
+// isPresetKey reports whether key addresses the presets section.
+func isPresetKey(key string) bool {
+	return key == "presets" || strings.HasPrefix(key, presetPrefix)
+}
+
+// parsePresetKey splits "presets.<name>[.<field>]" into name and field.
+func parsePresetKey(key string) (name, field string, err error) {
+	rest := strings.TrimPrefix(key, presetPrefix)
+	if rest == key || rest == "" {
+		return "", "", fmt.Errorf("%w: %s (expected presets.<name>.<field>)", ErrUnknownKey, key)
+	}
+
+	name, field, _ = strings.Cut(rest, ".")
+	if name == "" {
+		return "", "", fmt.Errorf("%w: %s (expected presets.<name>.<field>)", ErrUnknownKey, key)
+	}
+
+	if field != "" {
+		if _, ok := presetFields[field]; !ok {
+			return "", "", fmt.Errorf("%w: %s (valid preset fields: %s)", ErrUnknownKey, key, strings.Join(presetFieldNames(), ", "))
+		}
+	}
+
+	return name, field, nil
+}
+
+// presetFieldNames returns the sorted preset field names.
+func presetFieldNames() []string {
+	names := make([]string, 0, len(presetFields))
+	for f := range presetFields {
+		names = append(names, f)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// getPreset returns a preset field, or the whole preset as JSON when field is empty.
+func (cfg *Config) getPreset(key string) (string, bool) {
+	name, field, err := parsePresetKey(key)
+	if err != nil {
+		return "", false
+	}
+
+	p, ok := cfg.Presets[name]
+	if !ok {
+		return "", false
+	}
+
+	if field == "" {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return "", false
+		}
+
+		return string(data), true
+	}
+
+	return p.get(field)
+}
+
+// setPreset validates and sets a single preset field, creating the preset.
+func (cfg *Config) setPreset(key, value string) error {
+	name, field, err := parsePresetKey(key)
+	if err != nil {
+		return err
+	}
+
+	if field == "" {
+		return fmt.Errorf("%w: %s (set individual fields: presets.%s.<field>)", ErrUnknownKey, key, name)
+	}
+
+	if v := presetFields[field].validate; v != nil {
+		if err := v(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+	}
+
+	if cfg.Presets == nil {
+		cfg.Presets = make(map[string]Preset)
+	}
+
+	p := cfg.Presets[name]
+	p.set(field, value)
+	cfg.Presets[name] = p
+
+	return nil
+}
+
+// unsetPreset clears a preset field, or removes the whole preset when field
+// is empty. A preset left with no fields is removed.
+func (cfg *Config) unsetPreset(key string) error {
+	name, field, err := parsePresetKey(key)
+	if err != nil {
+		return err
+	}
+
+	if field == "" {
+		delete(cfg.Presets, name)
+	} else if p, ok := cfg.Presets[name]; ok {
+		p.set(field, "")
+
+		if p.isZero() {
+			delete(cfg.Presets, name)
+		} else {
+			cfg.Presets[name] = p
+		}
+	}
+
+	if len(cfg.Presets) == 0 {
+		cfg.Presets = nil
+	}
+
+	return nil
+}
+
+// PresetKeys returns the sorted dotted keys of all set preset fields.
+func (cfg *Config) PresetKeys() []string {
+	var keys []string
+
+	for name, p := range cfg.Presets {
+		for field := range presetFields {
+			if _, ok := p.get(field); ok {
+				keys = append(keys, presetPrefix+name+"."+field)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// get returns a field value as a string (lists comma-joined).
+func (p Preset) get(field string) (string, bool) {
+	switch field {
+	case "template":
+		return p.Template, p.Template != ""
+	case "font":
+		return p.Font, p.Font != ""
+	case "format":
+		return p.Format, p.Format != ""
+	case "layout":
+		return p.Layout, p.Layout != ""
+	case "text_color":
+		return strings.Join(p.TextColor, ","), len(p.TextColor) > 0
+	case "style":
+		return strings.Join(p.Style, ","), len(p.Style) > 0
+	case "safe":
+		if p.Safe == nil {
+			return "", false
+		}
+
+		return fmt.Sprintf("%t", *p.Safe), true
+	case "background":
+		return p.Background, p.Background != ""
+	default:
+		return "", false
+	}
+}
+
+// set assigns a field from its string form; an empty value clears it.
+func (p *Preset) set(field, value string) {
+	switch field {
+	case "template":
+		p.Template = value
+	case "font":
+		p.Font = value
+	case "format":
+		p.Format = value
+	case "layout":
+		p.Layout = value
+	case "text_color":
+		p.TextColor = splitList(value)
+	case "style":
+		p.Style = splitList(value)
+	case "safe":
+		if value == "" {
+			p.Safe = nil
+
+			return
+		}
+
+		b := value == boolTrue
+		p.Safe = &b
+	case "background":
+		p.Background = value
+	}
+}
+
+// isZero reports whether no field is set.
+func (p Preset) isZero() bool {
+	for field := range presetFields {
+		if _, ok := p.get(field); ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// splitList splits a comma-separated value, dropping empty items.
+func splitList(value string) []string {
+	var out []string
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,108 @@
+package config_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/config"
+)
+
+func TestPresetSetGet(t *testing.T) {
+	cfg := &config.Config{}
+	require.NoError(t, cfg.Set("presets.standup.template", "fine"))
+	require.NoError(t, cfg.Set("presets.standup.format", "png"))
+	require.NoError(t, cfg.Set("presets.standup.text_color", "white, black"))
+	require.NoError(t, cfg.Set("presets.standup.safe", "true"))
+
+	p := cfg.Presets["standup"]
+	assert.Equal(t, "fine", p.Template)
+	assert.Equal(t, "png", p.Format)
+	assert.Equal(t, []string{"white", "black"}, p.TextColor)
+	require.NotNil(t, p.Safe)
+	assert.True(t, *p.Safe)
+
+	got, ok := cfg.Get("presets.standup.text_color")
+	assert.True(t, ok)
+	assert.Equal(t, "white,black", got)
+
+	whole, ok := cfg.Get("presets.standup")
+	assert.True(t, ok)
+	assert.JSONEq(t, `{"template":"fine","format":"png","text_color":["white","black"],"safe":true}`, whole)
+
+	_, ok = cfg.Get("presets.standup.font")
+	assert.False(t, ok)
+
+	_, ok = cfg.Get("presets.nope.template")
+	assert.False(t, ok)
+}
+
+func TestPresetSetValidation(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		errRe string
+	}{
+		{"presets.standup.format", "bmp", "must be one of"},
+		{"presets.standup.layout", "bottom", "must be one of"},
+		{"presets.standup.safe", "yes", "must be true or false"},
+		{"presets.standup.colour", "red", "valid preset fields"},
+		{"presets.standup", "fine", "set individual fields"},
+		{"presets.", "x", "expected presets.<name>.<field>"},
+		{"presets", "x", "unknown config key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			cfg := &config.Config{}
+			err := cfg.Set(tt.key, tt.value)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errRe)
+		})
+	}
+}
+
+func TestPresetUnset(t *testing.T) {
+	cfg := &config.Config{}
+	require.NoError(t, cfg.Set("presets.standup.template", "fine"))
+	require.NoError(t, cfg.Set("presets.standup.font", "impact"))
+	require.NoError(t, cfg.Set("presets.retro.template", "drake"))
+
+	require.NoError(t, cfg.Unset("presets.standup.font"))
+	assert.Equal(t, config.Preset{Template: "fine"}, cfg.Presets["standup"])
+
+	// Clearing the last field removes the preset.
+	require.NoError(t, cfg.Unset("presets.standup.template"))
+	assert.NotContains(t, cfg.Presets, "standup")
+
+	require.NoError(t, cfg.Unset("presets.retro"))
+	assert.Nil(t, cfg.Presets)
+}
+
+func TestPresetKeys(t *testing.T) {
+	cfg := &config.Config{}
+	require.NoError(t, cfg.Set("presets.standup.template", "fine"))
+	require.NoError(t, cfg.Set("presets.standup.font", "impact"))
+	require.NoError(t, cfg.Set("presets.arch.template", "drake"))
+
+	assert.Equal(t, []string{
+		"presets.arch.template",
+		"presets.standup.font",
+		"presets.standup.template",
+	}, cfg.PresetKeys())
+}
+
+func TestPresetLoadSaveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	cfg := &config.Config{}
+	require.NoError(t, cfg.Set("presets.standup.template", "fine"))
+	require.NoError(t, cfg.Set("presets.standup.style", "animated"))
+	require.NoError(t, config.Save(path, cfg))
+
+	loaded, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Presets, loaded.Presets)
+}
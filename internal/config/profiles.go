@@ -0,0 +1,268 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dedene/memelink-cli/internal/atomicfile"
+)
+
+// DefaultProfile is the profile stored in the top-level config.json.
+const DefaultProfile = "default"
+
+// ProfileEnv selects the active profile when --profile is not given.
+const ProfileEnv = "MEMELINK_PROFILE"
+
+// ErrInvalidProfile indicates a profile name that cannot be used as a file name.
+var ErrInvalidProfile = errors.New("invalid profile name")
+
+// ErrUnknownProfile indicates a profile with no config file.
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// ErrProfileExists indicates a copy target that already exists.
+var ErrProfileExists = errors.New("profile already exists")
+
+var profileNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ValidateProfileName checks that name is usable as a profile file name.
+func ValidateProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("%w: %q (use letters, digits, '-' and '_')", ErrInvalidProfile, name)
+	}
+
+	return nil
+}
+
+// profilesDir returns the directory holding non-default profiles.
+func profilesDir() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "profiles"), nil
+}
+
+// activeProfilePath returns the file recording the profile chosen by
+// `config profiles use`.
+func activeProfilePath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "active_profile"), nil
+}
+
+// ProfilePath returns the config file for a profile: config.json for the
+// default profile, profiles/<name>.json otherwise.
+func ProfilePath(name string) (string, error) {
+	if name == "" || name == DefaultProfile {
+		return Path()
+	}
+
+	if err := ValidateProfileName(name); err != nil {
+		return "", err
+	}
+
+	dir, err := profilesDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// ActiveProfile resolves the profile to use.
+// Cascade: flag > MEMELINK_PROFILE > `profiles use` selection > default.
+// A non-default profile must exist unless allowNew is set, so a mistyped
+// name fails instead of running with an empty config.
+func ActiveProfile(flag string, allowNew bool) (string, error) {
+	name := flag
+	if name == "" {
+		name = os.Getenv(ProfileEnv)
+	}
+
+	if name == "" {
+		path, err := activeProfilePath()
+		if err != nil {
+			return "", err
+		}
+
+		data, err := os.ReadFile(path) //nolint:gosec // path is internal config state
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("reading active profile: %w", err)
+		}
+
+		name = strings.TrimSpace(string(data))
+	}
+
+	if name == "" {
+		return DefaultProfile, nil
+	}
+
+	if name != DefaultProfile {
+		if err := ValidateProfileName(name); err != nil {
+			return "", err
+		}
+	}
+
+	if !allowNew {
+		if err := requireProfile(name); err != nil {
+			return "", fmt.Errorf("%w (create it with 'memelink --profile %s config set <key> <value>')", err, name)
+		}
+	}
+
+	return name, nil
+}
+
+// UseProfile records name as the active profile. The profile must exist.
+func UseProfile(name string) error {
+	if err := requireProfile(name); err != nil {
+		return err
+	}
+
+	path, err := activeProfilePath()
+	if err != nil {
+		return err
+	}
+
+	if name == DefaultProfile {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("clearing active profile: %w", err)
+		}
+
+		return nil
+	}
+
+	return atomicfile.Write(path, []byte(name+"\n"))
+}
+
+// ListProfiles returns all profile names, sorted, with default first.
+func ListProfiles() ([]string, error) {
+	dir, err := profilesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading profiles: %w", err)
+	}
+
+	var names []string
+
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() || ValidateProfileName(name) != nil || name == DefaultProfile {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return append([]string{DefaultProfile}, names...), nil
+}
+
+// CopyProfile copies the config of src into a new profile dst.
+func CopyProfile(src, dst string) error {
+	if err := requireProfile(src); err != nil {
+		return err
+	}
+
+	dstPath, err := ProfilePath(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dstPath); err == nil {
+		return fmt.Errorf("%w: %s", ErrProfileExists, dst)
+	}
+
+	srcPath, err := ProfilePath(src)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := Load(srcPath)
+	if err != nil {
+		return err
+	}
+
+	return Save(dstPath, cfg)
+}
+
+// DeleteProfile removes a non-default profile. Deleting the active profile
+// switches back to the default one.
+func DeleteProfile(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("%w: the default profile cannot be deleted", ErrInvalidProfile)
+	}
+
+	if err := requireProfile(name); err != nil {
+		return err
+	}
+
+	path, err := ProfilePath(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("deleting profile: %w", err)
+	}
+
+	activePath, err := activeProfilePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(activePath) //nolint:gosec // path is internal config state
+	if err == nil && strings.TrimSpace(string(data)) == name {
+		return UseProfile(DefaultProfile)
+	}
+
+	return nil
+}
+
+// requireProfile returns ErrUnknownProfile when a non-default profile has no file.
+func requireProfile(name string) error {
+	if name == DefaultProfile {
+		return nil
+	}
+
+	path, err := ProfilePath(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("%w: %s", ErrUnknownProfile, name)
+	}
+
+	return nil
+}
+
+type profileCtxKey struct{}
+
+// WithProfile stores the active profile name in the context.
+func WithProfile(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, profileCtxKey{}, name)
+}
+
+// ProfileFromContext returns the active profile name, or DefaultProfile.
+func ProfileFromContext(ctx context.Context) string {
+	if name, ok := ctx.Value(profileCtxKey{}).(string); ok && name != "" {
+		return name
+	}
+
+	return DefaultProfile
+}
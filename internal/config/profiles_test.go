@@ -0,0 +1,136 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/config"
+)
+
+func setupProfiles(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv(config.ProfileEnv, "")
+
+	return filepath.Join(dir, "memelink")
+}
+
+func TestProfilePath(t *testing.T) {
+	dir := setupProfiles(t)
+
+	p, err := config.ProfilePath(config.DefaultProfile)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "config.json"), p)
+
+	p, err = config.ProfilePath("work")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "profiles", "work.json"), p)
+
+	_, err = config.ProfilePath("../etc")
+	require.ErrorIs(t, err, config.ErrInvalidProfile)
+}
+
+func TestActiveProfileCascade(t *testing.T) {
+	dir := setupProfiles(t)
+
+	name, err := config.ActiveProfile("", false)
+	require.NoError(t, err)
+	assert.Equal(t, config.DefaultProfile, name)
+
+	require.NoError(t, config.Save(filepath.Join(dir, "profiles", "work.json"), &config.Config{}))
+	require.NoError(t, config.UseProfile("work"))
+
+	name, err = config.ActiveProfile("", false)
+	require.NoError(t, err)
+	assert.Equal(t, "work", name, "profiles use selection")
+
+	t.Setenv(config.ProfileEnv, "home")
+
+	name, err = config.ActiveProfile("", true)
+	require.NoError(t, err)
+	assert.Equal(t, "home", name, "env beats selection")
+
+	name, err = config.ActiveProfile("ci", true)
+	require.NoError(t, err)
+	assert.Equal(t, "ci", name, "flag beats env")
+}
+
+func TestActiveProfileUnknown(t *testing.T) {
+	dir := setupProfiles(t)
+
+	_, err := config.ActiveProfile("wrok", false)
+	require.ErrorIs(t, err, config.ErrUnknownProfile)
+	assert.Contains(t, err.Error(), "memelink --profile wrok config set")
+
+	t.Setenv(config.ProfileEnv, "wrok")
+
+	_, err = config.ActiveProfile("", false)
+	require.ErrorIs(t, err, config.ErrUnknownProfile)
+
+	name, err := config.ActiveProfile("", true)
+	require.NoError(t, err)
+	assert.Equal(t, "wrok", name, "allowed when creating the profile")
+
+	require.NoError(t, config.Save(filepath.Join(dir, "profiles", "wrok.json"), &config.Config{}))
+
+	name, err = config.ActiveProfile("", false)
+	require.NoError(t, err)
+	assert.Equal(t, "wrok", name)
+}
+
+func TestUseProfileUnknown(t *testing.T) {
+	setupProfiles(t)
+
+	require.ErrorIs(t, config.UseProfile("nope"), config.ErrUnknownProfile)
+	require.NoError(t, config.UseProfile(config.DefaultProfile))
+}
+
+func TestListCopyDeleteProfiles(t *testing.T) {
+	setupProfiles(t)
+
+	tr := true
+	workPath, err := config.ProfilePath("work")
+	require.NoError(t, err)
+	require.NoError(t, config.Save(workPath, &config.Config{Safe: &tr}))
+
+	require.NoError(t, config.CopyProfile("work", "home"))
+	require.ErrorIs(t, config.CopyProfile("work", "home"), config.ErrProfileExists)
+	require.ErrorIs(t, config.CopyProfile("nope", "other"), config.ErrUnknownProfile)
+
+	homePath, err := config.ProfilePath("home")
+	require.NoError(t, err)
+
+	home, err := config.Load(homePath)
+	require.NoError(t, err)
+	require.NotNil(t, home.Safe)
+	assert.True(t, *home.Safe)
+
+	names, err := config.ListProfiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "home", "work"}, names)
+
+	require.NoError(t, config.UseProfile("work"))
+	require.NoError(t, config.DeleteProfile("work"))
+
+	_, err = os.Stat(workPath)
+	assert.True(t, os.IsNotExist(err))
+
+	name, err := config.ActiveProfile("", false)
+	require.NoError(t, err)
+	assert.Equal(t, config.DefaultProfile, name, "deleting the active profile resets to default")
+
+	require.ErrorIs(t, config.DeleteProfile(config.DefaultProfile), config.ErrInvalidProfile)
+	require.ErrorIs(t, config.DeleteProfile("work"), config.ErrUnknownProfile)
+}
+
+func TestProfileContext(t *testing.T) {
+	assert.Equal(t, config.DefaultProfile, config.ProfileFromContext(context.Background()))
+	assert.Equal(t, "work", config.ProfileFromContext(config.WithProfile(context.Background(), "work")))
+}
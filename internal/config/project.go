@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ProjectFileName is the project-local config discovered from the working
+// directory upwards, like .editorconfig.
+const ProjectFileName = ".memelink.json5"
+
+// FindProjectFile walks up from dir looking for ProjectFileName.
+// Returns "" when none is found before the filesystem root.
+func FindProjectFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("resolving directory: %w", err)
+	}
+
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return path, nil
+		}
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("checking %s: %w", path, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
+
+// LoadEffective loads the user config at userPath, merges the nearest
+// project config found from dir over it, then applies MEMELINK_<KEY>
+// environment overrides. A relative output_dir in the project file is
+// resolved against the project directory, and a project uploader or
+// api_base_url is ignored. Each set key remembers where it came from (see Origin).
+func LoadEffective(userPath, dir string) (*Config, error) {
+	user, err := Load(userPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	cfg.merge(user, "file:"+userPath)
+
+	projectPath, err := FindProjectFile(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if projectPath != "" {
+		project, err := Load(projectPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", projectPath, err)
+		}
+
+		if project.OutputDir != "" && !filepath.IsAbs(project.OutputDir) {
+			project.OutputDir = filepath.Join(filepath.Dir(projectPath), project.OutputDir)
+		}
+
+		// The uploader runs a command, and the API base URL receives the
+		// API key, so a checked-out project must not choose either.
+		for _, key := range []string{"uploader", "api_base_url"} {
+			if _, ok := project.Get(key); ok {
+				fmt.Fprintf(os.Stderr, "warning: %s: ignoring %s; set it with 'memelink config set %s'\n", projectPath, key, key)
+
+				_ = project.Unset(key)
+			}
+		}
+
+		cfg.merge(project, "file:"+projectPath)
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// merge copies every key set in src over cfg, recording origin for each.
+// Presets merge by name; a preset in src replaces one with the same name.
+func (cfg *Config) merge(src *Config, origin string) {
+	if cfg.origins == nil {
+		cfg.origins = make(map[string]string)
+	}
+
+	for key := range knownKeys {
+		if val, ok := src.Get(key); ok {
+			cfg.assign(key, val)
+			cfg.origins[key] = origin
+		}
+	}
+
+	for name, p := range src.Presets {
+		if cfg.Presets == nil {
+			cfg.Presets = make(map[string]Preset)
+		}
+
+		cfg.Presets[name] = p
+		cfg.origins[presetPrefix+name] = origin
+	}
+}
+
+// Origin returns where key was set in the effective config ("file:<path>"
+// or "env:<VAR>"), or "" when the key is unset or the config was not built
+// by LoadEffective. Preset fields report the file that defined the preset.
+func (cfg *Config) Origin(key string) string {
+	if origin, ok := cfg.origins[key]; ok {
+		return origin
+	}
+
+	if isPresetKey(key) {
+		if name, _, err := parsePresetKey(key); err == nil {
+			return cfg.origins[presetPrefix+name]
+		}
+	}
+
+	return ""
+}
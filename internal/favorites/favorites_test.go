@@ -0,0 +1,91 @@
+package favorites
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/filelock"
+)
+
+func TestAddRemoveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "favorites.json")
+	ctx := context.Background()
+
+	favs, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, favs, "no file means no favorites")
+
+	favs, err = Add(ctx, path, "drake", "buzz")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"drake", "buzz"}, favs)
+
+	favs, err = Add(ctx, path, "buzz", "fry")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"drake", "buzz", "fry"}, favs, "duplicates are ignored, order kept")
+
+	favs, err = Remove(ctx, path, "buzz", "nope")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"drake", "fry"}, favs)
+
+	favs, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"drake", "fry"}, favs)
+}
+
+func TestLoad_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "favorites.json")
+	require.NoError(t, os.WriteFile(path, []byte("{nope"), 0o600))
+
+	_, err := Load(path)
+	require.ErrorContains(t, err, "parsing favorites")
+
+	_, err = Add(context.Background(), path, "drake")
+	require.Error(t, err, "a corrupt file is not overwritten")
+}
+
+func TestAdd_LockHeld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "favorites.json")
+
+	lock, err := filelock.Acquire(context.Background(), path+".lock")
+	require.NoError(t, err)
+
+	defer func() { _ = lock.Release() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = Add(ctx, path, "drake")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "favorites file is locked")
+	assert.NoFileExists(t, path)
+}
+
+func TestAdd_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "favorites.json")
+
+	var wg sync.WaitGroup
+
+	for _, id := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := Add(context.Background(), path, id)
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	favs, err := Load(path)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d", "e", "f", "g", "h"}, favs)
+}
@@ -0,0 +1,77 @@
+// Package filelock provides advisory inter-process locks on lock files, so
+// concurrent memelink processes (parallel CI jobs, batch runs) serialize
+// config updates and cache refreshes.
+package filelock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// pollInterval is how often Acquire retries a held lock.
+const pollInterval = 25 * time.Millisecond
+
+// Lock is a held advisory lock. Locks are per open file, so two goroutines
+// of one process exclude each other just like two processes do.
+type Lock struct {
+	f *os.File
+}
+
+// Acquire blocks until it holds an exclusive lock on path, creating the
+// file and its directory when missing, or until ctx is done. The lock is
+// released by Release or when the process exits.
+func Acquire(ctx context.Context, path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("creating lock directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600) //nolint:gosec // lock file next to our own data
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file: %w", err)
+	}
+
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			_ = f.Close()
+
+			return nil, fmt.Errorf("locking %s: %w", path, err)
+		}
+
+		if ok {
+			return &Lock{f: f}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+
+			return nil, fmt.Errorf("waiting for lock %s: %w", path, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Release unlocks and closes the lock file. The file itself is kept, so
+// waiters never race a deleted lock.
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+
+	err := unlock(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+
+	l.f = nil
+
+	if err != nil {
+		return fmt.Errorf("releasing lock: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package filelock
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquire_Exclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "x.lock")
+
+	first, err := Acquire(context.Background(), path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = Acquire(ctx, path)
+	require.ErrorIs(t, err, context.DeadlineExceeded, "a held lock blocks other holders")
+
+	require.NoError(t, first.Release())
+
+	second, err := Acquire(context.Background(), path)
+	require.NoError(t, err)
+	require.NoError(t, second.Release())
+}
+
+func TestAcquire_Serializes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x.lock")
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		inside  int
+		maxSeen int
+	)
+
+	for range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			l, err := Acquire(context.Background(), path)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			mu.Lock()
+			inside++
+			maxSeen = max(maxSeen, inside)
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			inside--
+			mu.Unlock()
+
+			assert.NoError(t, l.Release())
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, 1, maxSeen)
+}
+
+func TestRelease_Nil(t *testing.T) {
+	var l *Lock
+	require.NoError(t, l.Release())
+
+	l, err := Acquire(context.Background(), filepath.Join(t.TempDir(), "x.lock"))
+	require.NoError(t, err)
+	require.NoError(t, l.Release())
+	require.NoError(t, l.Release(), "double release is harmless")
+}
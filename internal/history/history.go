@@ -0,0 +1,414 @@
+// Package history keeps an append-only JSONL log of generated memes, so
+// past memes can be listed, searched and generated again.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dedene/memelink-cli/internal/atomicfile"
+	"github.com/dedene/memelink-cli/internal/filelock"
+)
+
+// Generation modes recorded in Entry.Mode.
+const (
+	ModeAutomatic = "automatic"
+	ModeTemplate  = "template"
+	ModeCustom    = "custom"
+)
+
+// ErrNotFound indicates a history ID that is not in the log.
+var ErrNotFound = errors.New("history entry not found")
+
+// Entry is one generated meme.
+type Entry struct {
+	ID       int       `json:"id"`
+	Time     time.Time `json:"time"`
+	Mode     string    `json:"mode"`
+	Template string    `json:"template,omitempty"`
+	// Text holds the text lines, or the prompt in automatic mode.
+	Text  []string `json:"text,omitempty"`
+	Flags Flags    `json:"flags"`
+	// URL is the meme URL; Path the written file for local renders.
+	URL        string  `json:"url,omitempty"`
+	Path       string  `json:"path,omitempty"`
+	Generator  string  `json:"generator,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// Flags are the generation options needed to run an entry again.
+type Flags struct {
+	Format     string   `json:"format,omitempty"`
+	Font       string   `json:"font,omitempty"`
+	Layout     string   `json:"layout,omitempty"`
+	TextColor  []string `json:"text_color,omitempty"`
+	Style      []string `json:"style,omitempty"`
+	Width      int      `json:"width,omitempty"`
+	Height     int      `json:"height,omitempty"`
+	Center     string   `json:"center,omitempty"`
+	Scale      string   `json:"scale,omitempty"`
+	Safe       bool     `json:"safe,omitempty"`
+	Background string   `json:"background,omitempty"`
+	Offline    bool     `json:"offline,omitempty"`
+	Render     string   `json:"render,omitempty"`
+	FromFrame  int      `json:"from_frame,omitempty"`
+	ToFrame    int      `json:"to_frame,omitempty"`
+}
+
+// lockWait bounds how long a write waits for another process's write of
+// the same log.
+const lockWait = 30 * time.Second
+
+// tailSize is how much of the log's end Append reads to number an entry.
+const tailSize = 64 * 1024
+
+// Append records e in the log at path, numbering it after the newest entry
+// and stamping the current time when e has none. It appends one line; only
+// when the oldest entry is past retention is the log rewritten without the
+// expired entries. Retention 0 keeps everything. An advisory lock on
+// path.lock serializes concurrent writers. Returns the stored entry.
+func Append(ctx context.Context, path string, e Entry, retention time.Duration) (Entry, error) {
+	lock, err := acquire(ctx, path)
+	if err != nil {
+		return e, err
+	}
+
+	defer func() {
+		if err := lock.Release(); err != nil {
+			slog.Debug("history lock release", "error", err)
+		}
+	}()
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	ends, err := readEnds(path)
+	if err != nil {
+		return e, err
+	}
+
+	e.ID = 1
+	if ends.newest != nil {
+		e.ID = ends.newest.ID + 1
+	}
+
+	if retention > 0 && ends.oldest != nil && !ends.oldest.Time.After(e.Time.Add(-retention)) {
+		entries, err := Load(path)
+		if err != nil {
+			return e, err
+		}
+
+		return e, rewrite(path, append(prune(entries, e.Time, retention), e))
+	}
+
+	line, err := marshal(e)
+	if err != nil {
+		return e, err
+	}
+
+	// Finish a line cut short by an interrupted write, so it is skipped
+	// on its own instead of taking the new entry with it.
+	if !ends.complete {
+		line = append([]byte{'\n'}, line...)
+	}
+
+	return e, appendLine(path, line)
+}
+
+// appendLine appends line to the log at path, creating it when missing.
+func appendLine(path string, line []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("creating history directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600) //nolint:gosec // history file under the config dir
+	if err != nil {
+		return fmt.Errorf("opening history: %w", err)
+	}
+
+	if _, err := f.Write(line); err != nil {
+		_ = f.Close()
+
+		return fmt.Errorf("writing history: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing history: %w", err)
+	}
+
+	return nil
+}
+
+// acquire takes the advisory lock on path.lock, waiting at most lockWait.
+func acquire(ctx context.Context, path string) (*filelock.Lock, error) {
+	ctx, cancel := context.WithTimeout(ctx, lockWait)
+	defer cancel()
+
+	lock, err := filelock.Acquire(ctx, path+".lock")
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("history file is locked: %w", err)
+	}
+
+	return lock, err
+}
+
+// logEnds describes the ends of a log: its oldest and newest entries, nil
+// when it has none, and whether its last line is complete.
+type logEnds struct {
+	oldest, newest *Entry
+	complete       bool
+}
+
+// readEnds reads the ends of the log at path without decoding all of it:
+// the first entry from its head, the newest from its last tailSize bytes
+// (the whole log only when those hold none). A missing log has no entries.
+func readEnds(path string) (logEnds, error) {
+	f, err := os.Open(path) //nolint:gosec // history file under the config dir
+	if errors.Is(err, os.ErrNotExist) {
+		return logEnds{complete: true}, nil
+	}
+
+	if err != nil {
+		return logEnds{}, fmt.Errorf("reading history: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return logEnds{}, fmt.Errorf("reading history: %w", err)
+	}
+
+	size := info.Size()
+	if size == 0 {
+		return logEnds{complete: true}, nil
+	}
+
+	var ends logEnds
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	for ends.oldest == nil && sc.Scan() {
+		ends.oldest = parseLine(sc.Bytes())
+	}
+
+	if err := sc.Err(); err != nil {
+		return logEnds{}, fmt.Errorf("reading history: %w", err)
+	}
+
+	off := max(0, size-tailSize)
+
+	buf := make([]byte, size-off)
+	if _, err := f.ReadAt(buf, off); err != nil && !errors.Is(err, io.EOF) {
+		return logEnds{}, fmt.Errorf("reading history: %w", err)
+	}
+
+	ends.complete = buf[len(buf)-1] == '\n'
+
+	lines := bytes.Split(buf, []byte("\n"))
+	if off > 0 {
+		lines = lines[1:] // likely starts mid-line
+	}
+
+	for i := len(lines) - 1; i >= 0 && ends.newest == nil; i-- {
+		ends.newest = parseLine(lines[i])
+	}
+
+	if ends.newest == nil && ends.oldest != nil {
+		entries, err := Load(path)
+		if err != nil {
+			return logEnds{}, err
+		}
+
+		ends.newest = &entries[len(entries)-1]
+	}
+
+	return ends, nil
+}
+
+// parseLine decodes one log line, returning nil for blank or bad lines.
+func parseLine(line []byte) *Entry {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return nil
+	}
+
+	var e Entry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return nil
+	}
+
+	return &e
+}
+
+// Load reads all entries, oldest first. A missing log is empty. Lines that
+// do not parse (e.g. a write cut short) are skipped.
+func Load(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // history file under the config dir
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+
+	var entries []Entry
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	for n := 1; sc.Scan(); n++ {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			slog.Debug("skipping history line", "line", n, "error", err)
+
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Find returns the entry with the given ID.
+func Find(entries []Entry, id int) (Entry, error) {
+	for _, e := range entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+
+	return Entry{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+}
+
+// Search returns the entries whose template, text, URL, path or generator
+// contain query, case-insensitively, keeping their order.
+func Search(entries []Entry, query string) []Entry {
+	query = strings.ToLower(query)
+
+	var matches []Entry
+
+	for _, e := range entries {
+		fields := append([]string{e.Template, e.URL, e.Path, e.Generator}, e.Text...)
+
+		for _, f := range fields {
+			if strings.Contains(strings.ToLower(f), query) {
+				matches = append(matches, e)
+
+				break
+			}
+		}
+	}
+
+	return matches
+}
+
+// TemplateUses counts the entries per template ID. Automatic and custom
+// memes name no reusable template and are not counted.
+func TemplateUses(entries []Entry) map[string]int {
+	uses := make(map[string]int)
+
+	for _, e := range entries {
+		if e.Mode == ModeTemplate && e.Template != "" {
+			uses[e.Template]++
+		}
+	}
+
+	return uses
+}
+
+// Clear deletes the log and returns how many entries it held.
+func Clear(ctx context.Context, path string) (int, error) {
+	lock, err := acquire(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if err := lock.Release(); err != nil {
+			slog.Debug("history lock release", "error", err)
+		}
+	}()
+
+	entries, err := Load(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("clearing history: %w", err)
+	}
+
+	return len(entries), nil
+}
+
+// prune returns the entries recorded within retention of now.
+func prune(entries []Entry, now time.Time, retention time.Duration) []Entry {
+	if retention <= 0 {
+		return entries
+	}
+
+	cutoff := now.Add(-retention)
+	kept := entries[:0:0]
+
+	for _, e := range entries {
+		if e.Time.After(cutoff) {
+			kept = append(kept, e)
+		}
+	}
+
+	return kept
+}
+
+// marshal encodes e as one log line. URLs stay readable: & and friends are
+// not HTML-escaped.
+func marshal(e Entry) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(e); err != nil {
+		return nil, fmt.Errorf("marshaling history entry: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// rewrite replaces the log with entries atomically.
+func rewrite(path string, entries []Entry) error {
+	var buf bytes.Buffer
+
+	for _, e := range entries {
+		line, err := marshal(e)
+		if err != nil {
+			return err
+		}
+
+		buf.Write(line)
+	}
+
+	return atomicfile.Write(path, buf.Bytes())
+}
@@ -0,0 +1,220 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/filelock"
+)
+
+func TestAppendLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "history.jsonl")
+	ctx := context.Background()
+
+	entries, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "a missing log is empty")
+
+	first, err := Append(ctx, path, Entry{
+		Mode:     ModeTemplate,
+		Template: "drake",
+		Text:     []string{"a", "b"},
+		Flags:    Flags{Format: "png", Style: []string{"default"}},
+		URL:      "https://api.memegen.link/images/drake/a/b.png?width=100&height=50",
+	}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.ID)
+	assert.False(t, first.Time.IsZero())
+
+	second, err := Append(ctx, path, Entry{Mode: ModeAutomatic, Text: []string{"prompt"}, Generator: "Pattern", Confidence: 0.5}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, second.ID)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"), "one line per entry")
+	assert.Contains(t, string(data), "?width=100&height=50", "URLs are not HTML-escaped")
+
+	entries, err = Load(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "drake", entries[0].Template)
+	assert.Equal(t, Flags{Format: "png", Style: []string{"default"}}, entries[0].Flags)
+	assert.Equal(t, "Pattern", entries[1].Generator)
+}
+
+func TestLoad_SkipsBadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"id\":1,\"mode\":\"template\"}\n\n{\"id\":2,\"mo"), 0o600))
+
+	entries, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	e, err := Append(context.Background(), path, Entry{Mode: ModeCustom}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, e.ID)
+
+	entries, err = Load(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "the cut-short line does not swallow the new entry")
+	assert.Equal(t, ModeCustom, entries[1].Mode)
+}
+
+func TestAppend_Retention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, age := range []time.Duration{72 * time.Hour, 36 * time.Hour, time.Hour} {
+		_, err := Append(ctx, path, Entry{Mode: ModeTemplate, Time: now.Add(-age)}, 0)
+		require.NoError(t, err)
+	}
+
+	e, err := Append(ctx, path, Entry{Mode: ModeTemplate, Time: now}, 48*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 4, e.ID, "IDs keep counting after pruning")
+
+	entries, err := Load(path)
+	require.NoError(t, err)
+
+	ids := make([]int, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+
+	assert.Equal(t, []int{2, 3, 4}, ids, "entries older than the retention are dropped")
+}
+
+func TestAppend_AppendsInPlace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	ctx := context.Background()
+
+	_, err := Append(ctx, path, Entry{Mode: ModeTemplate}, 48*time.Hour)
+	require.NoError(t, err)
+
+	before, err := os.Stat(path)
+	require.NoError(t, err)
+
+	_, err = Append(ctx, path, Entry{Mode: ModeTemplate}, 48*time.Hour)
+	require.NoError(t, err)
+
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(before, after), "nothing expired, so the log is not rewritten")
+}
+
+func TestAppend_LockHeld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	lock, err := filelock.Acquire(context.Background(), path+".lock")
+	require.NoError(t, err)
+
+	defer func() { _ = lock.Release() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = Append(ctx, path, Entry{Mode: ModeTemplate}, 0)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "history file is locked")
+	assert.NoFileExists(t, path)
+}
+
+func TestAppend_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	var wg sync.WaitGroup
+
+	for range 20 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := Append(context.Background(), path, Entry{Mode: ModeTemplate}, 0)
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	entries, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 20)
+
+	seen := make(map[int]bool)
+	for _, e := range entries {
+		seen[e.ID] = true
+	}
+
+	assert.Len(t, seen, 20, "every entry gets its own ID")
+}
+
+func TestFindSearch(t *testing.T) {
+	entries := []Entry{
+		{ID: 1, Template: "drake", Text: []string{"Tabs", "Spaces"}},
+		{ID: 2, Template: "buzz", Text: []string{"memes", "memes everywhere"}},
+		{ID: 3, Mode: ModeAutomatic, Text: []string{"I like spaces"}, Generator: "Pattern"},
+	}
+
+	e, err := Find(entries, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "buzz", e.Template)
+
+	_, err = Find(entries, 9)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	ids := func(es []Entry) []int {
+		out := []int{}
+		for _, e := range es {
+			out = append(out, e.ID)
+		}
+
+		return out
+	}
+
+	assert.Equal(t, []int{1, 3}, ids(Search(entries, "SPACES")))
+	assert.Equal(t, []int{2}, ids(Search(entries, "buzz")))
+	assert.Equal(t, []int{3}, ids(Search(entries, "pattern")))
+	assert.Empty(t, Search(entries, "nothing"))
+}
+
+func TestTemplateUses(t *testing.T) {
+	uses := TemplateUses([]Entry{
+		{Mode: ModeTemplate, Template: "drake"},
+		{Mode: ModeTemplate, Template: "buzz"},
+		{Mode: ModeTemplate, Template: "drake"},
+		{Mode: ModeCustom, Template: "custom"},
+		{Mode: ModeAutomatic, Text: []string{"drake"}},
+	})
+
+	assert.Equal(t, map[string]int{"drake": 2, "buzz": 1}, uses)
+}
+
+func TestClear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	ctx := context.Background()
+
+	n, err := Clear(ctx, path)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	for range 3 {
+		_, err := Append(ctx, path, Entry{Mode: ModeTemplate}, 0)
+		require.NoError(t, err)
+	}
+
+	n, err = Clear(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.NoFileExists(t, path)
+}
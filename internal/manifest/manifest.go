@@ -0,0 +1,211 @@
+// Package manifest reads batch generation manifests in CSV, JSONL and YAML.
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Row describes a single meme to generate. An empty Template means
+// auto-generate from the joined Text.
+type Row struct {
+	Template   string   `json:"template" yaml:"template"`
+	Text       []string `json:"text" yaml:"text"`
+	Font       string   `json:"font,omitempty" yaml:"font"`
+	Format     string   `json:"format,omitempty" yaml:"format"`
+	Style      []string `json:"style,omitempty" yaml:"style"`
+	Background string   `json:"background,omitempty" yaml:"background"`
+	Output     string   `json:"output,omitempty" yaml:"output"`
+}
+
+// Format identifies a manifest encoding.
+type Format string
+
+// Supported manifest formats.
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+	FormatYAML  Format = "yaml"
+)
+
+// ErrUnknownFormat indicates a manifest file extension that is not supported.
+var ErrUnknownFormat = errors.New("unknown manifest format")
+
+// ErrUnknownColumn indicates a CSV header column that does not map to a Row field.
+var ErrUnknownColumn = errors.New("unknown manifest column")
+
+// DetectFormat picks the manifest format from the file extension.
+func DetectFormat(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	default:
+		return "", fmt.Errorf("%w: %s (expected .csv, .jsonl or .yaml)", ErrUnknownFormat, path)
+	}
+}
+
+// Load reads and parses the manifest at path, detecting its format.
+func Load(path string) ([]Row, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path) //nolint:gosec // path is a user-provided manifest
+	if err != nil {
+		return nil, fmt.Errorf("opening manifest: %w", err)
+	}
+	defer f.Close()
+
+	return Parse(f, format)
+}
+
+// Parse decodes manifest rows from r in the given format.
+func Parse(r io.Reader, format Format) ([]Row, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatJSONL:
+		return parseJSONL(r)
+	case FormatYAML:
+		return parseYAML(r)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// parseCSV reads a CSV manifest with a header row. The "text" and "style"
+// columns may repeat; each occurrence contributes one value.
+func parseCSV(r io.Reader) ([]Row, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading csv header: %w", err)
+	}
+
+	for i, col := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(col))
+
+		switch header[i] {
+		case "template", "text", "font", "format", "style", "background", "output":
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnknownColumn, col)
+		}
+	}
+
+	var rows []Row
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("reading csv: %w", err)
+		}
+
+		var row Row
+
+		for i, val := range record {
+			if i >= len(header) {
+				break
+			}
+
+			switch header[i] {
+			case "template":
+				row.Template = val
+			case "text":
+				row.Text = append(row.Text, val)
+			case "font":
+				row.Font = val
+			case "format":
+				row.Format = val
+			case "style":
+				if val != "" {
+					row.Style = append(row.Style, val)
+				}
+			case "background":
+				row.Background = val
+			case "output":
+				row.Output = val
+			}
+		}
+
+		// Rows with fewer lines than text columns leave trailing cells empty.
+		for len(row.Text) > 0 && row.Text[len(row.Text)-1] == "" {
+			row.Text = row.Text[:len(row.Text)-1]
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseJSONL reads one JSON object per line, skipping blank lines.
+func parseJSONL(r io.Reader) ([]Row, error) {
+	var rows []Row
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var row Row
+		if err := json.Unmarshal(line, &row); err != nil {
+			return nil, fmt.Errorf("parsing jsonl line %d: %w", lineNo, err)
+		}
+
+		rows = append(rows, row)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading jsonl: %w", err)
+	}
+
+	return rows, nil
+}
+
+// parseYAML reads a top-level sequence of rows.
+func parseYAML(r io.Reader) ([]Row, error) {
+	var rows []Row
+
+	if err := yaml.NewDecoder(r).Decode(&rows); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("parsing yaml: %w", err)
+	}
+
+	return rows, nil
+}
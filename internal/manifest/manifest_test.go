@@ -0,0 +1,117 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		path string
+		want Format
+	}{
+		{"memes.csv", FormatCSV},
+		{"memes.jsonl", FormatJSONL},
+		{"memes.ndjson", FormatJSONL},
+		{"memes.yaml", FormatYAML},
+		{"MEMES.YML", FormatYAML},
+	}
+
+	for _, tt := range tests {
+		got, err := DetectFormat(tt.path)
+		require.NoError(t, err, tt.path)
+		assert.Equal(t, tt.want, got, tt.path)
+	}
+
+	_, err := DetectFormat("memes.txt")
+	require.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestParseCSV(t *testing.T) {
+	in := `template,text,text,font,format,style,output
+drake,tabs,spaces,impact,png,,drake.png
+buzz,only one,,,,animated,
+,when the build passes,,,,,
+`
+
+	rows, err := Parse(strings.NewReader(in), FormatCSV)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	assert.Equal(t, Row{
+		Template: "drake",
+		Text:     []string{"tabs", "spaces"},
+		Font:     "impact",
+		Format:   "png",
+		Output:   "drake.png",
+	}, rows[0])
+	assert.Equal(t, []string{"only one"}, rows[1].Text, "trailing empty text cells trimmed")
+	assert.Equal(t, []string{"animated"}, rows[1].Style)
+	assert.Empty(t, rows[2].Template)
+}
+
+func TestParseCSV_UnknownColumn(t *testing.T) {
+	_, err := Parse(strings.NewReader("template,caption\ndrake,hi\n"), FormatCSV)
+	require.ErrorIs(t, err, ErrUnknownColumn)
+}
+
+func TestParseJSONL(t *testing.T) {
+	in := `{"template":"drake","text":["a","b"],"format":"gif","style":["animated"]}
+
+{"template":"custom","text":["hello"],"background":"https://example.com/bg.jpg"}
+`
+
+	rows, err := Parse(strings.NewReader(in), FormatJSONL)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "gif", rows[0].Format)
+	assert.Equal(t, []string{"animated"}, rows[0].Style)
+	assert.Equal(t, "https://example.com/bg.jpg", rows[1].Background)
+}
+
+func TestParseJSONL_ReportsLine(t *testing.T) {
+	_, err := Parse(strings.NewReader("{\"template\":\"drake\"}\n{oops\n"), FormatJSONL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+func TestParseYAML(t *testing.T) {
+	in := `
+- template: drake
+  text: [tabs, spaces]
+  output: out/drake.jpg
+- template: fry
+  text:
+    - not sure if
+    - or
+  font: comic
+`
+
+	rows, err := Parse(strings.NewReader(in), FormatYAML)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "out/drake.jpg", rows[0].Output)
+	assert.Equal(t, []string{"not sure if", "or"}, rows[1].Text)
+	assert.Equal(t, "comic", rows[1].Font)
+}
+
+func TestParseYAML_Empty(t *testing.T) {
+	rows, err := Parse(strings.NewReader(""), FormatYAML)
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memes.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"template":"drake","text":["a","b"]}`+"\n"), 0o644))
+
+	rows, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "drake", rows[0].Template)
+}
@@ -0,0 +1,51 @@
+package preview
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Blocks renders img as text that fits in width x height character cells,
+// keeping its aspect ratio. Each cell is an upper half block whose
+// foreground and background 24-bit colors are two stacked pixels. Unlike
+// the graphics protocols Show uses, the result is plain text, so it can be
+// laid out next to other text, e.g. in the TUI.
+func Blocks(img image.Image, width, height int) string {
+	b := img.Bounds()
+	if width <= 0 || height <= 0 || b.Dx() <= 0 || b.Dy() <= 0 {
+		return ""
+	}
+
+	// A cell is about twice as tall as it is wide, so it holds two square
+	// pixels: the pixel grid is width x 2*height.
+	w, h := width, width*b.Dy()/b.Dx()
+	if h > 2*height {
+		w, h = max(1, 2*height*b.Dx()/b.Dy()), 2*height
+	}
+
+	h = max(2, h+h%2)
+
+	px := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.ApproxBiLinear.Scale(px, px.Bounds(), img, b, xdraw.Src, nil)
+
+	var sb strings.Builder
+
+	for y := 0; y < h; y += 2 {
+		if y > 0 {
+			sb.WriteByte('\n')
+		}
+
+		for x := range w {
+			top, bottom := px.RGBAAt(x, y), px.RGBAAt(x, y+1)
+			fmt.Fprintf(&sb, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀",
+				top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+		}
+
+		sb.WriteString("\x1b[0m")
+	}
+
+	return sb.String()
+}
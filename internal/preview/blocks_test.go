@@ -0,0 +1,39 @@
+package preview
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlocks_FitsBox(t *testing.T) {
+	wide := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	tall := image.NewRGBA(image.Rect(0, 0, 100, 400))
+
+	out := Blocks(wide, 20, 20)
+	assert.Equal(t, 20, lipgloss.Width(out), "wide images use the full width")
+	assert.Equal(t, 5, lipgloss.Height(out), "2:1 at 20 cells is 20x10 pixels, 5 rows")
+
+	out = Blocks(tall, 20, 10)
+	assert.Equal(t, 10, lipgloss.Height(out), "tall images use the full height")
+	assert.Equal(t, 5, lipgloss.Width(out))
+
+	assert.Empty(t, Blocks(wide, 0, 10))
+	assert.Empty(t, Blocks(image.NewRGBA(image.Rect(0, 0, 0, 0)), 10, 10))
+}
+
+func TestBlocks_Colors(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	draw.Draw(img, image.Rect(0, 0, 2, 1), image.NewUniform(color.RGBA{R: 255, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 1, 2, 2), image.NewUniform(color.RGBA{B: 255, A: 255}), image.Point{}, draw.Src)
+
+	out := Blocks(img, 2, 1)
+
+	assert.Equal(t, 2, strings.Count(out, "\x1b[38;2;255;0;0m\x1b[48;2;0;0;255m▀"), "red over blue in each cell")
+	assert.True(t, strings.HasSuffix(out, "\x1b[0m"))
+}
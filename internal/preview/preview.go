@@ -15,6 +15,8 @@ import (
 
 	termimg "github.com/blacktop/go-termimg"
 	"golang.org/x/term"
+
+	"github.com/dedene/memelink-cli/internal/cache"
 )
 
 // Options configures image preview rendering.
@@ -23,6 +25,8 @@ type Options struct {
 	Width int
 	// Writer receives rendered escape sequences. Typically os.Stderr.
 	Writer io.Writer
+	// Images, when set, serves and stores the image in the disk cache.
+	Images *cache.Images
 }
 
 // Show downloads an image from imageURL and renders it to opts.Writer.
@@ -31,24 +35,36 @@ func Show(ctx context.Context, imageURL string, opts Options) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	body, err := open(ctx, imageURL, opts.Images)
 	if err != nil {
 		return nil
 	}
+	defer body.Close()
 
-	resp, err := http.DefaultClient.Do(req)
+	show(body, opts)
+
+	return nil
+}
+
+// ShowFile renders the image file at path, such as a locally rendered
+// meme, to opts.Writer. Like Show, it returns nil on any error.
+func ShowFile(path string, opts Options) error {
+	f, err := os.Open(path) //nolint:gosec // path is our own output file
 	if err != nil {
 		return nil
 	}
-	defer resp.Body.Close()
+	defer f.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil
-	}
+	show(f, opts)
+
+	return nil
+}
 
-	img, err := termimg.From(resp.Body)
+// show decodes and renders an image, silently giving up on any error.
+func show(r io.Reader, opts Options) {
+	img, err := termimg.From(r)
 	if err != nil {
-		return nil
+		return
 	}
 
 	const (
@@ -69,10 +85,34 @@ func Show(ctx context.Context, imageURL string, opts Options) error {
 
 	rendered, err := img.Width(width).Scale(termimg.ScaleFit).Render()
 	if err != nil {
-		return nil
+		return
 	}
 
 	fmt.Fprintln(opts.Writer, rendered)
+}
 
-	return nil
+// open returns the image body, from images when set, else straight from
+// the network.
+func open(ctx context.Context, imageURL string, images *cache.Images) (io.ReadCloser, error) {
+	if images != nil {
+		return images.Open(ctx, imageURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+
+		return nil, fmt.Errorf("fetching preview: HTTP %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
 }
@@ -6,11 +6,17 @@ import (
 	"image"
 	"image/color"
 	"image/png"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/cache"
 )
 
 // tiny1x1PNG generates a valid 1x1 red PNG in memory.
@@ -46,6 +52,42 @@ func TestShow_Success(t *testing.T) {
 	assert.NotEmpty(t, out.Bytes(), "expected rendered output")
 }
 
+func TestShow_ImageCache(t *testing.T) {
+	data := tiny1x1PNG(t)
+	calls := 0
+	images := cache.NewImages(t.TempDir(), 1<<20, func(_ context.Context, _ string, w io.Writer) error {
+		calls++
+		_, err := w.Write(data)
+
+		return err
+	})
+
+	for range 2 {
+		var out bytes.Buffer
+		assert.NoError(t, Show(context.Background(), "https://example.test/a.png", Options{
+			Width:  40,
+			Writer: &out,
+			Images: images,
+		}))
+		assert.NotEmpty(t, out.Bytes(), "expected rendered output")
+	}
+
+	assert.Equal(t, 1, calls, "second preview is served from the cache")
+}
+
+func TestShowFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "meme.png")
+	require.NoError(t, os.WriteFile(path, tiny1x1PNG(t), 0o600))
+
+	var out bytes.Buffer
+	assert.NoError(t, ShowFile(path, Options{Width: 40, Writer: &out}))
+	assert.NotEmpty(t, out.Bytes(), "expected rendered output")
+
+	out.Reset()
+	assert.NoError(t, ShowFile(filepath.Join(t.TempDir(), "missing.png"), Options{Width: 40, Writer: &out}))
+	assert.Empty(t, out.Bytes())
+}
+
 func TestShow_HTTPError(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
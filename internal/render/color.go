@@ -0,0 +1,34 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/colornames"
+)
+
+// ParseColor parses a text color as Memegen accepts it: an SVG color name
+// ("white", "hotpink") or hex RGB with an optional leading '#' ("#f00",
+// "ff0000").
+func ParseColor(s string) (color.Color, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+
+	if c, ok := colornames.Map[name]; ok {
+		return c, nil
+	}
+
+	hex := strings.TrimPrefix(name, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	if len(hex) == 6 {
+		if v, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid color %q: use a color name or hex like #ff0000", s)
+}
@@ -0,0 +1,282 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"sort"
+)
+
+// ErrFrameRange indicates a caption frame range outside the animation.
+var ErrFrameRange = errors.New("invalid frame range")
+
+// DecodeGIF reads every frame of a GIF background.
+func DecodeGIF(r io.Reader) (*gif.GIF, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decoding gif: %w", err)
+	}
+
+	return g, nil
+}
+
+// EncodeGIF writes an animation made by RenderGIF.
+func EncodeGIF(w io.Writer, g *gif.GIF) error {
+	if err := gif.EncodeAll(w, g); err != nil {
+		return fmt.Errorf("encoding gif: %w", err)
+	}
+
+	return nil
+}
+
+// RenderGIF draws the text lines onto every frame of src in
+// opts.FromFrame..opts.ToFrame and returns the new animation. Frames are
+// composited per their disposal methods first, so partial frames get the
+// whole caption, and each output frame is a full frame that keeps its
+// source delay. The loop count is kept as well.
+func RenderGIF(src *gif.GIF, opts Options) (*gif.GIF, error) {
+	n := len(src.Image)
+	if n == 0 {
+		return nil, errors.New("gif has no frames")
+	}
+
+	from, to, err := frameRange(n, opts.FromFrame, opts.ToFrame)
+	if err != nil {
+		return nil, err
+	}
+
+	screen := screenBounds(src)
+	width, height := scaledSize(screen, opts.Width, opts.Height)
+
+	// The caption is the same on every frame: draw it once, transparent
+	// everywhere else, and lay it over each frame.
+	overlay := image.NewRGBA(image.Rect(0, 0, width, height))
+	if err := drawText(overlay, opts); err != nil {
+		return nil, err
+	}
+
+	captionColors, err := captionPalette(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &gif.GIF{
+		Image:     make([]*image.Paletted, n),
+		Delay:     make([]int, n),
+		Disposal:  make([]byte, n),
+		LoopCount: src.LoopCount,
+	}
+
+	cur := image.NewRGBA(screen)
+
+	for i, fr := range src.Image {
+		var disposal byte
+		if i < len(src.Disposal) {
+			disposal = src.Disposal[i]
+		}
+
+		var prev *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			prev = image.NewRGBA(screen)
+			draw.Draw(prev, screen, cur, screen.Min, draw.Src)
+		}
+
+		draw.Draw(cur, fr.Bounds(), fr, fr.Bounds().Min, draw.Over)
+
+		frame := canvas(cur, width, height)
+
+		var extra []color.Color
+		if i+1 >= from && i+1 <= to {
+			draw.Draw(frame, frame.Bounds(), overlay, image.Point{}, draw.Over)
+
+			extra = captionColors
+		}
+
+		out.Image[i], out.Disposal[i] = quantize(frame, fr.Palette, extra)
+
+		if i < len(src.Delay) {
+			out.Delay[i] = src.Delay[i]
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(cur, fr.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			cur = prev
+		}
+	}
+
+	return out, nil
+}
+
+// frameRange resolves the 1-based, inclusive caption range for n frames.
+func frameRange(n, from, to int) (int, int, error) {
+	if from == 0 {
+		from = 1
+	}
+
+	if to == 0 {
+		to = n
+	}
+
+	if from < 1 || to < from || to > n {
+		return 0, 0, fmt.Errorf("%w: frames %d to %d of a %d-frame gif", ErrFrameRange, from, to, n)
+	}
+
+	return from, to, nil
+}
+
+// screenBounds is the GIF's logical screen, or the union of its frames when
+// the header leaves it empty.
+func screenBounds(g *gif.GIF) image.Rectangle {
+	if g.Config.Width > 0 && g.Config.Height > 0 {
+		return image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	}
+
+	var b image.Rectangle
+	for _, fr := range g.Image {
+		b = b.Union(fr.Bounds())
+	}
+
+	return image.Rect(0, 0, b.Max.X, b.Max.Y)
+}
+
+// captionPalette lists the colors the caption is drawn in: each line's
+// fill and its outline.
+func captionPalette(opts Options) ([]color.Color, error) {
+	fills, err := lineColors(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	colors := make([]color.Color, 0, 2*len(fills))
+	for _, fill := range fills {
+		colors = append(colors, fill, outlineColor(fill))
+	}
+
+	return colors, nil
+}
+
+// quantize maps img onto the source frame's palette. Colors in extra that
+// the palette lacks are added, replacing the least-used entries when the
+// palette is full, so the caption keeps its colors. Pixels under half
+// opacity become transparent, and such frames are disposed to the
+// background so the next frame does not show through.
+func quantize(img *image.RGBA, base color.Palette, extra []color.Color) (*image.Paletted, byte) {
+	transparent := false
+
+	for i := 3; i < len(img.Pix); i += 4 {
+		if img.Pix[i] < 0x80 {
+			transparent = true
+
+			break
+		}
+	}
+
+	pal := append(color.Palette{}, base...)
+	if transparent {
+		extra = append(extra, color.RGBA{})
+	}
+
+	var missing []color.Color
+
+	for _, c := range extra {
+		if !hasColor(pal, c) && !hasColor(missing, c) {
+			missing = append(missing, c)
+		}
+	}
+
+	if over := len(pal) + len(missing) - 256; over > 0 {
+		for k, idx := range leastUsed(img, pal, over) {
+			pal[idx] = missing[k]
+		}
+
+		missing = missing[over:]
+	}
+
+	pal = append(pal, missing...)
+
+	dst := image.NewPaletted(img.Bounds(), pal)
+	transparentIndex := uint8(0)
+
+	if transparent {
+		transparentIndex = uint8(pal.Index(color.RGBA{})) //nolint:gosec // palette has at most 256 entries
+	}
+
+	indexOf := nearest(pal)
+
+	for y := 0; y < img.Rect.Dy(); y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+4*img.Rect.Dx()]
+		for x := 0; x < len(row); x += 4 {
+			idx := transparentIndex
+			if row[x+3] >= 0x80 {
+				idx = indexOf(color.RGBA{row[x], row[x+1], row[x+2], 0xff})
+			}
+
+			dst.Pix[y*dst.Stride+x/4] = idx
+		}
+	}
+
+	disposal := byte(gif.DisposalNone)
+	if transparent {
+		disposal = gif.DisposalBackground
+	}
+
+	return dst, disposal
+}
+
+// leastUsed returns the indices of the n palette entries fewest opaque
+// pixels of img map to.
+func leastUsed(img *image.RGBA, pal color.Palette, n int) []int {
+	counts := make([]int, len(pal))
+	indexOf := nearest(pal)
+
+	for i := 0; i < len(img.Pix); i += 4 {
+		if img.Pix[i+3] >= 0x80 {
+			counts[indexOf(color.RGBA{img.Pix[i], img.Pix[i+1], img.Pix[i+2], 0xff})]++
+		}
+	}
+
+	order := make([]int, len(pal))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool { return counts[order[a]] < counts[order[b]] })
+
+	return order[:n]
+}
+
+// nearest returns a memoized pal.Index; frames repeat few colors, and
+// Index scans the whole palette.
+func nearest(pal color.Palette) func(color.RGBA) uint8 {
+	memo := make(map[color.RGBA]uint8)
+
+	return func(c color.RGBA) uint8 {
+		idx, ok := memo[c]
+		if !ok {
+			idx = uint8(pal.Index(c)) //nolint:gosec // palette has at most 256 entries
+			memo[c] = idx
+		}
+
+		return idx
+	}
+}
+
+// hasColor reports whether pal holds exactly c.
+func hasColor(pal []color.Color, c color.Color) bool {
+	r, g, b, a := c.RGBA()
+
+	for _, p := range pal {
+		pr, pg, pb, pa := p.RGBA()
+		if pr == r && pg == g && pb == b && pa == a {
+			return true
+		}
+	}
+
+	return false
+}
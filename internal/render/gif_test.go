@@ -0,0 +1,153 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	red   = color.RGBA{255, 0, 0, 255}
+	green = color.RGBA{0, 255, 0, 255}
+	blue  = color.RGBA{0, 0, 255, 255}
+)
+
+// testPalette has no white, so captions must add their own colors.
+var testPalette = color.Palette{red, green, blue, color.RGBA{}}
+
+// filled returns a frame covering r in one palette color.
+func filled(r image.Rectangle, c color.Color) *image.Paletted {
+	img := image.NewPaletted(r, testPalette)
+	idx := uint8(testPalette.Index(c))
+
+	for i := range img.Pix {
+		img.Pix[i] = idx
+	}
+
+	return img
+}
+
+// testGIF is a 120x60 animation: a red frame, a green square over it that
+// is undone afterwards (DisposalPrevious), and a blue frame.
+func testGIF() *gif.GIF {
+	screen := image.Rect(0, 0, 120, 60)
+
+	return &gif.GIF{
+		Image: []*image.Paletted{
+			filled(screen, red),
+			filled(image.Rect(10, 10, 30, 30), green),
+			filled(image.Rect(0, 0, 60, 60), blue),
+		},
+		Delay:     []int{10, 20, 30},
+		Disposal:  []byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalNone},
+		LoopCount: 0,
+		Config:    image.Config{Width: 120, Height: 60},
+	}
+}
+
+// roundTrip encodes and decodes g, as a viewer would see it.
+func roundTrip(t *testing.T, g *gif.GIF) *gif.GIF {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeGIF(&buf, g))
+
+	out, err := DecodeGIF(&buf)
+	require.NoError(t, err)
+
+	return out
+}
+
+// countColor counts pixels of img equal to c.
+func countColor(img image.Image, c color.Color) int {
+	want := color.RGBAModel.Convert(c)
+	n := 0
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.RGBAModel.Convert(img.At(x, y)) == want {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+func TestRenderGIF(t *testing.T) {
+	out, err := RenderGIF(testGIF(), Options{Lines: []string{"every", "frame"}})
+	require.NoError(t, err)
+
+	got := roundTrip(t, out)
+	require.Len(t, got.Image, 3)
+	assert.Equal(t, []int{10, 20, 30}, got.Delay, "timing kept")
+	assert.Equal(t, 0, got.LoopCount)
+
+	for i, fr := range got.Image {
+		assert.Equal(t, image.Rect(0, 0, 120, 60), fr.Bounds(), "frame %d is a full frame", i+1)
+		assert.Positive(t, countColor(fr, color.White), "frame %d has the caption", i+1)
+		assert.Equal(t, byte(gif.DisposalNone), got.Disposal[i])
+	}
+
+	// The green square sits on the red frame, then DisposalPrevious
+	// restores red before the blue frame covers the left half.
+	assert.Equal(t, green, got.Image[1].At(20, 20))
+	assert.Equal(t, blue, got.Image[2].At(20, 30))
+	assert.Equal(t, red, got.Image[2].At(90, 30))
+}
+
+func TestRenderGIF_FrameRange(t *testing.T) {
+	out, err := RenderGIF(testGIF(), Options{Lines: []string{"only", "two"}, FromFrame: 2, ToFrame: 2})
+	require.NoError(t, err)
+
+	assert.Zero(t, countColor(out.Image[0], color.White), "frame 1 is uncaptioned")
+	assert.Positive(t, countColor(out.Image[1], color.White))
+	assert.Zero(t, countColor(out.Image[2], color.White), "frame 3 is uncaptioned")
+
+	for _, tt := range []struct{ from, to int }{{0, 4}, {3, 2}, {-1, 0}, {4, 0}} {
+		_, err := RenderGIF(testGIF(), Options{FromFrame: tt.from, ToFrame: tt.to})
+		require.ErrorIs(t, err, ErrFrameRange, "%d-%d", tt.from, tt.to)
+	}
+}
+
+func TestRenderGIF_ResizeAndTransparency(t *testing.T) {
+	src := testGIF()
+	src.Image[0] = filled(src.Image[0].Bounds(), color.RGBA{})
+
+	out, err := RenderGIF(src, Options{Lines: []string{"a"}, Width: 60})
+	require.NoError(t, err)
+
+	got := roundTrip(t, out)
+	assert.Equal(t, image.Rect(0, 0, 60, 30), got.Image[0].Bounds())
+	assert.Equal(t, byte(gif.DisposalBackground), got.Disposal[0], "transparent frames must not show through")
+
+	_, _, _, a := got.Image[0].At(50, 25).RGBA()
+	assert.Zero(t, a)
+}
+
+func TestQuantize_FullPalette(t *testing.T) {
+	// A full palette of grays with no pure white or yellow.
+	pal := make(color.Palette, 256)
+	for i := range pal {
+		v := uint8(i / 2)
+		pal[i] = color.RGBA{v, v, v, 255}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	for x := range 4 {
+		img.Set(x, 0, color.RGBA{0, 0, 0, 255})
+	}
+
+	img.Set(0, 0, color.RGBA{255, 255, 0, 255})
+
+	dst, _ := quantize(img, pal, []color.Color{color.RGBA{255, 255, 0, 255}})
+	require.Len(t, dst.Palette, 256)
+	assert.Equal(t, color.RGBA{255, 255, 0, 255}, dst.At(0, 0), "least-used entry makes room for the caption color")
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, dst.At(1, 0))
+}
@@ -0,0 +1,415 @@
+// Package render draws meme text onto template images locally, so memes
+// can be made without the Memegen API: outlined bold text, auto-fitted to
+// the image, in the default and top layouts, on still images or on every
+// frame of a GIF.
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"sync"
+
+	// Register decoders for template blanks and backgrounds.
+	_ "image/gif"
+
+	_ "golang.org/x/image/webp"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Layouts, matching the Memegen layout names.
+const (
+	LayoutDefault = "default" // first line at the top, last at the bottom
+	LayoutTop     = "top"     // all lines stacked from the top
+)
+
+// Font size bounds in pixels. Text is also capped at 1/maxSizeDivisor of
+// the image height, so short lines stay meme-sized instead of filling
+// their band.
+const (
+	minFontSize    = 10
+	maxFontSize    = 200
+	maxSizeDivisor = 7
+)
+
+// ErrFormat indicates an output format the local renderer cannot encode.
+var ErrFormat = errors.New("unsupported local render format")
+
+// Options configures a render.
+type Options struct {
+	// Lines are the text lines, in template order. Empty lines are skipped
+	// but keep their slot in the layout.
+	Lines []string
+	// Colors are per-line text colors (names or hex); missing entries are
+	// white.
+	Colors []string
+	// Layout is LayoutDefault or LayoutTop; "" means LayoutDefault.
+	Layout string
+	// Width and Height resize the background first. With only one set, the
+	// other keeps the aspect ratio. 0 keeps the original size.
+	Width  int
+	Height int
+	// FromFrame and ToFrame limit the caption of an animation (RenderGIF)
+	// to frames FromFrame through ToFrame, counted from 1. 0 means the
+	// first and last frame respectively.
+	FromFrame int
+	ToFrame   int
+}
+
+// boldFont is the parsed built-in typeface, loaded on first use.
+var boldFont = sync.OnceValues(func() (*opentype.Font, error) {
+	return opentype.Parse(gobold.TTF)
+})
+
+// Decode reads a background image (jpg, png, gif or webp).
+func Decode(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+
+	return img, nil
+}
+
+// Render returns a copy of bg, resized per opts, with the text lines drawn.
+func Render(bg image.Image, opts Options) (*image.RGBA, error) {
+	dst := canvas(bg, opts.Width, opts.Height)
+
+	if err := drawText(dst, opts); err != nil {
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+// drawText draws opts.Lines onto dst in opts.Layout.
+func drawText(dst *image.RGBA, opts Options) error {
+	layout := opts.Layout
+	if layout == "" {
+		layout = LayoutDefault
+	}
+
+	if layout != LayoutDefault && layout != LayoutTop {
+		return fmt.Errorf("invalid layout %q: must be one of default, top", layout)
+	}
+
+	fills, err := lineColors(opts)
+	if err != nil {
+		return err
+	}
+
+	f, err := boldFont()
+	if err != nil {
+		return fmt.Errorf("loading font: %w", err)
+	}
+
+	boxes := textBoxes(dst.Bounds(), len(opts.Lines), layout)
+
+	for i, line := range opts.Lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if err := drawLine(dst, f, boxes[i], line, fills[i], dst.Bounds().Dy()/maxSizeDivisor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lineColors parses the fill color of each line, white by default.
+func lineColors(opts Options) ([]color.Color, error) {
+	fills := make([]color.Color, len(opts.Lines))
+
+	for i := range opts.Lines {
+		fills[i] = color.White
+
+		if i < len(opts.Colors) && opts.Colors[i] != "" {
+			c, err := ParseColor(opts.Colors[i])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+
+			fills[i] = c
+		}
+	}
+
+	return fills, nil
+}
+
+// Encode writes img as jpg or png.
+func Encode(w io.Writer, img image.Image, format string) error {
+	var err error
+
+	switch format {
+	case "jpg", "jpeg":
+		err = jpeg.Encode(w, img, &jpeg.Options{Quality: 90})
+	case "png":
+		err = png.Encode(w, img)
+	default:
+		return fmt.Errorf("%w %q: must be one of jpg, png", ErrFormat, format)
+	}
+
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", format, err)
+	}
+
+	return nil
+}
+
+// canvas draws bg onto a new RGBA image, scaled to width x height when
+// either is set.
+func canvas(bg image.Image, width, height int) *image.RGBA {
+	b := bg.Bounds()
+	width, height = scaledSize(b, width, height)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if width == b.Dx() && height == b.Dy() {
+		draw.Draw(dst, dst.Bounds(), bg, b.Min, draw.Src)
+	} else {
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), bg, b, draw.Src, nil)
+	}
+
+	return dst
+}
+
+// scaledSize is the output size for b: width x height, with a missing side
+// keeping the aspect ratio and both missing keeping b's size.
+func scaledSize(b image.Rectangle, width, height int) (int, int) {
+	switch {
+	case width > 0 && height <= 0:
+		height = max(1, b.Dy()*width/b.Dx())
+	case height > 0 && width <= 0:
+		width = max(1, b.Dx()*height/b.Dy())
+	case width <= 0 && height <= 0:
+		width, height = b.Dx(), b.Dy()
+	}
+
+	return width, height
+}
+
+// align is the vertical placement of text inside its box.
+type align int
+
+const (
+	alignTop align = iota
+	alignMiddle
+	alignBottom
+)
+
+// textBox is the area one line of text is fitted into.
+type textBox struct {
+	rect  image.Rectangle
+	align align
+}
+
+// textBoxes splits bounds into n boxes. The default layout gives each line
+// an equal horizontal band, pinning the first to the top edge and the last
+// to the bottom edge; the top layout stacks bands of at most a quarter of
+// the height from the top.
+func textBoxes(bounds image.Rectangle, n int, layout string) []textBox {
+	if n == 0 {
+		return nil
+	}
+
+	margin := max(4, bounds.Dx()/40)
+	inner := bounds.Inset(margin)
+	boxes := make([]textBox, n)
+
+	// Bands split the height exactly; the top layout caps them at a quarter.
+	height := inner.Dy()
+	if layout == LayoutTop {
+		height = min(height, n*inner.Dy()/4)
+	}
+
+	for i := range boxes {
+		top := inner.Min.Y + i*height/n
+		bottom := inner.Min.Y + (i+1)*height/n
+		boxes[i].rect = image.Rect(inner.Min.X, top, inner.Max.X, bottom)
+
+		switch {
+		case layout == LayoutTop:
+			boxes[i].align = alignTop
+		case i == 0:
+			boxes[i].align = alignTop
+		case i == n-1:
+			boxes[i].align = alignBottom
+		default:
+			boxes[i].align = alignMiddle
+		}
+	}
+
+	return boxes
+}
+
+// drawLine fits text into box at the largest size up to maxSize that fits
+// and draws it centered with an outline.
+func drawLine(dst *image.RGBA, f *opentype.Font, box textBox, text string, fill color.Color, maxSize int) error {
+	face, rows, err := fit(f, text, box.rect.Dx(), box.rect.Dy(), maxSize)
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	m := face.Metrics()
+	lineHeight := m.Height.Ceil()
+	blockHeight := lineHeight * len(rows)
+
+	top := box.rect.Min.Y
+
+	switch box.align {
+	case alignMiddle:
+		top += (box.rect.Dy() - blockHeight) / 2
+	case alignBottom:
+		top = box.rect.Max.Y - blockHeight
+	case alignTop:
+	}
+
+	size := lineHeight
+	radius := max(1, size/16)
+	stroke := outlineColor(fill)
+
+	for i, row := range rows {
+		width := font.MeasureString(face, row).Ceil()
+		x := box.rect.Min.X + (box.rect.Dx()-width)/2
+		y := top + i*lineHeight + m.Ascent.Ceil()
+
+		for dy := -radius; dy <= radius; dy++ {
+			for dx := -radius; dx <= radius; dx++ {
+				if dx*dx+dy*dy > radius*radius || (dx == 0 && dy == 0) {
+					continue
+				}
+
+				drawString(dst, face, stroke, x+dx, y+dy, row)
+			}
+		}
+
+		drawString(dst, face, fill, x, y, row)
+	}
+
+	return nil
+}
+
+// drawString draws s with its baseline origin at (x, y).
+func drawString(dst draw.Image, face font.Face, c color.Color, x, y int, s string) {
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
+
+// fit finds the largest font size up to maxSize at which text, word-wrapped
+// to width, fits within height. Below minFontSize it gives up and uses
+// minFontSize.
+func fit(f *opentype.Font, text string, width, height, maxSize int) (font.Face, []string, error) {
+	lo, hi := minFontSize, max(minFontSize, min(maxFontSize, maxSize, height))
+
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+
+		face, err := newFace(f, mid)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rows, ok := wrap(face, text, width)
+		fits := ok && len(rows)*face.Metrics().Height.Ceil() <= height
+
+		_ = face.Close()
+
+		if fits {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	face, err := newFace(f, lo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, _ := wrap(face, text, width)
+
+	return face, rows, nil
+}
+
+// newFace returns f at size pixels.
+func newFace(f *opentype.Font, size int) (font.Face, error) {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    float64(size),
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating font face: %w", err)
+	}
+
+	return face, nil
+}
+
+// wrap breaks text into rows no wider than width, greedily by word. ok is
+// false when a single word is wider than width.
+func wrap(face font.Face, text string, width int) ([]string, bool) {
+	words := strings.Fields(text)
+	limit := fixed.I(width)
+	ok := true
+
+	var rows []string
+
+	row := ""
+
+	for _, w := range words {
+		if font.MeasureString(face, w) > limit {
+			ok = false
+		}
+
+		candidate := w
+		if row != "" {
+			candidate = row + " " + w
+		}
+
+		if row != "" && font.MeasureString(face, candidate) > limit {
+			rows = append(rows, row)
+			row = w
+
+			continue
+		}
+
+		row = candidate
+	}
+
+	if row != "" {
+		rows = append(rows, row)
+	}
+
+	return rows, ok
+}
+
+// outlineColor is black, or white for dark text so it stays legible.
+func outlineColor(fill color.Color) color.Color {
+	r, g, b, _ := fill.RGBA()
+	// Rec. 601 luma on 16-bit channels.
+	luma := (299*r + 587*g + 114*b) / 1000
+
+	if luma < 0x4000 {
+		return color.White
+	}
+
+	return color.Black
+}
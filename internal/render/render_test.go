@@ -0,0 +1,247 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite golden images in testdata/golden")
+
+func loadBlank(t *testing.T, name string) image.Image {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", "blanks", name))
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	img, err := Decode(f)
+	require.NoError(t, err)
+
+	return img
+}
+
+// assertGolden compares img with testdata/golden/<name>.png. Glyph
+// rasterization may shift by a pixel between x/image releases, so a few
+// differing pixels are tolerated.
+func assertGolden(t *testing.T, name string, img image.Image) {
+	t.Helper()
+
+	path := filepath.Join("testdata", "golden", name+".png")
+
+	if *update {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, img))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+
+		return
+	}
+
+	f, err := os.Open(path)
+	require.NoError(t, err, "run go test ./internal/render -update to create goldens")
+
+	defer f.Close()
+
+	want, err := png.Decode(f)
+	require.NoError(t, err)
+	require.Equal(t, want.Bounds(), img.Bounds())
+
+	b := want.Bounds()
+	differing := 0
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if channelDiff(want.At(x, y), img.At(x, y)) > 48 {
+				differing++
+			}
+		}
+	}
+
+	limit := b.Dx() * b.Dy() / 200 // 0.5%
+	assert.LessOrEqual(t, differing, limit, "%s: %d pixels differ from the golden image", name, differing)
+}
+
+// channelDiff is the largest 8-bit channel difference between a and b.
+func channelDiff(a, b color.Color) int {
+	ar, ag, ab, _ := a.RGBA()
+	br, bg, bb, _ := b.RGBA()
+
+	d := 0
+	for _, p := range [][2]uint32{{ar, br}, {ag, bg}, {ab, bb}} {
+		d = max(d, abs(int(p[0]>>8)-int(p[1]>>8)))
+	}
+
+	return d
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+func TestRender_Golden(t *testing.T) {
+	tests := []struct {
+		name  string
+		blank string
+		opts  Options
+	}{
+		{
+			name:  "gradient-default",
+			blank: "gradient.png",
+			opts:  Options{Lines: []string{"one does not simply", "render memes offline"}},
+		},
+		{
+			name:  "checker-top-colors",
+			blank: "checker.jpg",
+			opts: Options{
+				Lines:  []string{"top layout", "stacks lines"},
+				Colors: []string{"yellow", "#000"},
+				Layout: LayoutTop,
+			},
+		},
+		{
+			name:  "panel-three-lines",
+			blank: "panel.png",
+			opts:  Options{Lines: []string{"first", "", "a much longer last line that has to wrap"}},
+		},
+		{
+			name:  "gradient-resized",
+			blank: "gradient.png",
+			opts:  Options{Lines: []string{"small", "image"}, Width: 200},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Render(loadBlank(t, tt.blank), tt.opts)
+			require.NoError(t, err)
+			assertGolden(t, tt.name, img)
+		})
+	}
+}
+
+func TestRender_DrawsText(t *testing.T) {
+	bg := image.NewRGBA(image.Rect(0, 0, 200, 100))
+
+	img, err := Render(bg, Options{Lines: []string{"HI"}})
+	require.NoError(t, err)
+
+	white, black := 0, 0
+
+	for y := range 100 {
+		for x := range 200 {
+			switch img.RGBAAt(x, y) {
+			case color.RGBA{255, 255, 255, 255}:
+				white++
+			case color.RGBA{0, 0, 0, 255}:
+				black++
+			}
+		}
+	}
+
+	assert.Positive(t, white, "fill drawn")
+	assert.Positive(t, black, "outline drawn")
+}
+
+func TestRender_Resize(t *testing.T) {
+	bg := image.NewRGBA(image.Rect(0, 0, 400, 200))
+
+	img, err := Render(bg, Options{Height: 100})
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 200, 100), img.Bounds(), "aspect ratio kept")
+
+	img, err = Render(bg, Options{Width: 50, Height: 60})
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 50, 60), img.Bounds())
+}
+
+func TestRender_Errors(t *testing.T) {
+	bg := image.NewRGBA(image.Rect(0, 0, 10, 10))
+
+	_, err := Render(bg, Options{Lines: []string{"a"}, Layout: "sideways"})
+	require.ErrorContains(t, err, "invalid layout")
+
+	_, err = Render(bg, Options{Lines: []string{"a", "b"}, Colors: []string{"", "nope"}})
+	require.ErrorContains(t, err, "line 2")
+}
+
+func TestTextBoxes(t *testing.T) {
+	b := image.Rect(0, 0, 400, 400)
+
+	boxes := textBoxes(b, 3, LayoutDefault)
+	require.Len(t, boxes, 3)
+	assert.Equal(t, alignTop, boxes[0].align)
+	assert.Equal(t, alignMiddle, boxes[1].align)
+	assert.Equal(t, alignBottom, boxes[2].align)
+	assert.Equal(t, 390, boxes[2].rect.Max.Y, "last band reaches the bottom margin")
+
+	boxes = textBoxes(b, 2, LayoutTop)
+	assert.Equal(t, alignTop, boxes[1].align)
+	assert.LessOrEqual(t, boxes[1].rect.Max.Y, 200, "top layout stays in the upper half")
+
+	assert.Empty(t, textBoxes(b, 0, LayoutDefault))
+}
+
+func TestWrap(t *testing.T) {
+	f, err := boldFont()
+	require.NoError(t, err)
+
+	face, err := newFace(f, 20)
+	require.NoError(t, err)
+
+	defer face.Close()
+
+	rows, ok := wrap(face, "one two three four five six", 120)
+	assert.True(t, ok)
+	assert.Greater(t, len(rows), 1)
+
+	_, ok = wrap(face, "supercalifragilistic", 40)
+	assert.False(t, ok, "a word wider than the box does not fit")
+}
+
+func TestEncode(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+
+	for _, format := range []string{"jpg", "png"} {
+		var buf bytes.Buffer
+		require.NoError(t, Encode(&buf, img, format))
+
+		got, err := Decode(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, img.Bounds(), got.Bounds())
+	}
+
+	require.ErrorIs(t, Encode(&bytes.Buffer{}, img, "webp"), ErrFormat)
+}
+
+func TestParseColor(t *testing.T) {
+	for in, want := range map[string]color.RGBA{
+		"white":   {255, 255, 255, 255},
+		"HotPink": {255, 105, 180, 255},
+		"#f00":    {255, 0, 0, 255},
+		"00ff00":  {0, 255, 0, 255},
+		"#0000FF": {0, 0, 255, 255},
+	} {
+		got, err := ParseColor(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, color.RGBAModel.Convert(got), in)
+	}
+
+	for _, bad := range []string{"", "nope", "#12", "#gggggg"} {
+		_, err := ParseColor(bad)
+		require.Error(t, err, bad)
+	}
+}
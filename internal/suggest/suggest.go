@@ -0,0 +1,142 @@
+// Package suggest ranks known IDs against a mistyped one for "did you mean" hints.
+package suggest
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// minSimilarity is the edit-distance similarity (0..1) an ID needs to be
+// suggested without any keyword overlap.
+const minSimilarity = 0.5
+
+// Candidate is a suggestable ID plus descriptive words (name, keywords,
+// aliases) used for keyword overlap.
+type Candidate struct {
+	ID    string
+	Words []string
+}
+
+// Rank returns up to limit candidate IDs that best match query, best first.
+// Scores combine edit-distance similarity against the ID with the share of
+// query words found in the candidate's ID and words.
+func Rank(query string, candidates []Candidate, limit int) []string {
+	q := strings.ToLower(query)
+	qWords := words(q)
+
+	type scored struct {
+		id    string
+		score float64
+	}
+
+	var matches []scored
+
+	for _, c := range candidates {
+		id := strings.ToLower(c.ID)
+		if id == q {
+			continue
+		}
+
+		sim := similarity(q, id)
+		if q != "" && (strings.Contains(id, q) || strings.Contains(q, id)) {
+			sim = max(sim, 0.8)
+		}
+
+		overlap := overlap(qWords, c)
+		if sim < minSimilarity && overlap == 0 {
+			continue
+		}
+
+		matches = append(matches, scored{id: c.ID, score: sim + overlap})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+
+		return matches[i].id < matches[j].id
+	})
+
+	out := make([]string, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		out = append(out, m.id)
+	}
+
+	return out
+}
+
+// Distance returns the Levenshtein edit distance between a and b.
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// similarity maps edit distance onto 0..1 relative to the longer string.
+func similarity(a, b string) float64 {
+	n := max(len([]rune(a)), len([]rune(b)))
+	if n == 0 {
+		return 1
+	}
+
+	return 1 - float64(Distance(a, b))/float64(n)
+}
+
+// overlap returns the share of query words present in the candidate.
+func overlap(qWords []string, c Candidate) float64 {
+	if len(qWords) == 0 {
+		return 0
+	}
+
+	have := make(map[string]bool)
+	for _, w := range words(strings.ToLower(c.ID)) {
+		have[w] = true
+	}
+
+	for _, phrase := range c.Words {
+		for _, w := range words(strings.ToLower(phrase)) {
+			have[w] = true
+		}
+	}
+
+	hits := 0
+
+	for _, w := range qWords {
+		if have[w] {
+			hits++
+		}
+	}
+
+	return float64(hits) / float64(len(qWords))
+}
+
+// words splits s on anything that is not a letter or digit.
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,61 @@
+package suggest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"drake", "drake", 0},
+		{"drak", "drake", 1},
+		{"darke", "drake", 2},
+		{"kitten", "sitting", 3},
+		{"", "fry", 3},
+		{"café", "cafe", 1},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Distance(tt.a, tt.b), "%q vs %q", tt.a, tt.b)
+	}
+}
+
+var templates = []Candidate{
+	{ID: "drake", Words: []string{"Drake Hotline Bling", "drake", "hotline"}},
+	{ID: "fry", Words: []string{"Futurama Fry", "not sure if"}},
+	{ID: "buzz", Words: []string{"X, X Everywhere", "toy story"}},
+	{ID: "ds", Words: []string{"Daily Struggle", "two buttons"}},
+	{ID: "drowning", Words: []string{"Drowning High Five"}},
+}
+
+func TestRank_Typo(t *testing.T) {
+	got := Rank("drak", templates, 3)
+	assert.Equal(t, "drake", got[0])
+}
+
+func TestRank_KeywordOverlap(t *testing.T) {
+	assert.Equal(t, []string{"buzz"}, Rank("toy-story", templates, 3))
+	assert.Equal(t, []string{"ds"}, Rank("two_buttons", templates, 3))
+}
+
+func TestRank_NoMatch(t *testing.T) {
+	assert.Empty(t, Rank("zzzzzzzz", templates, 3))
+}
+
+func TestRank_Limit(t *testing.T) {
+	got := Rank("dr", templates, 1)
+	assert.Len(t, got, 1)
+}
+
+func TestRank_SkipsExactMatch(t *testing.T) {
+	assert.NotContains(t, Rank("drake", templates, 5), "drake")
+}
+
+func TestRank_CaseInsensitive(t *testing.T) {
+	assert.Equal(t, "fry", Rank("FRY!", templates, 3)[0])
+}
@@ -13,6 +13,7 @@ import (
 // picker list component.
 type TemplateItem struct {
 	template api.Template
+	uses     int
 }
 
 // NewTemplateItem creates a TemplateItem from an api.Template.
@@ -20,12 +21,30 @@ func NewTemplateItem(t api.Template) TemplateItem {
 	return TemplateItem{template: t}
 }
 
+// WithUses returns a copy of the item that shows how often the template
+// was used.
+func (i TemplateItem) WithUses(n int) TemplateItem {
+	i.uses = n
+
+	return i
+}
+
 // Title returns the template name for list display.
 func (i TemplateItem) Title() string { return i.template.Name }
 
-// Description returns template ID and line count for list display.
+// Description returns template ID, line count and, once used, the use
+// count for list display.
 func (i TemplateItem) Description() string {
-	return fmt.Sprintf("ID: %s | %d lines", i.template.ID, i.template.Lines)
+	desc := fmt.Sprintf("ID: %s | %d lines", i.template.ID, i.template.Lines)
+
+	switch {
+	case i.uses == 1:
+		desc += " | used once"
+	case i.uses > 1:
+		desc += fmt.Sprintf(" | used %d times", i.uses)
+	}
+
+	return desc
 }
 
 // FilterValue returns a combined string of name, ID, and keywords for fuzzy matching.
@@ -35,3 +54,23 @@ func (i TemplateItem) FilterValue() string {
 
 // Template returns the wrapped api.Template.
 func (i TemplateItem) Template() api.Template { return i.template }
+
+// SectionItem is a heading between groups of templates, such as
+// "Favorites". It cannot be selected, and filtering hides it.
+type SectionItem struct {
+	title string
+}
+
+// NewSectionItem creates a heading with the given title.
+func NewSectionItem(title string) SectionItem {
+	return SectionItem{title: title}
+}
+
+// Title returns the heading text.
+func (s SectionItem) Title() string { return s.title }
+
+// Description returns a rule under the heading.
+func (s SectionItem) Description() string { return strings.Repeat("─", len([]rune(s.title))) }
+
+// FilterValue is empty so no filter matches a heading.
+func (s SectionItem) FilterValue() string { return "" }
@@ -0,0 +1,380 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dedene/memelink-cli/internal/api"
+)
+
+// Options are the generation options chosen in the options step. Empty
+// values leave the choice to the API.
+type Options struct {
+	Font   string
+	Layout string
+	Format string
+	Style  string
+	// Colors holds one text color per line; nil when none is set.
+	Colors []string
+	Width  int
+	Height int
+}
+
+// OptionsConfig enables and configures the options step.
+type OptionsConfig struct {
+	// Defaults are preselected, typically the configured font, layout and
+	// format.
+	Defaults Options
+	// Fonts, Layouts and Formats are the choices. Fonts can also arrive
+	// later in a FontsMsg.
+	Fonts   []string
+	Layouts []string
+	Formats []string
+	// Validate checks the options before the TUI finishes; its error is
+	// shown in the options step. Nil accepts anything.
+	Validate func(t api.Template, texts []string, o Options) error
+}
+
+// FontsMsg replaces the font choices, e.g. once the font list is loaded.
+type FontsMsg struct {
+	Fonts []string
+}
+
+// optionField is one row of the options step: a choice cycled with
+// left/right, or a text input.
+type optionField struct {
+	label   string
+	choices []string
+	idx     int
+	input   textinput.Model
+	text    bool
+}
+
+// value returns the chosen or typed value.
+func (f optionField) value() string {
+	if f.text {
+		return strings.TrimSpace(f.input.Value())
+	}
+
+	return f.choices[f.idx]
+}
+
+// view renders the field's value.
+func (f optionField) view() string {
+	if f.text {
+		return f.input.View()
+	}
+
+	v := f.choices[f.idx]
+	if v == "" {
+		v = "default"
+	}
+
+	return "‹ " + v + " ›"
+}
+
+// choiceField returns a choice field with selected preselected, adding it
+// to the choices if missing.
+func choiceField(label string, choices []string, selected string) optionField {
+	if !slices.Contains(choices, selected) {
+		choices = append([]string{selected}, choices...)
+	}
+
+	return optionField{label: label, choices: choices, idx: slices.Index(choices, selected)}
+}
+
+// textField returns a text input field.
+func textField(label, placeholder, value string, width int) optionField {
+	ti := textinput.New()
+	ti.Placeholder = placeholder
+	ti.CharLimit = 100
+	ti.SetValue(value)
+
+	if width > 4 {
+		ti.Width = width - 4
+	}
+
+	return optionField{label: label, input: ti, text: true}
+}
+
+// WithOptions returns the model with an options step after text input.
+func (m Model) WithOptions(cfg OptionsConfig) Model {
+	m.optionsCfg = &cfg
+	m.fonts = cfg.Fonts
+
+	return m
+}
+
+// Options returns the options chosen in the options step. Without that
+// step, it returns the zero Options.
+func (m Model) Options() Options { return m.options }
+
+// Field labels, also used to read values back.
+const (
+	fieldFont   = "Font"
+	fieldLayout = "Layout"
+	fieldFormat = "Format"
+	fieldStyle  = "Style"
+	fieldWidth  = "Width"
+	fieldHeight = "Height"
+)
+
+// enterOptions switches to the options step, building the fields on first
+// entry for the selected template. Going back and forth keeps them.
+func (m Model) enterOptions() (Model, tea.Cmd) {
+	if m.fields == nil {
+		m.fields = m.optionFields()
+	}
+
+	m.state = StateOptions
+	m.optErr = nil
+
+	return m, m.focusOption(0)
+}
+
+// optionFields builds the fields for the selected template.
+func (m Model) optionFields() []optionField {
+	d := m.optionsCfg.Defaults
+	width := m.contentWidth()
+
+	styles := []string{""}
+
+	for _, s := range m.selected.Styles {
+		if s != "default" && s != "" {
+			styles = append(styles, s)
+		}
+	}
+
+	fields := []optionField{
+		choiceField(fieldFont, append([]string{""}, m.fonts...), d.Font),
+		choiceField(fieldLayout, slices.Clone(m.optionsCfg.Layouts), d.Layout),
+		choiceField(fieldFormat, slices.Clone(m.optionsCfg.Formats), d.Format),
+		choiceField(fieldStyle, styles, d.Style),
+	}
+
+	for i := range m.inputs {
+		color := ""
+		if i < len(d.Colors) {
+			color = d.Colors[i]
+		}
+
+		fields = append(fields, textField(fmt.Sprintf("Color %d", i+1), "default", color, width))
+	}
+
+	return append(fields,
+		textField(fieldWidth, "auto", sizeValue(d.Width), width),
+		textField(fieldHeight, "auto", sizeValue(d.Height), width),
+	)
+}
+
+// sizeValue formats a size for its text field, leaving 0 blank.
+func sizeValue(n int) string {
+	if n == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(n)
+}
+
+// setFonts swaps in new font choices, keeping the selected font.
+func (m *Model) setFonts(fonts []string) {
+	m.fonts = fonts
+
+	for i, f := range m.fields {
+		if f.label == fieldFont && !f.text {
+			m.fields[i] = choiceField(fieldFont, append([]string{""}, fonts...), f.value())
+		}
+	}
+}
+
+// focusOption moves the focus to field i.
+func (m *Model) focusOption(i int) tea.Cmd {
+	if m.optIdx < len(m.fields) && m.fields[m.optIdx].text {
+		m.fields[m.optIdx].input.Blur()
+	}
+
+	m.optIdx = i
+
+	if m.fields[i].text {
+		return m.fields[i].input.Focus()
+	}
+
+	return nil
+}
+
+// currentOptions reads the fields back into Options.
+func (m Model) currentOptions() (Options, error) {
+	var o Options
+
+	for _, f := range m.fields {
+		v := f.value()
+
+		switch {
+		case f.label == fieldFont:
+			o.Font = v
+		case f.label == fieldLayout:
+			o.Layout = v
+		case f.label == fieldFormat:
+			o.Format = v
+		case f.label == fieldStyle:
+			o.Style = v
+		case f.label == fieldWidth || f.label == fieldHeight:
+			n, err := parseSize(f.label, v)
+			if err != nil {
+				return o, err
+			}
+
+			if f.label == fieldWidth {
+				o.Width = n
+			} else {
+				o.Height = n
+			}
+		default: // a line color
+			o.Colors = append(o.Colors, v)
+		}
+	}
+
+	if !slices.ContainsFunc(o.Colors, func(c string) bool { return c != "" }) {
+		o.Colors = nil
+	}
+
+	return o, nil
+}
+
+// parseSize parses a width or height in pixels; blank means auto.
+func parseSize(label, v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive number of pixels, not %q", strings.ToLower(label), v)
+	}
+
+	return n, nil
+}
+
+// updateOptions handles messages in the options step.
+func (m Model) updateOptions(msg tea.Msg) (Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m.updateOptionInput(msg)
+	}
+
+	field := &m.fields[m.optIdx]
+
+	switch keyMsg.String() {
+	case "ctrl+c":
+		m.cancelled = true
+		m.state = StateDone
+
+		return m, tea.Quit
+
+	case "esc":
+		// Back to the text, keeping the chosen options; templates without
+		// lines go back to the picker.
+		if len(m.inputs) == 0 {
+			m.state = StatePicking
+
+			return m, nil
+		}
+
+		m.state = StateInputting
+
+		return m, nil
+
+	case "enter":
+		o, err := m.currentOptions()
+		if err == nil && m.optionsCfg.Validate != nil {
+			err = m.optionsCfg.Validate(*m.selected, m.texts, o)
+		}
+
+		if err != nil {
+			m.optErr = err
+
+			return m, nil
+		}
+
+		m.options = o
+		m.state = StateDone
+
+		return m, tea.Quit
+
+	case "up", "shift+tab":
+		if m.optIdx > 0 {
+			return m, m.focusOption(m.optIdx - 1)
+		}
+
+		return m, nil
+
+	case "down", "tab":
+		if m.optIdx < len(m.fields)-1 {
+			return m, m.focusOption(m.optIdx + 1)
+		}
+
+		return m, nil
+
+	case "left", "right":
+		if field.text {
+			break // move the text cursor
+		}
+
+		step := 1
+		if keyMsg.String() == "left" {
+			step = len(field.choices) - 1
+		}
+
+		field.idx = (field.idx + step) % len(field.choices)
+		m.optErr = nil
+
+		return m, nil
+	}
+
+	return m.updateOptionInput(msg)
+}
+
+// updateOptionInput passes msg to the focused text field, if any.
+func (m Model) updateOptionInput(msg tea.Msg) (Model, tea.Cmd) {
+	if !m.fields[m.optIdx].text {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.fields[m.optIdx].input, cmd = m.fields[m.optIdx].input.Update(msg)
+
+	if _, ok := msg.(tea.KeyMsg); ok {
+		m.optErr = nil
+	}
+
+	return m, cmd
+}
+
+// viewOptions renders the options step.
+func (m Model) viewOptions() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Template: %s | options\n\n", m.selected.Name)
+
+	for i, f := range m.fields {
+		cursor := " "
+		if i == m.optIdx {
+			cursor = "›"
+		}
+
+		fmt.Fprintf(&b, "%s %-8s %s\n", cursor, f.label+":", f.view())
+	}
+
+	if m.optErr != nil {
+		fmt.Fprintf(&b, "\n  Error: %v\n", m.optErr)
+	}
+
+	b.WriteString("\n  ↑/↓: field | ←/→: change | Enter: generate | Esc: back | Ctrl+C: quit\n")
+
+	return b.String()
+}
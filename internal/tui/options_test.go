@@ -0,0 +1,200 @@
+package tui
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dedene/memelink-cli/internal/api"
+)
+
+func testOptionsConfig() OptionsConfig {
+	return OptionsConfig{
+		Defaults: Options{Font: "impact", Layout: "default", Format: "jpg"},
+		Fonts:    []string{"impact", "comic"},
+		Layouts:  []string{"default", "top"},
+		Formats:  []string{"gif", "jpg", "png", "webp"},
+	}
+}
+
+// press sends a key to the model.
+func press(t *testing.T, m Model, msg tea.KeyMsg) Model {
+	t.Helper()
+
+	result, _ := m.Update(msg)
+
+	return result.(Model)
+}
+
+func typeText(t *testing.T, m Model, s string) Model {
+	t.Helper()
+
+	return press(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)})
+}
+
+// optionsModel picks drake with options enabled and types both lines.
+func optionsModel(t *testing.T, cfg OptionsConfig) Model {
+	t.Helper()
+
+	result, _ := NewPicker(testItems()).WithOptions(cfg).Update(sizeMsg())
+	m := result.(Model)
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeText(t, m, "no")
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeText(t, m, "yes")
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, StateOptions, m.State())
+
+	return m
+}
+
+func TestOptions_DefaultsAccepted(t *testing.T) {
+	m := optionsModel(t, testOptionsConfig())
+
+	assert.Contains(t, m.View(), "Font:    ‹ impact ›")
+	assert.Contains(t, m.View(), "Color 2:")
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	assert.Equal(t, StateDone, m.State())
+	assert.Equal(t, []string{"no", "yes"}, m.Texts())
+	assert.Equal(t, Options{Font: "impact", Layout: "default", Format: "jpg"}, m.Options())
+}
+
+func TestOptions_ChooseAndType(t *testing.T) {
+	m := optionsModel(t, testOptionsConfig())
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyRight}) // font: comic
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyLeft}) // layout: wraps to top
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyRight}) // format: png
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyDown})  // style (none besides default)
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyDown})  // color 1
+	m = typeText(t, m, "red")
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyTab}) // color 2
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyTab}) // width
+	m = typeText(t, m, "400")
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	require.Equal(t, StateDone, m.State())
+	assert.Equal(t, Options{
+		Font:   "comic",
+		Layout: "top",
+		Format: "png",
+		Colors: []string{"red", ""},
+		Width:  400,
+	}, m.Options())
+}
+
+func TestOptions_FontsArriveLater(t *testing.T) {
+	cfg := testOptionsConfig()
+	cfg.Fonts = nil
+
+	m := optionsModel(t, cfg)
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyRight})
+	assert.Contains(t, m.View(), "‹ default ›", "only the default and configured fonts so far")
+
+	result, _ := m.Update(FontsMsg{Fonts: []string{"comic", "impact", "kalam"}})
+	m = result.(Model)
+	assert.Contains(t, m.View(), "‹ default ›", "the choice is kept")
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyRight})
+	assert.Contains(t, m.View(), "‹ comic ›")
+}
+
+func TestOptions_StylesFromTemplate(t *testing.T) {
+	items := testItems()
+	tmpl := items[0].(TemplateItem).Template()
+	tmpl.Styles = []string{"default", "animated"}
+
+	result, _ := NewPicker([]list.Item{NewTemplateItem(tmpl)}).WithOptions(testOptionsConfig()).Update(sizeMsg())
+	m := result.(Model)
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	for range 3 {
+		m = press(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	}
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyRight})
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	assert.Equal(t, "animated", m.Options().Style)
+}
+
+func TestOptions_ValidationErrorStays(t *testing.T) {
+	cfg := testOptionsConfig()
+
+	var got []string
+
+	cfg.Validate = func(tmpl api.Template, texts []string, o Options) error {
+		got = texts
+		if o.Format == "png" {
+			return errors.New("no png for you")
+		}
+
+		return nil
+	}
+
+	m := optionsModel(t, cfg)
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyRight})
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	assert.Equal(t, StateOptions, m.State())
+	assert.Contains(t, m.View(), "Error: no png for you")
+	assert.Equal(t, []string{"no", "yes"}, got)
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyLeft})
+	assert.NotContains(t, m.View(), "Error:", "changing a field clears the error")
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	assert.Equal(t, StateDone, m.State())
+}
+
+func TestOptions_BadSize(t *testing.T) {
+	m := optionsModel(t, testOptionsConfig())
+
+	for range 6 {
+		m = press(t, m, tea.KeyMsg{Type: tea.KeyDown})
+	}
+
+	m = typeText(t, m, "big")
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	assert.Equal(t, StateOptions, m.State())
+	assert.Contains(t, m.View(), `width must be a positive number of pixels, not "big"`)
+}
+
+func TestOptions_EscKeepsChoices(t *testing.T) {
+	m := optionsModel(t, testOptionsConfig())
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyRight})
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEscape})
+	require.Equal(t, StateInputting, m.State())
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, StateOptions, m.State())
+	assert.Contains(t, m.View(), "‹ comic ›")
+}
+
+func TestOptions_ZeroLines(t *testing.T) {
+	result, _ := NewPicker(testItemsWithZeroLines()).WithOptions(testOptionsConfig()).Update(sizeMsg())
+	m := result.(Model)
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, StateOptions, m.State(), "no text, straight to options")
+	assert.NotContains(t, m.View(), "Color 1")
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEscape})
+	assert.Equal(t, StatePicking, m.State())
+}
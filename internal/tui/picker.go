@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -19,10 +20,18 @@ const (
 	StatePicking State = iota
 	// StateInputting is the text input phase (used by plan 02).
 	StateInputting
+	// StateOptions is the generation options phase (see WithOptions).
+	StateOptions
 	// StateDone means the TUI is finished and ready to quit.
 	StateDone
 )
 
+// ItemsMsg replaces the picker's items, e.g. when a background refresh of a
+// stale template cache completes.
+type ItemsMsg struct {
+	Items []list.Item
+}
+
 // Model is the bubbletea model for the template picker TUI.
 type Model struct {
 	state     State
@@ -37,6 +46,22 @@ type Model struct {
 	inputs   []textinput.Model
 	focusIdx int
 	texts    []string
+
+	// Options step (optional, see WithOptions).
+	optionsCfg *OptionsConfig
+	fonts      []string
+	fields     []optionField
+	optIdx     int
+	options    Options
+	optErr     error
+
+	// Preview pane (optional, see WithPreview). previews is shared by
+	// copies of the model; only Update touches it. previewErr is the
+	// failure of the preview for previewKey, which is not cached.
+	preview    PreviewFunc
+	previews   map[string]string
+	previewKey string
+	previewErr error
 }
 
 // NewPicker creates a new picker Model with the given list items.
@@ -47,10 +72,13 @@ func NewPicker(items []list.Item) Model {
 	l.SetFilteringEnabled(true)
 	l.DisableQuitKeybindings()
 
-	return Model{
+	m := Model{
 		state: StatePicking,
 		list:  l,
 	}
+	m.skipSection(false)
+
+	return m
 }
 
 // Init returns the initial command. The list handles its own init internally.
@@ -58,21 +86,54 @@ func (m Model) Init() tea.Cmd {
 	return nil
 }
 
-// Update handles messages and updates model state.
+// Update handles messages and updates model state. Whatever changed, the
+// preview pane is then brought up to date.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	if next.state == StateDone {
+		return next, cmd
+	}
+
+	next, previewCmd := next.schedulePreview()
+
+	return next, tea.Batch(cmd, previewCmd)
+}
+
+// update applies msg to the model.
+func (m Model) update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
 	// Handle window resize globally.
-	if wsm, ok := msg.(tea.WindowSizeMsg); ok {
-		m.width = wsm.Width
-		m.height = wsm.Height
-		m.list.SetSize(wsm.Width, wsm.Height-2)
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.list.SetSize(m.contentWidth(), msg.Height-2)
 
 		for i := range m.inputs {
-			m.inputs[i].Width = wsm.Width - 4
+			m.inputs[i].Width = m.contentWidth() - 4
+		}
+
+		for i := range m.fields {
+			m.fields[i].input.Width = m.contentWidth() - 4
 		}
 
 		m.ready = true
 
 		return m, nil
+
+	// Refreshed items apply in any state so the list is current on return.
+	case ItemsMsg:
+		cmd := m.list.SetItems(msg.Items)
+		m.skipSection(false)
+
+		return m, tea.Batch(cmd, m.list.NewStatusMessage("Templates refreshed"))
+
+	case FontsMsg:
+		m.setFonts(msg.Fonts)
+
+		return m, nil
+
+	case previewDueMsg, previewMsg:
+		return m.updatePreview(msg)
 	}
 
 	// Dispatch by state.
@@ -81,13 +142,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updatePicking(msg)
 	case StateInputting:
 		return m.updateInputting(msg)
+	case StateOptions:
+		return m.updateOptions(msg)
 	}
 
 	return m, nil
 }
 
 // updatePicking handles messages in the template picker state.
-func (m Model) updatePicking(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (m Model) updatePicking(msg tea.Msg) (Model, tea.Cmd) {
 	if keyMsg, ok := msg.(tea.KeyMsg); ok {
 		switch keyMsg.String() {
 		case "ctrl+c":
@@ -119,9 +182,30 @@ func (m Model) updatePicking(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
 
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		m.skipSection(key.Matches(keyMsg, m.list.KeyMap.CursorUp))
+	}
+
 	return m, cmd
 }
 
+// skipSection moves the cursor off a section heading: up when the user was
+// moving up and there is room, down otherwise. Headings are never adjacent,
+// so one step lands on a template.
+func (m *Model) skipSection(up bool) {
+	if _, ok := m.list.SelectedItem().(SectionItem); !ok {
+		return
+	}
+
+	if up && m.list.Index() > 0 {
+		m.list.CursorUp()
+
+		return
+	}
+
+	m.list.CursorDown()
+}
+
 // View renders the current TUI state.
 func (m Model) View() string {
 	if !m.ready {
@@ -130,9 +214,11 @@ func (m Model) View() string {
 
 	switch m.state {
 	case StatePicking:
-		return m.list.View()
+		return m.joinPreview(m.list.View())
 	case StateInputting:
-		return m.viewInputting()
+		return m.joinPreview(m.viewInputting())
+	case StateOptions:
+		return m.joinPreview(m.viewOptions())
 	}
 
 	return ""
@@ -152,7 +238,7 @@ func (m Model) Texts() []string { return m.texts }
 
 // handlePickEnter processes Enter in statePicking: selects template and
 // transitions to stateInputting (or StateDone for 0-line templates).
-func (m Model) handlePickEnter() (tea.Model, tea.Cmd) {
+func (m Model) handlePickEnter() (Model, tea.Cmd) {
 	item, ok := m.list.SelectedItem().(TemplateItem)
 	if !ok {
 		return m, nil
@@ -160,10 +246,17 @@ func (m Model) handlePickEnter() (tea.Model, tea.Cmd) {
 
 	t := item.Template()
 	m.selected = &t
+	m.fields = nil
 
 	// Templates with 0 lines skip text input.
 	if t.Lines == 0 {
+		m.inputs = nil
 		m.texts = []string{}
+
+		if m.optionsCfg != nil {
+			return m.enterOptions()
+		}
+
 		m.state = StateDone
 
 		return m, tea.Quit
@@ -184,8 +277,8 @@ func (m Model) handlePickEnter() (tea.Model, tea.Cmd) {
 
 		ti.CharLimit = 200
 
-		if m.width > 4 {
-			ti.Width = m.width - 4
+		if m.contentWidth() > 4 {
+			ti.Width = m.contentWidth() - 4
 		}
 
 		m.inputs[i] = ti
@@ -199,7 +292,7 @@ func (m Model) handlePickEnter() (tea.Model, tea.Cmd) {
 }
 
 // updateInputting handles messages in the text input state.
-func (m Model) updateInputting(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (m Model) updateInputting(msg tea.Msg) (Model, tea.Cmd) {
 	keyMsg, ok := msg.(tea.KeyMsg)
 	if !ok {
 		// Delegate non-key messages to focused input.
@@ -234,12 +327,16 @@ func (m Model) updateInputting(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, textinput.Blink
 		}
 
-		// Last input -- collect and finish.
+		// Last input -- collect, then choose options or finish.
 		m.texts = make([]string, len(m.inputs))
 		for i := range m.inputs {
 			m.texts[i] = m.inputs[i].Value()
 		}
 
+		if m.optionsCfg != nil {
+			return m.enterOptions()
+		}
+
 		m.state = StateDone
 
 		return m, tea.Quit
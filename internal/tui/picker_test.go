@@ -78,6 +78,17 @@ func TestPicker_WindowSizeMsg(t *testing.T) {
 	assert.Equal(t, 24, model.height)
 }
 
+func TestPicker_ItemsMsgReplacesItems(t *testing.T) {
+	m := readyModel(t)
+
+	result, _ := m.Update(ItemsMsg{Items: testItemsWithZeroLines()})
+	model := result.(Model)
+
+	require.Len(t, model.list.Items(), 1)
+	assert.Equal(t, "noline", model.list.Items()[0].(TemplateItem).Template().ID)
+	assert.Equal(t, StatePicking, model.State())
+}
+
 func TestPicker_EnterTransitionsToInputting(t *testing.T) {
 	m := readyModel(t)
 
@@ -134,6 +145,8 @@ func TestTemplateItem_Title(t *testing.T) {
 func TestTemplateItem_Description(t *testing.T) {
 	item := NewTemplateItem(api.Template{ID: "drake", Lines: 2})
 	assert.Equal(t, "ID: drake | 2 lines", item.Description())
+	assert.Equal(t, "ID: drake | 2 lines | used once", item.WithUses(1).Description())
+	assert.Equal(t, "ID: drake | 2 lines | used 4 times", item.WithUses(4).Description())
 }
 
 func TestTemplateItem_FilterValue(t *testing.T) {
@@ -315,3 +328,51 @@ func TestTexts_EmptyBeforeConfirm(t *testing.T) {
 	m := inputtingModel(t)
 	assert.Nil(t, m.Texts())
 }
+
+func sectionedItems() []list.Item {
+	items := testItems()
+
+	return []list.Item{NewSectionItem("★ Favorites"), items[1], NewSectionItem("All templates"), items[0]}
+}
+
+func TestPicker_SkipsSections(t *testing.T) {
+	m := NewPicker(sectionedItems())
+	result, _ := m.Update(sizeMsg())
+	model := result.(Model)
+
+	assert.Equal(t, 1, model.list.Index(), "the cursor starts on the first template")
+
+	result, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	model = result.(Model)
+	assert.Equal(t, 3, model.list.Index(), "down skips the heading")
+
+	result, _ = model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	model = result.(Model)
+	assert.Equal(t, 1, model.list.Index(), "up skips the heading")
+
+	result, _ = model.Update(tea.KeyMsg{Type: tea.KeyUp})
+	model = result.(Model)
+	assert.Equal(t, 1, model.list.Index(), "the top heading is never selected")
+
+	result, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = result.(Model)
+	require.NotNil(t, model.Selected())
+	assert.Equal(t, "fry", model.Selected().ID)
+}
+
+func TestPicker_ItemsMsgSkipsSections(t *testing.T) {
+	m := readyModel(t)
+
+	result, _ := m.Update(ItemsMsg{Items: sectionedItems()})
+	model := result.(Model)
+
+	assert.IsType(t, TemplateItem{}, model.list.SelectedItem())
+}
+
+func TestSectionItem(t *testing.T) {
+	s := NewSectionItem("★ Favorites")
+
+	assert.Equal(t, "★ Favorites", s.Title())
+	assert.Equal(t, "───────────", s.Description())
+	assert.Empty(t, s.FilterValue(), "filters never match a heading")
+}
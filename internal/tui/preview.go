@@ -0,0 +1,244 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/dedene/memelink-cli/internal/api"
+)
+
+const (
+	// previewDebounce is how long the cursor or text must rest before a
+	// preview is rendered, so scrolling and typing stay responsive.
+	previewDebounce = 250 * time.Millisecond
+	// minSplitWidth is the narrowest terminal that gets a preview pane.
+	minSplitWidth = 60
+	// maxCachedPreviews bounds the preview cache; it is emptied when full.
+	maxCachedPreviews = 64
+)
+
+// PreviewRequest describes the image for the preview pane.
+type PreviewRequest struct {
+	Template api.Template
+	// Texts is nil while picking, which previews the template itself, and
+	// the typed lines while inputting.
+	Texts []string
+	// Options are the choices so far in the options step.
+	Options Options
+	// Width and Height bound the image in character cells.
+	Width  int
+	Height int
+}
+
+// PreviewFunc renders a preview as text, such as preview.Blocks output. It
+// runs outside the bubbletea event loop.
+type PreviewFunc func(PreviewRequest) (string, error)
+
+// previewDueMsg fires once the debounce for key has passed.
+type previewDueMsg struct {
+	key string
+}
+
+// previewMsg carries a finished preview.
+type previewMsg struct {
+	key  string
+	view string
+	err  error
+}
+
+// WithPreview returns the model with a preview pane beside the list and
+// the text inputs, rendered by fn.
+func (m Model) WithPreview(fn PreviewFunc) Model {
+	m.preview = fn
+	m.previews = make(map[string]string)
+
+	return m
+}
+
+// showPreview reports whether the preview pane is shown.
+func (m Model) showPreview() bool {
+	return m.preview != nil && m.width >= minSplitWidth
+}
+
+// paneWidth is the width of the preview pane, or 0 without one.
+func (m Model) paneWidth() int {
+	if !m.showPreview() {
+		return 0
+	}
+
+	return m.width * 2 / 5
+}
+
+// contentWidth is the width left for the list or the text inputs.
+func (m Model) contentWidth() int {
+	return m.width - m.paneWidth()
+}
+
+// previewRequest returns what the pane should show now: the highlighted
+// template while picking, the typed text (and options) afterwards.
+func (m Model) previewRequest() (PreviewRequest, bool) {
+	req := PreviewRequest{
+		// Leave a column of padding, and rows for the title and spacing.
+		Width:  m.paneWidth() - 2,
+		Height: m.height - 4,
+	}
+
+	switch m.state {
+	case StatePicking:
+		item, ok := m.list.SelectedItem().(TemplateItem)
+		if !ok {
+			return req, false
+		}
+
+		req.Template = item.Template()
+	case StateInputting, StateOptions:
+		if m.selected == nil {
+			return req, false
+		}
+
+		req.Template = *m.selected
+		req.Texts = make([]string, len(m.inputs))
+
+		for i := range m.inputs {
+			req.Texts[i] = m.inputs[i].Value()
+		}
+
+		if m.state == StateOptions {
+			// Half-typed sizes are ignored until they parse.
+			req.Options, _ = m.currentOptions()
+		}
+	default:
+		return req, false
+	}
+
+	return req, req.Width > 0 && req.Height > 0
+}
+
+// previewKey identifies a request in the cache.
+func previewKey(req PreviewRequest) string {
+	mode := "pick"
+	if req.Texts != nil {
+		mode = "type"
+	}
+
+	return fmt.Sprintf("%s|%s|%q|%+v|%dx%d", mode, req.Template.ID, req.Texts, req.Options, req.Width, req.Height)
+}
+
+// schedulePreview starts the debounce when the wanted preview changed and
+// is not cached yet.
+func (m Model) schedulePreview() (Model, tea.Cmd) {
+	if !m.showPreview() {
+		return m, nil
+	}
+
+	req, ok := m.previewRequest()
+	if !ok {
+		m.previewKey = ""
+
+		return m, nil
+	}
+
+	key := previewKey(req)
+	if key == m.previewKey {
+		return m, nil
+	}
+
+	m.previewKey = key
+	m.previewErr = nil
+
+	if _, ok := m.previews[key]; ok {
+		return m, nil
+	}
+
+	return m, tea.Tick(previewDebounce, func(time.Time) tea.Msg { return previewDueMsg{key: key} })
+}
+
+// updatePreview handles the preview messages. A due preview is rendered
+// only if it is still wanted; finished ones are cached either way, unless
+// they failed, so going back to a template retries it.
+func (m Model) updatePreview(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case previewDueMsg:
+		if msg.key != m.previewKey {
+			return m, nil
+		}
+
+		if _, ok := m.previews[msg.key]; ok {
+			return m, nil
+		}
+
+		req, ok := m.previewRequest()
+		if !ok || previewKey(req) != msg.key {
+			return m, nil
+		}
+
+		fn := m.preview
+
+		return m, func() tea.Msg {
+			view, err := fn(req)
+
+			return previewMsg{key: msg.key, view: view, err: err}
+		}
+
+	case previewMsg:
+		if msg.err != nil {
+			if msg.key == m.previewKey {
+				m.previewErr = msg.err
+			}
+
+			return m, nil
+		}
+
+		if len(m.previews) >= maxCachedPreviews {
+			clear(m.previews)
+		}
+
+		m.previews[msg.key] = msg.view
+	}
+
+	return m, nil
+}
+
+// viewPreview renders the pane for the current preview.
+func (m Model) viewPreview() string {
+	var body string
+
+	view, ok := m.previews[m.previewKey]
+
+	switch {
+	case m.previewKey == "":
+		body = ""
+	case ok:
+		body = view
+	case m.previewErr != nil:
+		body = "No preview: " + m.previewErr.Error()
+	default:
+		body = "Loading preview..."
+	}
+
+	title := "Preview"
+	if m.state != StatePicking {
+		title = "Live preview"
+	}
+
+	return lipgloss.NewStyle().
+		Width(m.paneWidth()).
+		MaxHeight(m.height).
+		PaddingLeft(1).
+		Render(title + "\n\n" + body)
+}
+
+// joinPreview puts the preview pane to the right of content.
+func (m Model) joinPreview(content string) string {
+	if !m.showPreview() {
+		return content
+	}
+
+	left := lipgloss.NewStyle().Width(m.contentWidth()).Render(strings.TrimRight(content, "\n"))
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, left, m.viewPreview())
+}
@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePreview records requests and renders them as text.
+type fakePreview struct {
+	requests []PreviewRequest
+	err      error
+}
+
+func (f *fakePreview) render(req PreviewRequest) (string, error) {
+	f.requests = append(f.requests, req)
+
+	return "IMG:" + req.Template.ID + ":" + strings.Join(req.Texts, "/"), f.err
+}
+
+// previewModel returns a sized model with a preview pane.
+func previewModel(t *testing.T, f *fakePreview) Model {
+	t.Helper()
+
+	result, cmd := NewPicker(testItems()).WithPreview(f.render).Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	require.NotNil(t, cmd, "the first preview is scheduled")
+
+	return result.(Model)
+}
+
+// settle delivers the due preview and its result.
+func settle(t *testing.T, m Model) Model {
+	t.Helper()
+
+	result, cmd := m.Update(previewDueMsg{key: m.previewKey})
+	require.NotNil(t, cmd, "a due preview renders")
+
+	result, _ = result.(Model).Update(cmd())
+
+	return result.(Model)
+}
+
+func TestPreview_SplitsView(t *testing.T) {
+	f := &fakePreview{}
+	m := previewModel(t, f)
+
+	assert.Contains(t, m.View(), "Loading preview...")
+
+	m = settle(t, m)
+
+	view := m.View()
+	assert.Contains(t, view, "IMG:drake:")
+	assert.Contains(t, view, "Drake Hotline Bling", "the list is still shown")
+
+	require.Len(t, f.requests, 1)
+	assert.Nil(t, f.requests[0].Texts, "picking previews the template itself")
+	assert.Equal(t, 38, f.requests[0].Width)
+	assert.Equal(t, 60, m.list.Width())
+}
+
+func TestPreview_DebouncedAndCached(t *testing.T) {
+	f := &fakePreview{}
+	m := previewModel(t, f)
+	first := m.previewKey
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = result.(Model)
+	assert.NotEqual(t, first, m.previewKey)
+
+	result, cmd := m.Update(previewDueMsg{key: first})
+	assert.Nil(t, cmd, "a preview the cursor moved away from is dropped")
+
+	m = settle(t, result.(Model))
+	assert.Contains(t, m.View(), "IMG:fry:")
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m = settle(t, result.(Model))
+
+	result, cmd = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = result.(Model)
+	assert.Nil(t, cmd, "a cached preview shows at once")
+	assert.Contains(t, m.View(), "IMG:fry:")
+	assert.Len(t, f.requests, 2)
+}
+
+func TestPreview_LiveWhileTyping(t *testing.T) {
+	f := &fakePreview{}
+	m := settle(t, previewModel(t, f))
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = result.(Model)
+	require.Equal(t, StateInputting, m.State())
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("no")})
+	m = settle(t, result.(Model))
+
+	assert.Contains(t, m.View(), "Live preview")
+	assert.Contains(t, m.View(), "IMG:drake:no/")
+	assert.Equal(t, []string{"no", ""}, f.requests[len(f.requests)-1].Texts)
+}
+
+func TestPreview_Error(t *testing.T) {
+	f := &fakePreview{err: errors.New("offline")}
+	m := settle(t, previewModel(t, f))
+
+	assert.Contains(t, m.View(), "No preview: offline")
+
+	// Failures are not cached: coming back to the template retries it.
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = settle(t, result.(Model))
+
+	f.err = nil
+	result, cmd := m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	require.NotNil(t, cmd, "the failed preview is scheduled again")
+	assert.Contains(t, result.(Model).View(), "Loading preview...")
+
+	m = settle(t, result.(Model))
+	assert.Contains(t, m.View(), "IMG:drake:")
+	assert.Len(t, f.requests, 3)
+}
+
+func TestPreview_NarrowTerminal(t *testing.T) {
+	result, cmd := NewPicker(testItems()).WithPreview((&fakePreview{}).render).Update(tea.WindowSizeMsg{Width: 50, Height: 30})
+	m := result.(Model)
+
+	assert.Nil(t, cmd, "no pane, no preview")
+	assert.NotContains(t, m.View(), "Preview")
+	assert.Equal(t, 50, m.list.Width())
+}
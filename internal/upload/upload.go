@@ -0,0 +1,216 @@
+// Package upload validates local background images and publishes them
+// through a user-configured uploader, so Memegen can fetch them by URL.
+package upload
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MaxSize is the largest background accepted for upload or local
+// composition.
+const MaxSize = 10 << 20
+
+// ErrNotImage indicates a file that is not a supported image type.
+var ErrNotImage = errors.New("not a supported image (jpg, png, gif, webp)")
+
+// ErrTooLarge indicates a file over MaxSize.
+var ErrTooLarge = errors.New("image too large")
+
+// ErrNoURL indicates an uploader that did not report a URL.
+var ErrNoURL = errors.New("uploader returned no URL")
+
+// ErrNoUploader indicates an empty or blank uploader setting.
+var ErrNoUploader = errors.New("no uploader configured")
+
+// imageTypes are the content types Memegen and the local renderer accept.
+var imageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// IsLocal reports whether a --background value names a local file rather
+// than a URL. file:// URLs count as local.
+func IsLocal(background string) bool {
+	return background != "" &&
+		!strings.HasPrefix(background, "http://") &&
+		!strings.HasPrefix(background, "https://")
+}
+
+// LocalPath strips an optional file:// prefix from a local background.
+func LocalPath(background string) string {
+	return strings.TrimPrefix(background, "file://")
+}
+
+// Validate checks that path is a regular file of at most MaxSize bytes
+// whose content sniffs as a supported image type, and returns that type.
+func Validate(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("background: %w", err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("background %s: not a regular file", path)
+	}
+
+	if info.Size() > MaxSize {
+		return "", fmt.Errorf("background %s: %w (%d bytes, max %d)", path, ErrTooLarge, info.Size(), MaxSize)
+	}
+
+	f, err := os.Open(path) //nolint:gosec // user-provided background path
+	if err != nil {
+		return "", fmt.Errorf("background: %w", err)
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("reading background: %w", err)
+	}
+
+	ct := http.DetectContentType(head[:n])
+	if !imageTypes[ct] {
+		return "", fmt.Errorf("background %s: %w (detected %s)", path, ErrNotImage, ct)
+	}
+
+	return ct, nil
+}
+
+// Upload validates path and publishes it with uploader, returning the
+// public URL. An http(s) uploader receives the file as an HTTP PUT, with
+// "{name}" in the URL replaced by the file name; the URL is taken from the
+// Location header or else the response body. Any other uploader is a
+// command run with the file path appended as its last argument; the first
+// line of its stdout is the URL.
+func Upload(ctx context.Context, uploader, path string) (string, error) {
+	if strings.TrimSpace(uploader) == "" {
+		return "", ErrNoUploader
+	}
+
+	ct, err := Validate(path)
+	if err != nil {
+		return "", err
+	}
+
+	var raw string
+	if strings.HasPrefix(uploader, "http://") || strings.HasPrefix(uploader, "https://") {
+		raw, err = put(ctx, uploader, path, ct)
+	} else {
+		raw, err = run(ctx, uploader, path)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%w: got %q", ErrNoURL, raw)
+	}
+
+	return raw, nil
+}
+
+// put uploads path to endpoint with HTTP PUT.
+func put(ctx context.Context, endpoint, path, contentType string) (string, error) {
+	f, err := os.Open(path) //nolint:gosec // validated background path
+	if err != nil {
+		return "", fmt.Errorf("opening background: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("opening background: %w", err)
+	}
+
+	target := strings.ReplaceAll(endpoint, "{name}", url.PathEscape(filepath.Base(path)))
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, f)
+	if err != nil {
+		return "", fmt.Errorf("creating upload request: %w", err)
+	}
+
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("uploading background: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return "", fmt.Errorf("reading upload response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("uploading background: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if loc := resp.Header.Get("Location"); loc != "" {
+		return loc, nil
+	}
+
+	return firstLine(body), nil
+}
+
+// run uploads path with an uploader command.
+func run(ctx context.Context, command, path string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", ErrNoUploader
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, args[0], append(args[1:], path)...) //nolint:gosec // uploader is user config
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg != "" {
+			return "", fmt.Errorf("running uploader %s: %w: %s", args[0], err, msg)
+		}
+
+		return "", fmt.Errorf("running uploader %s: %w", args[0], err)
+	}
+
+	return firstLine(stdout.Bytes()), nil
+}
+
+// firstLine returns the first non-empty line of b, trimmed.
+func firstLine(b []byte) string {
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" {
+			return line
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,176 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePNG writes a small PNG to dir/name and returns its path.
+func writePNG(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+
+	return path
+}
+
+func TestIsLocal(t *testing.T) {
+	for in, want := range map[string]bool{
+		"":                          false,
+		"https://example.com/a.png": false,
+		"http://example.com/a.png":  false,
+		"shot.png":                  true,
+		"/tmp/shot.png":             true,
+		"file:///tmp/shot.png":      true,
+	} {
+		assert.Equal(t, want, IsLocal(in), in)
+	}
+
+	assert.Equal(t, "/tmp/shot.png", LocalPath("file:///tmp/shot.png"))
+	assert.Equal(t, "shot.png", LocalPath("shot.png"))
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+
+	ct, err := Validate(writePNG(t, dir, "ok.png"))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", ct)
+
+	text := filepath.Join(dir, "notes.png")
+	require.NoError(t, os.WriteFile(text, []byte("just text"), 0o644))
+
+	_, err = Validate(text)
+	require.ErrorIs(t, err, ErrNotImage, "type is sniffed, not taken from the extension")
+
+	big := filepath.Join(dir, "big.png")
+	require.NoError(t, os.WriteFile(big, make([]byte, MaxSize+1), 0o644))
+
+	_, err = Validate(big)
+	require.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = Validate(dir)
+	require.ErrorContains(t, err, "not a regular file")
+
+	_, err = Validate(filepath.Join(dir, "missing.png"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestUpload_HTTP(t *testing.T) {
+	var gotPath, gotType string
+
+	var gotBody []byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotType = r.Header.Get("Content-Type")
+		gotBody, _ = io.ReadAll(r.Body)
+
+		if r.URL.Query().Get("mode") == "location" {
+			w.Header().Set("Location", "https://cdn.example.com/loc.png")
+			w.WriteHeader(http.StatusCreated)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("\nhttps://cdn.example.com/body.png\n"))
+	}))
+	defer srv.Close()
+
+	path := writePNG(t, t.TempDir(), "my shot.png")
+
+	got, err := Upload(context.Background(), srv.URL+"/up/{name}", path)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/body.png", got)
+	assert.Equal(t, "/up/my shot.png", gotPath)
+	assert.Equal(t, "image/png", gotType)
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, gotBody)
+
+	got, err = Upload(context.Background(), srv.URL+"/up?mode=location", path)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/loc.png", got)
+}
+
+func TestUpload_HTTPErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/denied" {
+			http.Error(w, "nope", http.StatusForbidden)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("stored"))
+	}))
+	defer srv.Close()
+
+	path := writePNG(t, t.TempDir(), "a.png")
+
+	_, err := Upload(context.Background(), srv.URL+"/denied", path)
+	require.ErrorContains(t, err, "HTTP 403")
+
+	_, err = Upload(context.Background(), srv.URL+"/ok", path)
+	require.ErrorIs(t, err, ErrNoURL)
+
+	_, err = Upload(context.Background(), "", path)
+	require.Error(t, err)
+}
+
+func TestUpload_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "up.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"uploading $2\" >&2\necho \"https://cdn.example.com/$1/$(basename \"$2\")\"\n"), 0o755))
+
+	path := writePNG(t, dir, "shot.png")
+
+	got, err := Upload(context.Background(), script+" public", path)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/public/shot.png", got)
+
+	failing := filepath.Join(dir, "fail.sh")
+	require.NoError(t, os.WriteFile(failing, []byte("#!/bin/sh\necho 'quota exceeded' >&2\nexit 3\n"), 0o755))
+
+	_, err = Upload(context.Background(), failing, path)
+	require.ErrorContains(t, err, "quota exceeded")
+
+	// Invalid files never reach the uploader.
+	text := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(text, []byte("hello"), 0o644))
+
+	_, err = Upload(context.Background(), script, text)
+	require.ErrorIs(t, err, ErrNotImage)
+}
+
+func TestUpload_NoUploader(t *testing.T) {
+	path := writePNG(t, t.TempDir(), "shot.png")
+
+	for _, uploader := range []string{"", "  ", "\t\n"} {
+		_, err := Upload(context.Background(), uploader, path)
+		require.ErrorIs(t, err, ErrNoUploader, "uploader %q", uploader)
+	}
+
+	_, err := run(context.Background(), " ", path)
+	require.ErrorIs(t, err, ErrNoUploader, "no panic on a blank command")
+}